@@ -0,0 +1,354 @@
+//go:build arrow
+// +build arrow
+
+package insights
+
+import (
+	"io"
+	"time"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/ipc"
+	"github.com/apache/arrow/go/arrow/memory"
+)
+
+/*
+	This file contains the utilities for exchanging the datasets as Apache
+	Arrow records. It is built only with the arrow build tag so that the
+	insights don't depend on arrow unless required.
+*/
+
+//arrowKeys are the keys of the arrow field metadata storing the display
+//name, semantic, numerator and denominator of the metrics
+var arrowKeys = []string{"brain.display_name", "brain.semantic",
+	"brain.numerator", "brain.denominator"}
+
+//ToArrow converts the dataset into an arrow record. The columns are the
+//metrics sorted by their names. Float and Int metrics share their memory
+//with the record. The other metrics are copied into the memory allocated
+//from mem. Validity of the metrics is used as the null bitmaps of the
+//columns. Display name, semantic and the components of the ratios are
+//stored in the metadata of the fields. The record has to be released by
+//the caller.
+func (d Dataset) ToArrow(mem memory.Allocator) array.Record {
+	/*
+		We will convert each metric into an arrow array.
+		Then we make the schema from the fields of the arrays.
+		Then we make the record of the arrays.
+	*/
+	ms := sortedMetrics(d)
+	fields := make([]arrow.Field, len(ms))
+	cols := make([]array.Interface, len(ms))
+	for i, m := range ms {
+		cols[i] = arrowColumn(d, m, mem)
+		fields[i] = arrow.Field{Name: m.Name, Type: cols[i].DataType(),
+			Nullable: true, Metadata: arrowMetadata(m)}
+	}
+	rec := array.NewRecord(arrow.NewSchema(fields, nil), cols, d.Length)
+	//record retains the arrays
+	for _, c := range cols {
+		c.Release()
+	}
+	return rec
+}
+
+//FromArrow converts the arrow record into a dataset. Float64 and Int64
+//columns without nulls share their memory with the dataset. So they must
+//not be modified nor their memory reused while the dataset is in use. The
+//other columns are copied. It returns an error if a column is not of a
+//supported arrow type.
+func FromArrow(rec array.Record) (Dataset, error) {
+	cols := make([][]array.Interface, rec.NumCols())
+	for i := range cols {
+		cols[i] = []array.Interface{rec.Column(i)}
+	}
+	return fromArrow(rec.Schema(), cols, rec.NumRows())
+}
+
+//FromArrowTable converts the arrow table into a dataset. Columns having a
+//single chunk are converted as done by FromArrow. Columns with more chunks
+//are copied.
+func FromArrowTable(tbl array.Table) (Dataset, error) {
+	cols := make([][]array.Interface, tbl.NumCols())
+	for i := range cols {
+		cols[i] = tbl.Column(i).Data().Chunks()
+	}
+	return fromArrow(tbl.Schema(), cols, tbl.NumRows())
+}
+
+//WriteArrow writes the dataset as an arrow IPC file. The writer has to be
+//seekable like a file since the footer of the file refers to the offsets of
+//the record batches.
+func (d Dataset) WriteArrow(w io.WriteSeeker) error {
+	mem := memory.NewGoAllocator()
+	rec := d.ToArrow(mem)
+	defer rec.Release()
+	fw, err := ipc.NewFileWriter(w, ipc.WithSchema(rec.Schema()),
+		ipc.WithAllocator(mem))
+	if err != nil {
+		return &Error{ErrMWriteArrow + err.Error(), ErrCWriteFailed}
+	}
+	if err := fw.Write(rec); err != nil {
+		return &Error{ErrMWriteArrow + err.Error(), ErrCWriteFailed}
+	}
+	if err := fw.Close(); err != nil {
+		return &Error{ErrMWriteArrow + err.Error(), ErrCWriteFailed}
+	}
+	return nil
+}
+
+//ReadArrow reads the arrow IPC file into a dataset. All the record batches
+//in the file are read as a table and converted as done by FromArrowTable.
+func ReadArrow(r ipc.ReadAtSeeker) (Dataset, error) {
+	/*
+		We will read all the record batches in the file.
+		Then we make a table of the batches and convert it.
+	*/
+	mem := memory.NewGoAllocator()
+	fr, err := ipc.NewFileReader(r, ipc.WithAllocator(mem))
+	if err != nil {
+		return NewDataset(), &Error{ErrMReadArrow + err.Error(), ErrCReadFailed}
+	}
+	defer fr.Close()
+	recs := make([]array.Record, 0, fr.NumRecords())
+	for i := 0; i < fr.NumRecords(); i++ {
+		rec, err := fr.Record(i)
+		if err != nil {
+			return NewDataset(), &Error{ErrMReadArrow + err.Error(), ErrCReadFailed}
+		}
+		//reader releases the record on reading the next one
+		rec.Retain()
+		defer rec.Release()
+		recs = append(recs, rec)
+	}
+	tbl := array.NewTableFromRecords(fr.Schema(), recs)
+	defer tbl.Release()
+	return FromArrowTable(tbl)
+}
+
+//arrowMetadata returns the field metadata having the details of the metric
+func arrowMetadata(m Metric) arrow.Metadata {
+	keys := []string{}
+	vals := []string{}
+	for i, v := range []string{m.DisplayName, m.Semantic, m.Numerator,
+		m.Denominator} {
+		if len(v) > 0 {
+			keys = append(keys, arrowKeys[i])
+			vals = append(vals, v)
+		}
+	}
+	return arrow.NewMetadata(keys, vals)
+}
+
+//arrowMetric returns the metric of the arrow field
+func arrowMetric(f arrow.Field) Metric {
+	m := Metric{Name: f.Name}
+	for i, v := range []*string{&m.DisplayName, &m.Semantic, &m.Numerator,
+		&m.Denominator} {
+		if j := f.Metadata.FindKey(arrowKeys[i]); j >= 0 {
+			*v = f.Metadata.Values()[j]
+		}
+	}
+	return m
+}
+
+//arrowColumn returns the arrow array of the metric in the dataset
+func arrowColumn(d Dataset, m Metric, mem memory.Allocator) array.Interface {
+	/*
+		Float and int metrics are wrapped into arrays without copying.
+		The other metrics are built with the builders.
+	*/
+	n := int(d.Length)
+	bits, nulls := d.Validity[m.Name], 0
+	var bitmap *memory.Buffer
+	if bits != nil {
+		nulls = n - bits.Count()
+		bitmap = memory.NewBufferBytes(arrow.Uint64Traits.CastToBytes(bits))
+	}
+	switch m.DataType {
+	case Float:
+		return array.NewFloat64Data(array.NewData(arrow.PrimitiveTypes.Float64,
+			n, []*memory.Buffer{bitmap, memory.NewBufferBytes(
+				arrow.Float64Traits.CastToBytes(d.DataF[m.Index]))}, nil, nulls, 0))
+	case Int:
+		return array.NewInt64Data(array.NewData(arrow.PrimitiveTypes.Int64,
+			n, []*memory.Buffer{bitmap, memory.NewBufferBytes(
+				arrow.Int64Traits.CastToBytes(d.DataI[m.Index]))}, nil, nulls, 0))
+	}
+	var valid []bool
+	if bits != nil {
+		valid = make([]bool, n)
+		for i := range valid {
+			valid[i] = bits.Get(i)
+		}
+	}
+	switch m.DataType {
+	case Bool:
+		b := array.NewBooleanBuilder(mem)
+		defer b.Release()
+		b.AppendValues(d.DataB[m.Index], valid)
+		return b.NewArray()
+	case Time:
+		b := array.NewTimestampBuilder(mem, &arrow.TimestampType{
+			Unit: arrow.Nanosecond, TimeZone: "UTC"})
+		defer b.Release()
+		ts := make([]arrow.Timestamp, n)
+		for i, t := range d.DataT[m.Index] {
+			ts[i] = arrow.Timestamp(t.UnixNano())
+		}
+		b.AppendValues(ts, valid)
+		return b.NewArray()
+	}
+	b := array.NewStringBuilder(mem)
+	defer b.Release()
+	b.AppendValues(d.DataS[m.Index], valid)
+	return b.NewArray()
+}
+
+//fromArrow converts the chunks of the columns having the schema into a
+//dataset of n records
+func fromArrow(schema *arrow.Schema, cols [][]array.Interface, n int64) (Dataset, error) {
+	d := NewDataset()
+	for i, f := range schema.Fields() {
+		m := arrowMetric(f)
+		dt, ok := arrowType(f.Type)
+		if !ok {
+			return d, &Error{ErrMDAddMetricUnsupportedType + f.Type.Name(),
+				ErrCUnsupportedDataType}
+		}
+		m.DataType = dt
+		data, valid := arrowData(dt, cols[i], int(n))
+		var err error
+		if valid == nil {
+			err = d.AddMetric(m, data)
+		} else {
+			err = d.AddNullableMetric(m, data, valid)
+		}
+		if err != nil {
+			return d, err
+		}
+	}
+	return d, nil
+}
+
+//arrowType returns the data type of the metric for the arrow type. Arrow
+//types with no metric data type return false.
+func arrowType(t arrow.DataType) (string, bool) {
+	switch t.ID() {
+	case arrow.FLOAT64, arrow.FLOAT32, arrow.INT8, arrow.INT16, arrow.INT32,
+		arrow.UINT8, arrow.UINT16, arrow.UINT32, arrow.UINT64:
+		return Float, true
+	case arrow.INT64:
+		return Int, true
+	case arrow.BOOL:
+		return Bool, true
+	case arrow.STRING:
+		return String, true
+	case arrow.TIMESTAMP, arrow.DATE32, arrow.DATE64:
+		return Time, true
+	}
+	return "", false
+}
+
+//arrowData returns the data array of the data type from the chunks of a
+//column. Single chunks of float64 and int64 without nulls are returned
+//without copying. Validities are nil if none of the values are null.
+func arrowData(dt string, chunks []array.Interface, n int) (interface{}, []bool) {
+	if len(chunks) == 1 && chunks[0].NullN() == 0 {
+		switch c := chunks[0].(type) {
+		case *array.Float64:
+			return c.Float64Values(), nil
+		case *array.Int64:
+			return c.Int64Values(), nil
+		}
+	}
+	valid := make([]bool, n)
+	nulls := false
+	var fs []float64
+	var is []int64
+	var bs []bool
+	var ts []time.Time
+	var ss []string
+	switch dt {
+	case Float:
+		fs = make([]float64, n)
+	case Int:
+		is = make([]int64, n)
+	case Bool:
+		bs = make([]bool, n)
+	case Time:
+		ts = make([]time.Time, n)
+	default:
+		ss = make([]string, n)
+	}
+	k := 0
+	for _, c := range chunks {
+		for j := 0; j < c.Len(); j, k = j+1, k+1 {
+			if valid[k] = c.IsValid(j); !valid[k] {
+				nulls = true
+				continue
+			}
+			switch a := c.(type) {
+			case *array.Float64:
+				fs[k] = a.Value(j)
+			case *array.Float32:
+				fs[k] = float64(a.Value(j))
+			case *array.Int8:
+				fs[k] = float64(a.Value(j))
+			case *array.Int16:
+				fs[k] = float64(a.Value(j))
+			case *array.Int32:
+				fs[k] = float64(a.Value(j))
+			case *array.Uint8:
+				fs[k] = float64(a.Value(j))
+			case *array.Uint16:
+				fs[k] = float64(a.Value(j))
+			case *array.Uint32:
+				fs[k] = float64(a.Value(j))
+			case *array.Uint64:
+				fs[k] = float64(a.Value(j))
+			case *array.Int64:
+				is[k] = a.Value(j)
+			case *array.Boolean:
+				bs[k] = a.Value(j)
+			case *array.String:
+				ss[k] = a.Value(j)
+			case *array.Timestamp:
+				ts[k] = arrowTime(int64(a.Value(j)),
+					a.DataType().(*arrow.TimestampType).Unit)
+			case *array.Date32:
+				ts[k] = time.Unix(int64(a.Value(j))*86400, 0).UTC()
+			case *array.Date64:
+				ts[k] = arrowTime(int64(a.Value(j)), arrow.Millisecond)
+			}
+		}
+	}
+	if !nulls {
+		valid = nil
+	}
+	switch dt {
+	case Float:
+		return fs, valid
+	case Int:
+		return is, valid
+	case Bool:
+		return bs, valid
+	case Time:
+		return ts, valid
+	}
+	return ss, valid
+}
+
+//arrowTime returns the time of the arrow timestamp in the unit
+func arrowTime(v int64, unit arrow.TimeUnit) time.Time {
+	switch unit {
+	case arrow.Second:
+		return time.Unix(v, 0).UTC()
+	case arrow.Millisecond:
+		return time.Unix(0, v*int64(time.Millisecond)).UTC()
+	case arrow.Microsecond:
+		return time.Unix(0, v*int64(time.Microsecond)).UTC()
+	}
+	return time.Unix(0, v).UTC()
+}
@@ -0,0 +1,107 @@
+//go:build arrow
+// +build arrow
+
+package insights
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/arrow/memory"
+)
+
+/*
+	This file contains the tests for the arrow interop
+*/
+
+//arrowDataset returns a dataset with the metrics of all the data types
+//having missing values in amount and city
+func arrowDataset() Dataset {
+	d := NewDataset()
+	d.AddMetric(Metric{Name: "day", DataType: Time, Semantic: SemanticTime},
+		[]time.Time{time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+			time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC)})
+	d.AddMetric(Metric{Name: "units", DataType: Int}, []int64{3, 4, 5})
+	d.AddMetric(Metric{Name: "paid", DataType: Bool}, []bool{true, false, true})
+	d.AddNullableMetric(Metric{Name: "amount", DisplayName: "Amount",
+		DataType: Float, Semantic: SemanticMonetary}, []float64{1.5, 0, 3},
+		[]bool{true, false, true})
+	d.AddNullableMetric(Metric{Name: "city", DataType: String},
+		[]string{"Kochi", "Pune", ""}, []bool{true, true, false})
+	d.AddMetric(Metric{Name: "score", DataType: Float}, []float64{7, 8, 9})
+	return d
+}
+
+func TestDataset_ToArrow(t *testing.T) {
+	d := arrowDataset()
+	rec := d.ToArrow(memory.NewGoAllocator())
+	defer rec.Release()
+	if rec.NumRows() != 3 || rec.NumCols() != 6 {
+		t.Fatal("Expected 3 rows of 6 columns. Got", rec.NumRows(), rec.NumCols())
+	}
+	if rec.ColumnName(0) != "amount" || rec.Column(0).NullN() != 1 {
+		t.Fatal("Expected amount as the first column with 1 null. Got",
+			rec.ColumnName(0), rec.Column(0).NullN())
+	}
+
+	r, err := FromArrow(rec)
+	if err != nil {
+		t.Fatal("Error while converting the record", err)
+	}
+	for name, m := range d.Metrics {
+		rm, ok := r.Metrics[name]
+		if !ok || rm.DataType != m.DataType || rm.DisplayName != m.DisplayName ||
+			rm.Semantic != m.Semantic {
+			t.Fatal("Expected the metric", m, "Got", rm, ok)
+		}
+		if r.NullCount(name) != d.NullCount(name) {
+			t.Fatal("Expected", d.NullCount(name), "missing values in", name,
+				"Got", r.NullCount(name))
+		}
+	}
+	if a := r.DataF[r.Metrics["amount"].Index]; a[0] != 1.5 || !math.IsNaN(a[1]) {
+		t.Fatal("Expected the amounts [1.5 NaN 3]. Got", a)
+	}
+	if day := r.DataT[r.Metrics["day"].Index][2]; !day.Equal(
+		d.DataT[d.Metrics["day"].Index][2]) {
+		t.Fatal("Expected the day 2019-01-03. Got", day)
+	}
+
+	//score has no missing values. so it should share the memory.
+	score := d.DataF[d.Metrics["score"].Index]
+	if rs := r.DataF[r.Metrics["score"].Index]; &rs[0] != &score[0] {
+		t.Fatal("Expected the score to be shared without copying")
+	}
+}
+
+func TestDataset_WriteArrow(t *testing.T) {
+	d := arrowDataset()
+	f, err := ioutil.TempFile("", "brain")
+	if err != nil {
+		t.Fatal("Error while creating the arrow file", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if err := d.WriteArrow(f); err != nil {
+		t.Fatal("Error while writing the arrow file", err)
+	}
+	r, err := ReadArrow(f)
+	if err != nil {
+		t.Fatal("Error while reading the arrow file", err)
+	}
+	if r.Length != d.Length || len(r.Metrics) != len(d.Metrics) {
+		t.Fatal("Expected", d.Length, "records of", len(d.Metrics), "metrics. Got",
+			r.Length, len(r.Metrics))
+	}
+	if c := r.DataS[r.Metrics["city"].Index]; c[1] != "Pune" || r.NullCount("city") != 1 {
+		t.Fatal("Expected the cities with 1 missing. Got", c)
+	}
+	if _, err := ReadArrow(bytes.NewReader([]byte("not arrow"))); err == nil {
+		t.Fatal("Expected an error for an invalid file. Got nil")
+	}
+}
@@ -0,0 +1,377 @@
+package insights
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cuttle-ai/brain/visualizations"
+)
+
+/*
+	This file contains the utilities and structs required for market basket
+	insights
+*/
+
+const (
+	//minBasketTransactions is the minimum no. of transactions required for
+	//finding the association rules
+	minBasketTransactions = 20
+	//minBasketSupport is the minimum ratio of the transactions in which an
+	//itemset should occur for it to be frequent
+	minBasketSupport = 0.01
+	//minBasketCount is the minimum no. of transactions in which an itemset
+	//should occur for it to be frequent
+	minBasketCount = 2
+	//minConfidence is the minimum confidence of a rule to be reported
+	minConfidence = 0.3
+	//minLift is the minimum lift of a rule to be reported
+	minLift = 1.5
+	//maxItemsetSize is the maximum no. of items in the itemsets mined
+	maxItemsetSize = 3
+	//maxRules is the maximum no. of rules reported
+	maxRules = 20
+)
+
+//AssociationRule is a rule that the transactions having the antecedent
+//items also have the consequent item
+type AssociationRule struct {
+	Antecedent []string //Antecedent has the items in the condition of the rule
+	Consequent string   //Consequent is the item implied by the rule
+	//Support is the ratio of the transactions having all the items of the rule
+	Support float64
+	//Confidence is the ratio of the transactions having the antecedent which
+	//also have the consequent
+	Confidence float64
+	//Lift is the ratio of the confidence to the ratio of the transactions
+	//having the consequent. Lift above 1 means that the items are bought
+	//together more often than by chance.
+	Lift float64
+}
+
+//Basket is the market basket insight.
+//It finds the association rules between the items of the transactions using
+//the apriori algorithm and reports the rules with high lift.
+type Basket struct {
+	//visual has the visualization to be used for showing the rules.
+	//Table of the rules is used.
+	visual visualizations.Visual
+	//relevant stores the information whether the insight is relevant or not.
+	//This property is updated after running methods like FSFA and Generate
+	relevant bool
+	dt       Dataset //dt is the dataset having the transactions
+	//ms is the list of metrics. They are the transaction id and the item in
+	//the order.
+	ms    []Metric
+	rules []AssociationRule //rules has the rules sorted by their lift
+	//transactions is the no. of distinct transactions in the dataset
+	transactions int
+}
+
+//New returns a new instance of the Basket with
+//initializations done for the given dataset
+func (b *Basket) New(d Dataset, ms []Metric) Insight {
+	return &Basket{dt: d, ms: ms}
+}
+
+//Visual returns the visualization to be used for visualizing the rules
+func (b *Basket) Visual() visualizations.Visual {
+	return b.visual
+}
+
+//Type returns the type string for the market basket type of insight
+func (b *Basket) Type() string {
+	return BASKET
+}
+
+//Relevant returns whether the insight is relevant or not for the given dataset.
+func (b *Basket) Relevant() bool {
+	return b.relevant
+}
+
+//Rules returns the association rules sorted by their lift
+func (b *Basket) Rules() []AssociationRule {
+	return b.rules
+}
+
+//Transactions returns the no. of distinct transactions in the dataset
+func (b *Basket) Transactions() int {
+	return b.transactions
+}
+
+//FSFA does the fast statistical feasibilty analysis over the dataset
+//with the given metrics whether the association rules can be mined.
+//It requires the metrics having the transaction id and the item.
+func (b *Basket) FSFA() {
+	if len(b.ms) != 2 || b.ms[0].Semantic != SemanticTransaction ||
+		b.ms[1].Semantic != SemanticItem {
+		b.relevant = false
+		return
+	}
+	b.relevant = b.dt.Length >= minBasketTransactions
+}
+
+//Generate generates the market basket insight for the datatset
+//associated with it for the provided metrics.
+//This method can only be run after running the FSFA.
+//Else the insight won't be generated
+func (b *Basket) Generate() {
+	/*
+		If the insight is not relevant we won't event bother
+		to go forward.
+		We will group the items of the records into the transactions.
+		Then we mine the frequent itemsets using apriori.
+		Then we find the rules from the frequent itemsets having
+		the minimum confidence and lift.
+	*/
+	//Checking whether the existing relevance of the insight
+	if !b.relevant {
+		return
+	}
+
+	//grouping the items into transactions
+	txs, ok := b.baskets()
+	if !ok || len(txs) < minBasketTransactions {
+		b.relevant = false
+		return
+	}
+	b.transactions = len(txs)
+
+	//mining the frequent itemsets
+	minCount := int(math.Ceil(minBasketSupport * float64(len(txs))))
+	if minCount < minBasketCount {
+		minCount = minBasketCount
+	}
+	counts := apriori(txs, minCount, maxItemsetSize)
+
+	//finding the rules
+	b.rules = associationRules(counts, len(txs))
+	if len(b.rules) == 0 {
+		b.relevant = false
+		return
+	}
+	b.relevant = true
+	b.visual = b.table()
+}
+
+//baskets returns the distinct items of each transaction in the dataset
+//as sorted lists. Records without the transaction id or the item are skipped.
+func (b *Basket) baskets() ([][]string, bool) {
+	ids, ok1 := keys(b.dt, b.ms[0])
+	items, ok2 := keys(b.dt, b.ms[1])
+	if !ok1 || !ok2 {
+		return nil, false
+	}
+	index := map[string]int{}
+	sets := []map[string]bool{}
+	for i, id := range ids {
+		if len(id) == 0 || len(items[i]) == 0 {
+			continue
+		}
+		t, ok := index[id]
+		if !ok {
+			t = len(sets)
+			index[id] = t
+			sets = append(sets, map[string]bool{})
+		}
+		sets[t][items[i]] = true
+	}
+	txs := make([][]string, len(sets))
+	for i, s := range sets {
+		for item := range s {
+			txs[i] = append(txs[i], item)
+		}
+		sort.Strings(txs[i])
+	}
+	return txs, true
+}
+
+//table returns the table of the association rules
+func (b *Basket) table() visualizations.Table {
+	top := b.rules[0]
+	visual := visualizations.Table{
+		T: strconv.Itoa(len(b.rules)) + " rules found between the items of " +
+			strconv.Itoa(b.transactions) + " transactions",
+		D: "Transactions having " + strings.Join(top.Antecedent, ", ") +
+			" also have " + top.Consequent + " " +
+			formatFloat(top.Confidence*100) + "% of the time, " +
+			formatFloat(top.Lift) + " times more often than other transactions",
+		M: []visualizations.Metric{
+			{Name: "antecedent", DisplayName: "Items", DataType: String,
+				Dimension: 0},
+			{Name: "consequent", DisplayName: "Also bought", DataType: String,
+				Dimension: 1},
+			{Name: "support", DisplayName: "Support", DataType: Float,
+				Dimension: 2, PostplacementUnit: "%"},
+			{Name: "confidence", DisplayName: "Confidence", DataType: Float,
+				Dimension: 3, PostplacementUnit: "%"},
+			{Name: "lift", DisplayName: "Lift", DataType: Float, Dimension: 4},
+		},
+	}
+	data := make([]map[string]interface{}, len(b.rules))
+	for i, r := range b.rules {
+		data[i] = map[string]interface{}{
+			"antecedent": strings.Join(r.Antecedent, ", "),
+			"consequent": r.Consequent,
+			"support":    math.Round(r.Support*10000) / 100,
+			"confidence": math.Round(r.Confidence*10000) / 100,
+			"lift":       math.Round(r.Lift*100) / 100,
+		}
+	}
+	visual.Dt = data
+	return visual
+}
+
+//Propose suggests the possible insights from the domain knowledge.
+//Datasets having the transaction id and the item are proposed for the
+//market basket analysis.
+func (b *Basket) Propose(d Dataset) []ProposedInsight {
+	t, ok1 := metricWithSemantic(d, SemanticTransaction)
+	i, ok2 := metricWithSemantic(d, SemanticItem)
+	if !ok1 || !ok2 {
+		return []ProposedInsight{}
+	}
+	ms := []Metric{t, i}
+	return []ProposedInsight{{b.New(d, ms), ms}}
+}
+
+//itemsetKey returns the key of the sorted itemset used in the maps
+func itemsetKey(items []string) string {
+	return strings.Join(items, "\x00")
+}
+
+//itemsetItems returns the sorted itemset of the key
+func itemsetItems(key string) []string {
+	return strings.Split(key, "\x00")
+}
+
+//apriori returns the no. of transactions having each frequent itemset with
+//upto maxSize items. Itemsets in atleast minCount transactions are frequent.
+//Items of the transactions should be sorted.
+func apriori(txs [][]string, minCount, maxSize int) map[string]int {
+	/*
+		We start with the frequent items.
+		Candidates of size k are made by joining the frequent itemsets of
+		size k-1 having the same first k-2 items. Candidates having an
+		infrequent subset are pruned.
+		Then we count the transactions having the candidates.
+		We stop when there are no frequent itemsets of a size.
+	*/
+	result := map[string]int{}
+
+	//frequent items
+	counts := map[string]int{}
+	for _, tx := range txs {
+		for _, item := range tx {
+			counts[item]++
+		}
+	}
+	level := [][]string{}
+	for item, c := range counts {
+		if c >= minCount {
+			result[item] = c
+			level = append(level, []string{item})
+		}
+	}
+
+	//frequent itemsets of higher sizes
+	sets := make([]map[string]bool, len(txs))
+	for i, tx := range txs {
+		sets[i] = map[string]bool{}
+		for _, item := range tx {
+			sets[i][item] = true
+		}
+	}
+	for k := 2; k <= maxSize && len(level) > 1; k++ {
+		sort.Slice(level, func(i, j int) bool {
+			return itemsetKey(level[i]) < itemsetKey(level[j])
+		})
+		candidates := [][]string{}
+		for i := range level {
+			for j := i + 1; j < len(level); j++ {
+				if itemsetKey(level[i][:k-2]) != itemsetKey(level[j][:k-2]) {
+					break
+				}
+				c := append(append([]string{}, level[i]...), level[j][k-2])
+				if frequentSubsets(c, result) {
+					candidates = append(candidates, c)
+				}
+			}
+		}
+		level = [][]string{}
+		for _, c := range candidates {
+			n := 0
+			for _, s := range sets {
+				if containsAll(s, c) {
+					n++
+				}
+			}
+			if n >= minCount {
+				result[itemsetKey(c)] = n
+				level = append(level, c)
+			}
+		}
+	}
+	return result
+}
+
+//frequentSubsets returns whether all the subsets of the itemset with one
+//item less are frequent
+func frequentSubsets(items []string, frequent map[string]int) bool {
+	for i := range items {
+		sub := append(append([]string{}, items[:i]...), items[i+1:]...)
+		if _, ok := frequent[itemsetKey(sub)]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+//containsAll returns whether the set has all the items
+func containsAll(set map[string]bool, items []string) bool {
+	for _, item := range items {
+		if !set[item] {
+			return false
+		}
+	}
+	return true
+}
+
+//associationRules returns the rules having a single consequent from the
+//frequent itemsets with atleast minConfidence and minLift. Rules are sorted by
+//their lift and atmost maxRules rules are returned.
+func associationRules(counts map[string]int, n int) []AssociationRule {
+	rules := []AssociationRule{}
+	for key, c := range counts {
+		items := itemsetItems(key)
+		if len(items) < 2 {
+			continue
+		}
+		for i, item := range items {
+			ante := append(append([]string{}, items[:i]...), items[i+1:]...)
+			conf := float64(c) / float64(counts[itemsetKey(ante)])
+			lift := conf / (float64(counts[item]) / float64(n))
+			if conf < minConfidence || lift < minLift {
+				continue
+			}
+			rules = append(rules, AssociationRule{ante, item,
+				float64(c) / float64(n), conf, lift})
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Lift != rules[j].Lift {
+			return rules[i].Lift > rules[j].Lift
+		}
+		if rules[i].Confidence != rules[j].Confidence {
+			return rules[i].Confidence > rules[j].Confidence
+		}
+		ki := itemsetKey(rules[i].Antecedent) + "\x01" + rules[i].Consequent
+		kj := itemsetKey(rules[j].Antecedent) + "\x01" + rules[j].Consequent
+		return ki < kj
+	})
+	if len(rules) > maxRules {
+		rules = rules[:maxRules]
+	}
+	return rules
+}
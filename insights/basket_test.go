@@ -0,0 +1,167 @@
+package insights
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/cuttle-ai/brain/visualizations"
+)
+
+/*
+	This file contains the tests for the market basket insight
+*/
+
+//basketMetrics are the metrics of the transaction datasets
+var basketMetrics = []Metric{
+	{Name: "order", DataType: String, Semantic: SemanticTransaction},
+	{Name: "product", DataType: String, Semantic: SemanticItem},
+}
+
+//orders returns 40 orders. 10 of them have bread and butter, 5 only bread,
+//10 beer and chips and the rest only milk.
+func orders() Dataset {
+	ids, items := []string{}, []string{}
+	add := func(id int, products ...string) {
+		for _, p := range products {
+			ids = append(ids, strconv.Itoa(id))
+			items = append(items, p)
+		}
+	}
+	for i := 0; i < 40; i++ {
+		switch {
+		case i < 10:
+			add(i, "bread", "butter", "bread")
+		case i < 15:
+			add(i, "bread")
+		case i < 25:
+			add(i, "chips", "beer")
+		default:
+			add(i, "milk")
+		}
+	}
+	d := NewDataset()
+	d.AddMetric(basketMetrics[0], ids)
+	d.AddMetric(basketMetrics[1], items)
+	return d
+}
+
+func TestBasket_New(t *testing.T) {
+	d := orders()
+	bi := (&Basket{}).New(d, basketMetrics)
+	b, ok := bi.(*Basket)
+	if !ok {
+		t.Fatal("Expected a basket. Got", reflect.TypeOf(bi))
+	}
+	if len(b.ms) != 2 {
+		t.Fatal("Expected 2 metrics. Got", len(b.ms))
+	}
+}
+
+func TestBasket_Type(t *testing.T) {
+	b := &Basket{}
+	if b.Type() != BASKET {
+		t.Fatal("Expected insight type is", BASKET, "Got", b.Type())
+	}
+}
+
+func TestBasket_FSFA(t *testing.T) {
+	d := orders()
+	b := &Basket{dt: d, ms: basketMetrics[:1]}
+	b.FSFA()
+	if b.Relevant() {
+		t.Fatal("Expected basket to be irrelevant without item metric.",
+			"Got it as relevant")
+	}
+	b = &Basket{dt: d, ms: basketMetrics}
+	b.FSFA()
+	if !b.Relevant() {
+		t.Fatal("Expected basket to be relevant with normal conditions.",
+			"Got it as irrelevant")
+	}
+}
+
+func TestBasket_Generate(t *testing.T) {
+	t.Run("Testing generate with frequent itemsets", func(t *testing.T) {
+		d := orders()
+		b := (&Basket{}).Propose(d)[0].I.(*Basket)
+		b.FSFA()
+		b.Generate()
+		if !b.Relevant() {
+			t.Fatal("Expected basket to be relevant. Got irrelevant")
+		}
+		if b.Transactions() != 40 {
+			t.Fatal("Expected 40 transactions. Got", b.Transactions())
+		}
+		rules := b.Rules()
+		if len(rules) != 4 {
+			t.Fatal("Expected 4 rules. Got", rules)
+		}
+		expected := AssociationRule{[]string{"beer"}, "chips", 0.25, 1, 4}
+		if !reflect.DeepEqual(rules[0], expected) {
+			t.Fatal("Expected top rule", expected, "Got", rules[0])
+		}
+		if rules[3].Antecedent[0] != "bread" || rules[3].Consequent != "butter" ||
+			rules[3].Confidence != 10.0/15 {
+			t.Fatal("Expected bread implying butter with 66.67% confidence.",
+				"Got", rules[3])
+		}
+		tb, ok := b.Visual().(visualizations.Table)
+		if !ok {
+			t.Fatal("Expected a table. Got", reflect.TypeOf(b.Visual()))
+		}
+		if len(tb.Data()) != 4 {
+			t.Fatal("Expected 4 rows in the table. Got", len(tb.Data()))
+		}
+	})
+
+	t.Run("Testing generate with independent items", func(t *testing.T) {
+		ids, items := []string{}, []string{}
+		for i := 0; i < 40; i++ {
+			ids = append(ids, strconv.Itoa(i))
+			items = append(items, "item"+strconv.Itoa(i%4))
+		}
+		d := NewDataset()
+		d.AddMetric(basketMetrics[0], ids)
+		d.AddMetric(basketMetrics[1], items)
+		b := &Basket{dt: d, ms: basketMetrics}
+		b.FSFA()
+		b.Generate()
+		if b.Relevant() {
+			t.Fatal("Expected basket to be irrelevant without rules.",
+				"Got relevant")
+		}
+	})
+}
+
+func TestBasket_Propose(t *testing.T) {
+	d := orders()
+	if pro := (&Basket{}).Propose(d); len(pro) != 1 {
+		t.Fatal("Expected 1 proposal. Got", len(pro))
+	}
+	d = NewDataset()
+	d.AddMetric(Metric{Name: "order", DataType: String}, []string{"a"})
+	if pro := (&Basket{}).Propose(d); len(pro) != 0 {
+		t.Fatal("Expected no proposals. Got", len(pro))
+	}
+}
+
+func TestApriori(t *testing.T) {
+	txs := [][]string{
+		{"a", "b", "c"},
+		{"a", "b", "c"},
+		{"a", "b"},
+		{"c", "d"},
+	}
+	counts := apriori(txs, 2, 3)
+	expected := map[string]int{
+		"a": 3, "b": 3, "c": 3,
+		itemsetKey([]string{"a", "b"}):      3,
+		itemsetKey([]string{"a", "c"}):      2,
+		itemsetKey([]string{"b", "c"}):      2,
+		itemsetKey([]string{"a", "b", "c"}): 2,
+	}
+	if !reflect.DeepEqual(counts, expected) {
+		t.Fatal("Expected itemsets", expected, "Got", counts)
+	}
+}
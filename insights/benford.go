@@ -0,0 +1,267 @@
+package insights
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/cuttle-ai/brain/visualizations"
+	"github.com/gonum/stat"
+	"github.com/gonum/stat/distuv"
+)
+
+/*
+	This file contains the utilities and structs required for benford's law
+	conformity insights
+*/
+
+const (
+	//minBenfordRecords is the minimum no. of non zero values required in a
+	//metric for testing its conformity to benford's law
+	minBenfordRecords = 100
+	//minBenfordMagnitudes is the minimum no. of orders of magnitude the values
+	//of a metric should span for benford's law to be applicable
+	minBenfordMagnitudes = 2
+)
+
+const (
+	//ConformityClose denotes that the first digits of the metric closely
+	//conform to benford's law
+	ConformityClose = "close conformity"
+	//ConformityAcceptable denotes that the first digits of the metric
+	//acceptably conform to benford's law
+	ConformityAcceptable = "acceptable conformity"
+	//ConformityMarginal denotes that the first digits of the metric
+	//marginally conform to benford's law
+	ConformityMarginal = "marginally acceptable conformity"
+	//ConformityNone denotes that the first digits of the metric doesn't
+	//conform to benford's law
+	ConformityNone = "nonconformity"
+)
+
+//Benford is the benford's law conformity insight.
+//It tests whether the first digits of a metric follow benford's law.
+//Monetary metrics not following the law are often worth investigating for
+//fraud or errors.
+type Benford struct {
+	//visual has the visualization to be used for showing the observed and
+	//the expected frequencies of the first digits. Bar chart is used.
+	visual visualizations.Visual
+	//relevant stores the information whether the insight is relevant or not.
+	//This property is updated after running methods like FSFA and Generate
+	relevant bool
+	dt       Dataset  //dt is the dataset to be used for the insight
+	ms       []Metric //ms has the metric to be tested
+	//chiSquare is the chi-square statistic of the first digit frequencies
+	chiSquare float64
+	pValue    float64 //pValue is the p-value of the chi-square test
+	//mad is the mean absolute deviation of the first digit proportions from
+	//the expected proportions
+	mad        float64
+	conformity string //conformity is the conformity level as per the mad
+}
+
+//New returns a new instance of the Benford with
+//initializations done for the given dataset
+func (b *Benford) New(d Dataset, ms []Metric) Insight {
+	return &Benford{dt: d, ms: ms}
+}
+
+//Visual returns the visualization to be used for visualizing the insight
+func (b *Benford) Visual() visualizations.Visual {
+	return b.visual
+}
+
+//Type returns the type string for the benford type of insight
+func (b *Benford) Type() string {
+	return BENFORD
+}
+
+//Relevant returns whether the insight is relevant or not for the given dataset.
+func (b *Benford) Relevant() bool {
+	return b.relevant
+}
+
+//ChiSquare returns the chi-square statistic of the first digit frequencies
+func (b *Benford) ChiSquare() float64 {
+	return b.chiSquare
+}
+
+//PValue returns the p-value of the chi-square test
+func (b *Benford) PValue() float64 {
+	return b.pValue
+}
+
+//MAD returns the mean absolute deviation of the first digit proportions
+//from the proportions expected by benford's law
+func (b *Benford) MAD() float64 {
+	return b.mad
+}
+
+//Conformity returns the conformity level of the metric to benford's law.
+//It is one of ConformityClose, ConformityAcceptable, ConformityMarginal and
+//ConformityNone.
+func (b *Benford) Conformity() string {
+	return b.conformity
+}
+
+//FSFA does the fast statistical feasibilty analysis over the dataset
+//with the given metric whether benford's law can be tested.
+//It requires a float metric with atleast minBenfordRecords no. of records.
+func (b *Benford) FSFA() {
+	/*
+		Will check whether there is only one metric and is of float data type.
+		Then we check whether there are sufficient records
+	*/
+	//checking the metric
+	if len(b.ms) != 1 || b.ms[0].DataType != Float {
+		b.relevant = false
+		return
+	}
+
+	//checking the no. of records
+	if b.dt.Length < minBenfordRecords {
+		b.relevant = false
+		return
+	}
+
+	//Everything is fine
+	b.relevant = true
+}
+
+//Generate generates the benford's law conformity insight for the datatset
+//associated with it for the provided metric. The insight is relevant only if
+//the metric doesn't conform to the law.
+//This method can only be run after running the FSFA.
+//Else the insight won't be generated
+func (b *Benford) Generate() {
+	/*
+		If the insight is not relevant we won't event bother
+		to go forward.
+		We will find the first digits of the non zero values of the metric.
+		If the values doesn't span over minBenfordMagnitudes orders of
+		magnitude, the law isn't applicable.
+		Then we do the chi-square test and find the mean absolute deviation.
+		Metric is reported if it doesn't conform to the law.
+	*/
+	//Checking whether the existing relevance of the insight
+	if !b.relevant {
+		return
+	}
+	m := b.ms[0]
+	if m.Index >= len(b.dt.DataF) {
+		b.relevant = false
+		return
+	}
+
+	//finding the first digits
+	counts := make([]float64, 9)
+	n := 0.0
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, v := range b.dt.DataF[m.Index] {
+		d, ok := firstDigit(v)
+		if !ok {
+			continue
+		}
+		counts[d-1]++
+		n++
+		min = math.Min(min, math.Abs(v))
+		max = math.Max(max, math.Abs(v))
+	}
+	if n < minBenfordRecords || math.Log10(max/min) < minBenfordMagnitudes {
+		b.relevant = false
+		return
+	}
+
+	//doing the chi-square test and finding the mean absolute deviation
+	expected := make([]float64, 9)
+	for i := range expected {
+		expected[i] = n * math.Log10(1+1/float64(i+1))
+	}
+	b.chiSquare = stat.ChiSquare(counts, expected)
+	b.pValue = distuv.ChiSquared{K: 8}.Survival(b.chiSquare)
+	b.mad = 0
+	for i := range counts {
+		b.mad += math.Abs(counts[i]-expected[i]) / n
+	}
+	b.mad /= 9
+	b.conformity = conformity(b.mad)
+	if b.conformity != ConformityNone {
+		b.relevant = false
+		return
+	}
+
+	b.relevant = true
+	b.visual = b.bar(counts, expected, n)
+}
+
+//bar returns the bar chart of the observed and the expected frequencies of
+//the first digits
+func (b *Benford) bar(counts, expected []float64, n float64) visualizations.BarChart {
+	m := b.ms[0]
+	name := displayName(m)
+	visual := visualizations.BarChart{
+		T: "First digits of " + name + " doesn't follow Benford's law",
+		D: "Mean absolute deviation from Benford's law is " +
+			strconv.FormatFloat(b.mad, 'f', 4, 64) + " and the chi-square " +
+			"statistic is " + formatFloat(b.chiSquare) + " with p-value " +
+			strconv.FormatFloat(b.pValue, 'g', 4, 64),
+		M: []visualizations.Metric{
+			{Name: "digit", DisplayName: "First digit", DataType: String,
+				Dimension: 0},
+			{Name: "observed", DisplayName: "Observed", DataType: Float,
+				Dimension: 1},
+			{Name: "expected", DisplayName: "Expected", DataType: Float,
+				Dimension: 1},
+		},
+	}
+	data := make([]map[string]interface{}, 9)
+	for i := range data {
+		data[i] = map[string]interface{}{
+			"digit":    strconv.Itoa(i + 1),
+			"observed": counts[i] / n,
+			"expected": expected[i] / n,
+		}
+	}
+	visual.Dt = data
+	return visual
+}
+
+//Propose suggests the possible insights from the domain knowledge.
+//Every float metric in the dataset is proposed for the test.
+func (b *Benford) Propose(d Dataset) []ProposedInsight {
+	result := []ProposedInsight{}
+	for _, m := range sortedMetrics(d) {
+		if m.DataType != Float {
+			continue
+		}
+		metrics := []Metric{m}
+		result = append(result, ProposedInsight{b.New(d, metrics), metrics})
+	}
+	return result
+}
+
+//firstDigit returns the first significant digit of the value. It returns
+//false for zero, NaN and infinite values.
+func firstDigit(v float64) (int, bool) {
+	if v == 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, false
+	}
+	s := strconv.FormatFloat(math.Abs(v), 'e', -1, 64)
+	return int(s[0] - '0'), true
+}
+
+//conformity returns the conformity level to benford's law for the mean
+//absolute deviation of the first digit proportions. The limits are the ones
+//suggested by Nigrini.
+func conformity(mad float64) string {
+	switch {
+	case mad <= 0.006:
+		return ConformityClose
+	case mad <= 0.012:
+		return ConformityAcceptable
+	case mad <= 0.015:
+		return ConformityMarginal
+	default:
+		return ConformityNone
+	}
+}
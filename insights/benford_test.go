@@ -0,0 +1,174 @@
+package insights
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/cuttle-ai/brain/visualizations"
+)
+
+/*
+	This file contains the tests for the benford's law conformity insight
+*/
+
+//benfordData returns the data following benford's law. The values are
+//spread uniformly on the log scale over 4 orders of magnitude.
+func benfordData(n int) []float64 {
+	data := make([]float64, n)
+	for i := range data {
+		data[i] = math.Pow(10, 4*(float64(i)+0.5)/float64(n))
+	}
+	return data
+}
+
+//uniformDigitData returns the data having all the first digits with equal
+//frequencies over 4 orders of magnitude
+func uniformDigitData() []float64 {
+	data := []float64{}
+	for k := 0; k < 4; k++ {
+		for d := 1; d <= 9; d++ {
+			for r := 0; r < 5; r++ {
+				data = append(data, float64(d)*math.Pow(10, float64(k))+float64(r))
+			}
+		}
+	}
+	return data
+}
+
+func TestBenford_New(t *testing.T) {
+	d := NewDataset()
+	m := Metric{Name: "amount", DataType: Float}
+	d.AddMetric(m, []float64{10, 20, 30})
+	bi := (&Benford{}).New(d, []Metric{m})
+	b, ok := bi.(*Benford)
+	if !ok {
+		t.Fatal("Expected a benford. Got", reflect.TypeOf(bi))
+	}
+	if b.dt.Length != 3 || len(b.ms) != 1 {
+		t.Fatal("Expected dataset of length 3 with 1 metric. Got", b.dt.Length,
+			len(b.ms))
+	}
+}
+
+func TestBenford_Type(t *testing.T) {
+	b := &Benford{}
+	if b.Type() != BENFORD {
+		t.Fatal("Expected insight type is", BENFORD, "Got", b.Type())
+	}
+}
+
+func TestBenford_FSFA(t *testing.T) {
+	t.Run("Testing FSFA with string metric", func(t *testing.T) {
+		d := NewDataset()
+		d.AddMetric(Metric{Name: "name", DataType: String},
+			make([]string, minBenfordRecords))
+		b := &Benford{dt: d, ms: []Metric{d.Metrics["name"]}}
+		b.FSFA()
+		if b.Relevant() {
+			t.Fatal("Expected benford to be irrelevant for string metric.",
+				"Got it as relevant")
+		}
+	})
+
+	t.Run("Testing FSFA with insufficient records", func(t *testing.T) {
+		d := NewDataset()
+		d.AddMetric(Metric{Name: "amount", DataType: Float}, benfordData(10))
+		b := &Benford{dt: d, ms: []Metric{d.Metrics["amount"]}}
+		b.FSFA()
+		if b.Relevant() {
+			t.Fatal("Expected benford to be irrelevant with 10 records.",
+				"Got it as relevant")
+		}
+	})
+
+	t.Run("Testing FSFA in normal conditions", func(t *testing.T) {
+		d := NewDataset()
+		d.AddMetric(Metric{Name: "amount", DataType: Float}, benfordData(200))
+		b := &Benford{dt: d, ms: []Metric{d.Metrics["amount"]}}
+		b.FSFA()
+		if !b.Relevant() {
+			t.Fatal("Expected benford to be relevant with normal conditions.",
+				"Got it as irrelevant")
+		}
+	})
+}
+
+type bGenerateTC struct {
+	ID          string
+	Description string
+	Data        []float64
+	Relevance   bool
+	Conformity  string
+}
+
+var bGenerateTCs = []bGenerateTC{
+	{"1", "Data following benford's law", benfordData(1000), false,
+		ConformityClose},
+	{"2", "Data with uniform first digits", uniformDigitData(), true,
+		ConformityNone},
+	{"3", "Data spanning a single order of magnitude",
+		func() []float64 {
+			data := uniformDigitData()
+			for i := range data {
+				data[i] = 100 + math.Mod(data[i], 900)
+			}
+			return data
+		}(), false, ""},
+}
+
+func TestBenford_Generate(t *testing.T) {
+	for _, v := range bGenerateTCs {
+		t.Run(v.ID, func(t *testing.T) {
+			d := NewDataset()
+			d.AddMetric(Metric{Name: "amount", DataType: Float}, v.Data)
+			b := &Benford{dt: d, ms: []Metric{d.Metrics["amount"]}}
+			b.FSFA()
+			b.Generate()
+			if b.Relevant() != v.Relevance {
+				t.Fatal("Expected relevance", v.Relevance, "Got", b.Relevant(),
+					"with mad", b.MAD())
+			}
+			if b.Conformity() != v.Conformity {
+				t.Fatal("Expected conformity", v.Conformity, "Got",
+					b.Conformity())
+			}
+			if !v.Relevance {
+				return
+			}
+			if b.PValue() > 0.05 || b.ChiSquare() <= 0 {
+				t.Fatal("Expected a significant chi-square. Got", b.ChiSquare(),
+					b.PValue())
+			}
+			bar, ok := b.Visual().(visualizations.BarChart)
+			if !ok {
+				t.Fatal("Expected a bar chart. Got", reflect.TypeOf(b.Visual()))
+			}
+			if len(bar.Data()) != 9 {
+				t.Fatal("Expected 9 digits in the bar chart. Got",
+					len(bar.Data()))
+			}
+		})
+	}
+}
+
+func TestBenford_Propose(t *testing.T) {
+	d := NewDataset()
+	d.AddMetric(Metric{Name: "amount", DataType: Float}, []float64{1, 2})
+	d.AddMetric(Metric{Name: "tax", DataType: Float}, []float64{1, 2})
+	d.AddMetric(Metric{Name: "name", DataType: String}, []string{"a", "b"})
+	if pro := (&Benford{}).Propose(d); len(pro) != 2 {
+		t.Fatal("Expected 2 proposals. Got", len(pro))
+	}
+}
+
+func TestFirstDigit(t *testing.T) {
+	for v, e := range map[float64]int{0.3: 3, 123: 1, -98: 9, 7e-10: 7} {
+		if d, ok := firstDigit(v); !ok || d != e {
+			t.Fatal("Expected first digit of", v, "to be", e, "Got", d)
+		}
+	}
+	if _, ok := firstDigit(0); ok {
+		t.Fatal("Expected zero to have no first digit")
+	}
+}
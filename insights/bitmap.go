@@ -0,0 +1,56 @@
+package insights
+
+/*
+	This file contains the bitmap used for storing the validity of the values
+	of the metrics in the dataset
+*/
+
+//Bitmap is a set of bits packed into words. It is used as the validity
+//bitmap of a metric where the bit of a record is set if its value is not
+//missing.
+type Bitmap []uint64
+
+//NewBitmap returns a bitmap of n bits. All the bits are set if set is true.
+func NewBitmap(n int, set bool) Bitmap {
+	b := make(Bitmap, (n+63)/64)
+	if !set {
+		return b
+	}
+	for i := range b {
+		b[i] = ^uint64(0)
+	}
+	//clearing the bits beyond n so that the counts are correct
+	if r := uint(n % 64); r != 0 {
+		b[len(b)-1] = (uint64(1) << r) - 1
+	}
+	return b
+}
+
+//Get returns whether the ith bit is set. Bits beyond the bitmap are not set.
+func (b Bitmap) Get(i int) bool {
+	if i < 0 || i/64 >= len(b) {
+		return false
+	}
+	return b[i/64]&(uint64(1)<<uint(i%64)) != 0
+}
+
+//Set sets the ith bit to the given value
+func (b Bitmap) Set(i int, v bool) {
+	if v {
+		b[i/64] |= uint64(1) << uint(i%64)
+		return
+	}
+	b[i/64] &^= uint64(1) << uint(i%64)
+}
+
+//Count returns the no. of bits set
+func (b Bitmap) Count() int {
+	c := 0
+	for _, w := range b {
+		for ; w != 0; c++ {
+			//clearing the lowest set bit
+			w &= w - 1
+		}
+	}
+	return c
+}
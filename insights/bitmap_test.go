@@ -0,0 +1,37 @@
+package insights
+
+import (
+	"testing"
+)
+
+/*
+	This file contains the tests for the bitmap
+*/
+
+func TestNewBitmap(t *testing.T) {
+	for _, n := range []int{0, 1, 63, 64, 65, 130} {
+		if c := NewBitmap(n, true).Count(); c != n {
+			t.Fatal("Expected", n, "bits set. Got", c)
+		}
+		if c := NewBitmap(n, false).Count(); c != 0 {
+			t.Fatal("Expected no bits set. Got", c)
+		}
+	}
+}
+
+func TestBitmap_Set(t *testing.T) {
+	b := NewBitmap(100, false)
+	b.Set(3, true)
+	b.Set(64, true)
+	b.Set(99, true)
+	b.Set(99, false)
+	if !b.Get(3) || !b.Get(64) || b.Get(99) || b.Get(4) {
+		t.Fatal("Expected bits 3 and 64 to be set. Got", b)
+	}
+	if b.Get(-1) || b.Get(200) {
+		t.Fatal("Expected the bits beyond the bitmap not to be set")
+	}
+	if b.Count() != 2 {
+		t.Fatal("Expected 2 bits set. Got", b.Count())
+	}
+}
@@ -0,0 +1,587 @@
+package insights
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cuttle-ai/brain/visualizations"
+	"github.com/gonum/stat"
+)
+
+/*
+	This file contains the utilities and structs required for clustering
+	insights
+*/
+
+const (
+	//minClusterRecords is the minimum no. of records required in a dataset
+	//to look for the clusters in it
+	minClusterRecords = 10
+	//maxClusters is the maximum no. of clusters that will be tried while
+	//looking for the natural segments in the dataset
+	maxClusters = 8
+	//minSilhouette is the minimum average silhouette score required for the
+	//discovered clusters to be considered as natural segments
+	minSilhouette = 0.5
+	//maxSilhouetteSample is the maximum no. of records used for computing the
+	//silhouette score. Silhouette is quadratic in the no. of records.
+	maxSilhouetteSample = 1000
+	//kmeansRuns is the no. of times k-means is run with different initial
+	//centroids for a given k. The best run is kept.
+	kmeansRuns = 5
+	//kmeansIterations is the maximum no. of iterations in a k-means run
+	kmeansIterations = 100
+	//segmentDeviation is the deviation in standard units of a cluster centroid
+	//from the mean beyond which the metric is said to be high or low for the
+	//cluster
+	segmentDeviation = 0.5
+	//clusterSeed is the seed used for choosing the initial centroids.
+	//A fixed seed keeps the insights reproducible.
+	clusterSeed = 1
+	//clusterField is the name of the field having the cluster of the record
+	//in the visualization data
+	clusterField = "cluster"
+)
+
+//Segment is a natural segment of records discovered by the clustering insight
+type Segment struct {
+	ID   int //ID is the id of the cluster. It starts from 1
+	Size int //Size is the no. of records in the segment
+	//Centroid has the mean of the metrics of the records in the segment
+	//mapped to the metric names
+	Centroid map[string]float64
+	//Description describes the defining characteristics of the segment.
+	//Example - cluster 2: high spend, low visits
+	Description string
+}
+
+//Clustering is the clustering insight.
+//It discovers the natural segments of records in a dataset
+type Clustering struct {
+	//visual has the visualization to be used for showing the segments.
+	//Scatter plot colored by the cluster is used.
+	visual visualizations.Visual
+	//relevant stores the information whether the insight is relevant or not.
+	//This property is updated after running methods like FSFA and Generate
+	relevant bool
+	dt       Dataset //dt is the dataset to be used for the clustering
+	//ms is the list of metrics over which the records has to be clustered
+	ms []Metric
+	//assignments has the cluster id of each record in the dataset.
+	//Records that couldn't be clustered will have 0 as the cluster id.
+	assignments []int
+	segments    []Segment //segments has the discovered segments
+}
+
+//New returns a new instance of the Clustering with
+//initializations done for the given dataset
+func (c *Clustering) New(d Dataset, ms []Metric) Insight {
+	return &Clustering{dt: d, ms: ms}
+}
+
+//Visual returns the visualization to be used for visualizing the segments
+func (c *Clustering) Visual() visualizations.Visual {
+	return c.visual
+}
+
+//Type returns the type string for the clustering type of insight
+func (c *Clustering) Type() string {
+	return CLUSTERING
+}
+
+//Relevant returns whether the insight is relevant or not for the given dataset.
+func (c *Clustering) Relevant() bool {
+	return c.relevant
+}
+
+//Assignments returns the cluster id of each record in the dataset.
+//Cluster ids start from 1. Records that couldn't be clustered like the ones
+//having NaN values will have 0 as the cluster id.
+func (c *Clustering) Assignments() []int {
+	return c.assignments
+}
+
+//Segments returns the segments discovered by the insight
+func (c *Clustering) Segments() []Segment {
+	return c.segments
+}
+
+//FSFA does the fast statistical feasibilty analysis over the dataset
+//with the given metrics whether the records can be clustered.
+//Clustering requires atleast two float metrics and minClusterRecords no.
+//of records.
+func (c *Clustering) FSFA() {
+	/*
+		Will check whether there are atleast two metrics.
+		Then it will check whether the data types of the variables
+		are float.
+		Then we check whether there are sufficient records
+	*/
+	//Checking the length of the metrics
+	if len(c.ms) < 2 {
+		c.relevant = false
+		return
+	}
+
+	//checking the data types of the metrics
+	for _, m := range c.ms {
+		if m.DataType != Float {
+			c.relevant = false
+			return
+		}
+	}
+
+	//checking the no. of records
+	if c.dt.Length < minClusterRecords {
+		c.relevant = false
+		return
+	}
+
+	//Everything is fine
+	c.relevant = true
+}
+
+//Generate generates the clustering insight for the datatset associated with
+//it for the provided variables.
+//This method can only be run after running the FSFA.
+//Else the insight won't be generated
+func (c *Clustering) Generate() {
+	/*
+		If the insight is not relevant we won't event bother
+		to go forward.
+		We will standardize the metrics and drop the records with NaN values.
+		Then we run k-means for k from 2 to maxClusters and choose the k with
+		best silhouette score.
+		If the best silhouette score is less than minSilhouette, the
+		records doesn't have natural segments.
+		Then we describe the segments and create the visual.
+	*/
+	//Checking whether the existing relevance of the insight
+	if !c.relevant {
+		return
+	}
+
+	//standardizing the metrics
+	ms, points, rows := c.standardize()
+	if len(ms) < 2 || len(points) < minClusterRecords {
+		c.relevant = false
+		return
+	}
+
+	//finding the best k
+	r := rand.New(rand.NewSource(clusterSeed))
+	var best []int
+	bestK := 0
+	bestScore := math.Inf(-1)
+	for k := 2; k <= maxClusters && k*2 <= len(points); k++ {
+		labels, _ := kmeans(points, k, r)
+		score := silhouette(points, labels, k)
+		if score > bestScore {
+			best, bestK, bestScore = labels, k, score
+		}
+	}
+	if best == nil || bestScore < minSilhouette {
+		//records doesn't have natural segments
+		c.relevant = false
+		return
+	}
+
+	//storing the assignments
+	c.assignments = make([]int, c.dt.Length)
+	for i, row := range rows {
+		c.assignments[row] = best[i] + 1
+	}
+
+	//describing the segments
+	c.segments = c.describe(ms, points, rows, best, bestK)
+	c.relevant = true
+	c.visual = c.scatter(ms, points, best, bestK)
+}
+
+//standardize returns the non constant metrics and the standardized values of
+//the records in the dataset for those metrics. Records having NaN values are
+//dropped. The indices of the records that were kept are also returned.
+func (c *Clustering) standardize() ([]Metric, [][]float64, []int) {
+	/*
+		We will first find the non constant metrics having data in the dataset.
+		Then we will find the records without NaN values for those metrics.
+		Then we will compute the z scores of the records.
+	*/
+	//finding the usable metrics
+	ms := []Metric{}
+	for _, m := range c.ms {
+		if m.Index >= len(c.dt.DataF) ||
+			int64(len(c.dt.DataF[m.Index])) != c.dt.Length {
+			continue
+		}
+		ms = append(ms, m)
+	}
+
+	//finding the records without NaN values
+	rows := []int{}
+	for i := 0; i < int(c.dt.Length); i++ {
+		ok := true
+		for _, m := range ms {
+			if math.IsNaN(c.dt.DataF[m.Index][i]) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			rows = append(rows, i)
+		}
+	}
+
+	//finding the mean and standard deviation of each metric
+	usable := []Metric{}
+	means := []float64{}
+	stds := []float64{}
+	for _, m := range ms {
+		vals := make([]float64, len(rows))
+		for i, row := range rows {
+			vals[i] = c.dt.DataF[m.Index][row]
+		}
+		mean, std := stat.MeanStdDev(vals, nil)
+		if std == 0 || math.IsNaN(std) {
+			//constant metrics won't help in segmenting the records
+			continue
+		}
+		usable = append(usable, m)
+		means = append(means, mean)
+		stds = append(stds, std)
+	}
+
+	//computing the z scores
+	points := make([][]float64, len(rows))
+	for i, row := range rows {
+		points[i] = make([]float64, len(usable))
+		for j, m := range usable {
+			points[i][j] = (c.dt.DataF[m.Index][row] - means[j]) / stds[j]
+		}
+	}
+	return usable, points, rows
+}
+
+//describe returns the segments discovered in the records
+func (c *Clustering) describe(ms []Metric, points [][]float64, rows []int,
+	labels []int, k int) []Segment {
+	/*
+		For each cluster we will find the size, the centroid in original units
+		and the centroid in the standard units.
+		Metrics deviating from the mean by more than segmentDeviation in
+		standard units are used to describe the segment.
+	*/
+	segs := make([]Segment, k)
+	zs := make([][]float64, k)
+	for i := range segs {
+		segs[i] = Segment{ID: i + 1, Centroid: map[string]float64{}}
+		zs[i] = make([]float64, len(ms))
+	}
+
+	//finding the sums
+	for i, row := range rows {
+		l := labels[i]
+		segs[l].Size++
+		for j, m := range ms {
+			segs[l].Centroid[m.Name] += c.dt.DataF[m.Index][row]
+			zs[l][j] += points[i][j]
+		}
+	}
+
+	//finding the means and the descriptions
+	for i := range segs {
+		traits := []string{}
+		for j, m := range ms {
+			segs[i].Centroid[m.Name] /= float64(segs[i].Size)
+			zs[i][j] /= float64(segs[i].Size)
+			if zs[i][j] >= segmentDeviation {
+				traits = append(traits, "high "+displayName(m))
+			} else if zs[i][j] <= -segmentDeviation {
+				traits = append(traits, "low "+displayName(m))
+			}
+		}
+		if len(traits) == 0 {
+			traits = append(traits, "average on all metrics")
+		}
+		segs[i].Description = "cluster " + strconv.Itoa(segs[i].ID) + ": " +
+			strings.Join(traits, ", ")
+	}
+	return segs
+}
+
+//scatter returns the scatter plot for the segments. The two metrics that
+//separate the clusters the most are plotted on the axes.
+func (c *Clustering) scatter(ms []Metric, points [][]float64, labels []int,
+	k int) visualizations.ScatterPlot {
+	/*
+		We will find the separation of clusters along each metric as the
+		variance of the cluster means weighted by the size of the clusters.
+		The metrics with the highest separation will be plotted.
+		The cluster of each record is added as the third dimension.
+	*/
+	//finding the separation along each metric
+	sep := make([]float64, len(ms))
+	for j := range ms {
+		sums := make([]float64, k)
+		sizes := make([]float64, k)
+		for i, p := range points {
+			sums[labels[i]] += p[j]
+			sizes[labels[i]]++
+		}
+		for l := range sums {
+			if sizes[l] == 0 {
+				continue
+			}
+			mean := sums[l] / sizes[l]
+			sep[j] += sizes[l] * mean * mean
+		}
+	}
+	order := make([]int, len(ms))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return sep[order[i]] > sep[order[j]]
+	})
+	x, y := ms[order[0]], ms[order[1]]
+
+	//creating the visual
+	descs := make([]string, len(c.segments))
+	for i, s := range c.segments {
+		descs[i] = s.Description
+	}
+	visual := visualizations.ScatterPlot{
+		T: strconv.Itoa(k) + " segments found in the records",
+		D: strings.Join(descs, "; "),
+		M: []visualizations.Metric{
+			{
+				Name:        x.Name,
+				DisplayName: x.DisplayName,
+				DataType:    Float,
+				Dimension:   0,
+			},
+			{
+				Name:        y.Name,
+				DisplayName: y.DisplayName,
+				DataType:    Float,
+				Dimension:   1,
+			},
+			{
+				Name:        clusterField,
+				DisplayName: "Cluster",
+				DataType:    String,
+				Dimension:   2,
+			},
+		},
+	}
+
+	//adding the data of the clustered records
+	data := []map[string]interface{}{}
+	for row, l := range c.assignments {
+		if l == 0 {
+			continue
+		}
+		rec := map[string]interface{}{}
+		for _, m := range ms {
+			rec[m.Name] = c.dt.DataF[m.Index][row]
+		}
+		rec[clusterField] = "cluster " + strconv.Itoa(l)
+		data = append(data, rec)
+	}
+	visual.Dt = data
+	return visual
+}
+
+//Propose suggests the possible insights from the domain knowledge.
+//All the float metrics in the dataset are proposed for clustering the records.
+func (c *Clustering) Propose(d Dataset) []ProposedInsight {
+	/*
+		We will iterate through the metrics in the data set and select the
+		variables that have float data type.
+		If there are atleast two such metrics we will propose the insight.
+	*/
+	//selecting the float metrics
+	svars := []Metric{}
+	for _, v := range d.Metrics {
+		if v.DataType != Float {
+			continue
+		}
+		svars = append(svars, v)
+	}
+	if len(svars) < 2 {
+		return []ProposedInsight{}
+	}
+
+	//sorting the metrics to keep the proposals reproducible
+	sort.Slice(svars, func(i, j int) bool {
+		return svars[i].Index < svars[j].Index
+	})
+	return []ProposedInsight{{c.New(d, svars), svars}}
+}
+
+//kmeans clusters the points in to k clusters. It returns the cluster of each
+//point and the within cluster sum of squares. The initial centroids are
+//chosen using k-means++ and the best of kmeansRuns runs is returned.
+func kmeans(points [][]float64, k int, r *rand.Rand) ([]int, float64) {
+	var best []int
+	bestSS := math.Inf(1)
+	for run := 0; run < kmeansRuns; run++ {
+		labels, ss := kmeansRun(points, k, r)
+		if ss < bestSS {
+			best, bestSS = labels, ss
+		}
+	}
+	return best, bestSS
+}
+
+//kmeansRun does a single run of the k-means clustering
+func kmeansRun(points [][]float64, k int, r *rand.Rand) ([]int, float64) {
+	/*
+		We will choose the initial centroids using k-means++.
+		Then we will alternate between assigning the points to the nearest
+		centroids and recomputing the centroids till the assignments don't
+		change.
+	*/
+	//choosing the initial centroids
+	dims := len(points[0])
+	centroids := [][]float64{append([]float64{}, points[r.Intn(len(points))]...)}
+	dists := make([]float64, len(points))
+	for len(centroids) < k {
+		total := 0.0
+		for i, p := range points {
+			dists[i] = math.Inf(1)
+			for _, ct := range centroids {
+				if d := sqDistance(p, ct); d < dists[i] {
+					dists[i] = d
+				}
+			}
+			total += dists[i]
+		}
+		target := r.Float64() * total
+		next := len(points) - 1
+		for i, d := range dists {
+			target -= d
+			if target <= 0 {
+				next = i
+				break
+			}
+		}
+		centroids = append(centroids, append([]float64{}, points[next]...))
+	}
+
+	//iterating till convergence
+	labels := make([]int, len(points))
+	for i := range labels {
+		labels[i] = -1
+	}
+	ss := 0.0
+	for it := 0; it < kmeansIterations; it++ {
+		//assigning the points to the nearest centroids
+		changed := false
+		ss = 0.0
+		for i, p := range points {
+			nearest, nd := 0, math.Inf(1)
+			for l, ct := range centroids {
+				if d := sqDistance(p, ct); d < nd {
+					nearest, nd = l, d
+				}
+			}
+			if labels[i] != nearest {
+				labels[i] = nearest
+				changed = true
+			}
+			ss += nd
+		}
+		if !changed {
+			break
+		}
+
+		//recomputing the centroids. Empty clusters keep their centroids.
+		sums := make([][]float64, k)
+		sizes := make([]int, k)
+		for l := range sums {
+			sums[l] = make([]float64, dims)
+		}
+		for i, p := range points {
+			sizes[labels[i]]++
+			for j, v := range p {
+				sums[labels[i]][j] += v
+			}
+		}
+		for l := range centroids {
+			if sizes[l] == 0 {
+				continue
+			}
+			for j := range centroids[l] {
+				centroids[l][j] = sums[l][j] / float64(sizes[l])
+			}
+		}
+	}
+	return labels, ss
+}
+
+//silhouette returns the average silhouette score of the clustering.
+//If the no. of points is more than maxSilhouetteSample, a systematic sample of
+//the points is used for computing the score.
+func silhouette(points [][]float64, labels []int, k int) float64 {
+	/*
+		We will first sample the points.
+		For each point we find the mean distance to the points in its own
+		cluster(a) and the least mean distance to the points of the other
+		clusters(b). Silhouette of the point is (b - a) / max(a, b).
+	*/
+	//sampling the points
+	idx := []int{}
+	step := 1
+	if len(points) > maxSilhouetteSample {
+		step = int(math.Ceil(float64(len(points)) / maxSilhouetteSample))
+	}
+	for i := 0; i < len(points); i += step {
+		idx = append(idx, i)
+	}
+
+	//finding the silhouette of each point
+	total := 0.0
+	for _, i := range idx {
+		sums := make([]float64, k)
+		sizes := make([]float64, k)
+		for _, j := range idx {
+			if i == j {
+				continue
+			}
+			sums[labels[j]] += math.Sqrt(sqDistance(points[i], points[j]))
+			sizes[labels[j]]++
+		}
+		if sizes[labels[i]] == 0 {
+			//silhouette of a singleton is zero
+			continue
+		}
+		a := sums[labels[i]] / sizes[labels[i]]
+		b := math.Inf(1)
+		for l := range sums {
+			if l == labels[i] || sizes[l] == 0 {
+				continue
+			}
+			if m := sums[l] / sizes[l]; m < b {
+				b = m
+			}
+		}
+		if math.IsInf(b, 1) || math.Max(a, b) == 0 {
+			continue
+		}
+		total += (b - a) / math.Max(a, b)
+	}
+	return total / float64(len(idx))
+}
+
+//sqDistance returns the squared euclidean distance between two points
+func sqDistance(a, b []float64) float64 {
+	d := 0.0
+	for i := range a {
+		d += (a[i] - b[i]) * (a[i] - b[i])
+	}
+	return d
+}
@@ -0,0 +1,242 @@
+package insights
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cuttle-ai/brain/visualizations"
+)
+
+/*
+	This file contains the tests for the clustering insight
+*/
+
+func TestClustering_New(t *testing.T) {
+	d := NewDataset()
+	m := Metric{Name: "spend", DataType: Float}
+	d.AddMetric(m, []float64{10, 20, 30})
+	ci := (&Clustering{}).New(d, []Metric{m})
+	c, ok := ci.(*Clustering)
+	//checking the insight given the New function is clustering itself
+	if !ok {
+		t.Fatal("Expected a clustering. Got", reflect.TypeOf(ci))
+	}
+	//Checking the length of the datatset of c
+	if c.dt.Length != 3 {
+		t.Fatal("Expected length of dataset is 3. Got", c.dt.Length)
+	}
+	//Checking the length of metrics in the clustering
+	if len(c.ms) != 1 {
+		t.Fatal("Expected length of metrics in the clustering is 1. Got",
+			len(c.ms))
+	}
+}
+
+func TestClustering_Type(t *testing.T) {
+	cl := &Clustering{}
+	if cl.Type() != CLUSTERING {
+		t.Fatal("Expected insight type is", CLUSTERING, "Got", cl.Type())
+	}
+}
+
+func TestClustering_Relevant(t *testing.T) {
+	cl := &Clustering{relevant: true}
+	if !cl.Relevant() {
+		t.Fatal("Expected relevance to be true. Got false")
+	}
+}
+
+//segmentedData returns the data with two well separated segments.
+//High spend, low visits and low spend, high visits.
+func segmentedData() ([]float64, []float64) {
+	spend := []float64{}
+	visits := []float64{}
+	for i := 0; i < 10; i++ {
+		spend = append(spend, 100+float64(i%3))
+		visits = append(visits, 2+float64(i%2))
+	}
+	for i := 0; i < 10; i++ {
+		spend = append(spend, 10+float64(i%3))
+		visits = append(visits, 20+float64(i%2))
+	}
+	return spend, visits
+}
+
+func TestClustering_FSFA(t *testing.T) {
+	spend, visits := segmentedData()
+	d := NewDataset()
+	d.AddMetric(Metric{Name: "spend", DataType: Float}, spend)
+	d.AddMetric(Metric{Name: "visits", DataType: Float}, visits)
+	d.AddMetric(Metric{Name: "region", DataType: String},
+		make([]string, len(spend)))
+
+	t.Run("Testing FSFA when metrics < 2", func(t *testing.T) {
+		cl := &Clustering{dt: d, ms: []Metric{d.Metrics["spend"]}}
+		cl.FSFA()
+		if cl.Relevant() {
+			t.Fatal("Expected clustering to be irrelevant with 1 metric. Got",
+				"it as relevant")
+		}
+	})
+
+	t.Run("Testing FSFA when metric data type not float", func(t *testing.T) {
+		cl := &Clustering{dt: d, ms: []Metric{d.Metrics["spend"],
+			d.Metrics["region"]}}
+		cl.FSFA()
+		if cl.Relevant() {
+			t.Fatal("Expected clustering to be irrelevant with not float",
+				"data type. Got it as relevant")
+		}
+	})
+
+	t.Run("Testing FSFA when records are insufficient", func(t *testing.T) {
+		small := NewDataset()
+		small.AddMetric(Metric{Name: "spend", DataType: Float}, []float64{1, 2})
+		small.AddMetric(Metric{Name: "visits", DataType: Float}, []float64{1, 2})
+		cl := &Clustering{dt: small, ms: []Metric{small.Metrics["spend"],
+			small.Metrics["visits"]}}
+		cl.FSFA()
+		if cl.Relevant() {
+			t.Fatal("Expected clustering to be irrelevant with 2 records. Got",
+				"it as relevant")
+		}
+	})
+
+	t.Run("Testing FSFA in normal conditions", func(t *testing.T) {
+		cl := &Clustering{dt: d, ms: []Metric{d.Metrics["spend"],
+			d.Metrics["visits"]}}
+		cl.FSFA()
+		if !cl.Relevant() {
+			t.Fatal("Expected clustering to be relevant with normal",
+				"conditions Got it as irrelevant")
+		}
+	})
+}
+
+func TestClustering_Generate(t *testing.T) {
+	t.Run("Testing generate when clustering is irrelevant",
+		func(t *testing.T) {
+			cl := &Clustering{}
+			cl.Generate()
+			if cl.Relevant() {
+				t.Fatal("Expected generation to be irrelvant when the insight",
+					"is irrelvant. Got it relevant")
+			}
+		})
+
+	t.Run("Testing generate with segmented records", func(t *testing.T) {
+		spend, visits := segmentedData()
+		d := NewDataset()
+		d.AddMetric(Metric{Name: "spend", DisplayName: "spend",
+			DataType: Float}, spend)
+		d.AddMetric(Metric{Name: "visits", DisplayName: "visits",
+			DataType: Float}, visits)
+		cl := &Clustering{dt: d, ms: []Metric{d.Metrics["spend"],
+			d.Metrics["visits"]}}
+		cl.FSFA()
+		cl.Generate()
+		if !cl.Relevant() {
+			t.Fatal("Expected the segmented records to be relevant. Got",
+				"irrelevant")
+		}
+		if len(cl.Segments()) != 2 {
+			t.Fatal("Expected 2 segments. Got", len(cl.Segments()))
+		}
+		if len(cl.Assignments()) != len(spend) {
+			t.Fatal("Expected", len(spend), "assignments. Got",
+				len(cl.Assignments()))
+		}
+		//records of the same segment should be in the same cluster
+		if cl.Assignments()[0] == cl.Assignments()[10] ||
+			cl.Assignments()[0] != cl.Assignments()[9] {
+			t.Fatal("Records are clustered incorrectly", cl.Assignments())
+		}
+		seg := cl.Segments()[cl.Assignments()[0]-1]
+		if !strings.Contains(seg.Description, "high spend") ||
+			!strings.Contains(seg.Description, "low visits") {
+			t.Fatal("Expected the segment to have high spend and low visits.",
+				"Got", seg.Description)
+		}
+		if seg.Size != 10 || math.Abs(seg.Centroid["spend"]-100.9) > 1e-9 {
+			t.Fatal("Expected the segment of size 10 with spend 100.9. Got",
+				seg.Size, seg.Centroid["spend"])
+		}
+		sc, ok := cl.Visual().(visualizations.ScatterPlot)
+		if !ok {
+			t.Fatal("Expected a scatter plot. Got", reflect.TypeOf(cl.Visual()))
+		}
+		if len(sc.Metrics()) != 3 || len(sc.Data()) != len(spend) {
+			t.Fatal("Expected 3 metrics and", len(spend), "records. Got",
+				len(sc.Metrics()), len(sc.Data()))
+		}
+	})
+
+	t.Run("Testing generate without segments", func(t *testing.T) {
+		d := NewDataset()
+		x := []float64{}
+		y := []float64{}
+		for i := 0; i < 20; i++ {
+			x = append(x, float64(i))
+			y = append(y, float64((i*7)%20))
+		}
+		d.AddMetric(Metric{Name: "x", DataType: Float}, x)
+		d.AddMetric(Metric{Name: "y", DataType: Float}, y)
+		cl := &Clustering{dt: d, ms: []Metric{d.Metrics["x"], d.Metrics["y"]}}
+		cl.FSFA()
+		cl.Generate()
+		if cl.Relevant() {
+			t.Fatal("Expected uniformly spread records to be irrelevant. Got",
+				"relevant")
+		}
+	})
+}
+
+type clProposeTC struct {
+	ID          string
+	Description string
+	Metrics     []Metric
+	Data        []interface{}
+	Expected    int
+}
+
+var clProposeTCs = []clProposeTC{
+	{"1", "Normal case", []Metric{
+		{Name: "spend", DataType: Float},
+		{Name: "visits", DataType: Float},
+		{Name: "region", DataType: String},
+	}, []interface{}{
+		[]float64{1, 2, 3},
+		[]float64{1, 2, 3},
+		[]string{"a", "b", "c"},
+	}, 1},
+	{"2", "Single float metric", []Metric{
+		{Name: "spend", DataType: Float},
+		{Name: "region", DataType: String},
+	}, []interface{}{
+		[]float64{1, 2, 3},
+		[]string{"a", "b", "c"},
+	}, 0},
+}
+
+func TestClustering_Propose(t *testing.T) {
+	for _, v := range clProposeTCs {
+		t.Run(v.ID, func(t *testing.T) {
+			d := NewDataset()
+			for i := range v.Metrics {
+				err := d.AddMetric(v.Metrics[i], v.Data[i])
+				if err != nil {
+					t.Fatal("Error while adding metric for", v.ID, err)
+				}
+			}
+			pro := (&Clustering{}).Propose(d)
+			if len(pro) != v.Expected {
+				t.Fatal("Expected", v.Expected, "proposals. Got", len(pro))
+			}
+			if len(pro) == 1 && len(pro[0].M) != 2 {
+				t.Fatal("Expected 2 metrics in the proposal. Got", pro[0].M)
+			}
+		})
+	}
+}
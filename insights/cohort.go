@@ -0,0 +1,397 @@
+package insights
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/cuttle-ai/brain/visualizations"
+	"github.com/gonum/stat"
+)
+
+/*
+	This file contains the utilities and structs required for cohort retention
+	insights
+*/
+
+const (
+	//minCohorts is the minimum no. of cohorts with retention after the first
+	//period required for the cohort retention insight
+	minCohorts = 3
+)
+
+//CohortRetention is the retention of the users in a cohort
+type CohortRetention struct {
+	//Cohort is the label of the period in which the users of the cohort
+	//signed up
+	Cohort string
+	Size   int //Size is the no. of users in the cohort
+	//Retention has the ratio of the users of the cohort active in each period
+	//after the signup. Retention[0] is for the period of the signup.
+	//Periods that are yet to be observed are not present.
+	Retention []float64
+}
+
+//Cohort is the cohort retention insight.
+//It groups the users by the period of their signup and tracks the ratio of
+//users active in the subsequent periods.
+type Cohort struct {
+	//visual has the visualization to be used for showing the retention
+	//matrix. Heat map of the cohorts and the periods is used.
+	visual visualizations.Visual
+	//relevant stores the information whether the insight is relevant or not.
+	//This property is updated after running methods like FSFA and Generate
+	relevant bool
+	dt       Dataset //dt is the dataset having the activities of the users
+	//ms is the list of metrics. They are the user id, signup time and the
+	//activity time in the order.
+	ms      []Metric
+	cohorts []CohortRetention //cohorts has the retention of the cohorts
+	//best and worst are the indices of the cohorts with best and worst
+	//retention after the first period
+	best  int
+	worst int
+	//trend is the change in the retention after the first period per cohort
+	trend float64
+	//granularity is the granularity of the periods. It is empty if the
+	//times are the period numbers themselves.
+	granularity string
+}
+
+//New returns a new instance of the Cohort with
+//initializations done for the given dataset
+func (c *Cohort) New(d Dataset, ms []Metric) Insight {
+	return &Cohort{dt: d, ms: ms}
+}
+
+//Visual returns the visualization to be used for visualizing the retention
+func (c *Cohort) Visual() visualizations.Visual {
+	return c.visual
+}
+
+//Type returns the type string for the cohort retention type of insight
+func (c *Cohort) Type() string {
+	return COHORT
+}
+
+//Relevant returns whether the insight is relevant or not for the given dataset.
+func (c *Cohort) Relevant() bool {
+	return c.relevant
+}
+
+//Cohorts returns the retention of the cohorts ordered by the signup period
+func (c *Cohort) Cohorts() []CohortRetention {
+	return c.cohorts
+}
+
+//Best returns the cohort with the best retention after the first period.
+//It should be called only if the insight is relevant.
+func (c *Cohort) Best() CohortRetention {
+	return c.cohorts[c.best]
+}
+
+//Worst returns the cohort with the worst retention after the first period.
+//It should be called only if the insight is relevant.
+func (c *Cohort) Worst() CohortRetention {
+	return c.cohorts[c.worst]
+}
+
+//Trend returns the change in the retention after the first period from one
+//cohort to the next. Positive trend means that the retention is improving.
+func (c *Cohort) Trend() float64 {
+	return c.trend
+}
+
+//FSFA does the fast statistical feasibilty analysis over the dataset
+//with the given metrics whether the cohorts can be analysed.
+//It requires the metrics having the user id, signup time and activity time.
+func (c *Cohort) FSFA() {
+	if len(c.ms) != 3 || c.ms[0].Semantic != SemanticUser ||
+		c.ms[1].Semantic != SemanticSignup ||
+		c.ms[2].Semantic != SemanticActivity ||
+		c.ms[1].DataType != c.ms[2].DataType {
+		c.relevant = false
+		return
+	}
+	c.relevant = c.dt.Length > 0
+}
+
+//Generate generates the cohort retention insight for the datatset
+//associated with it for the provided metrics.
+//This method can only be run after running the FSFA.
+//Else the insight won't be generated
+func (c *Cohort) Generate() {
+	/*
+		If the insight is not relevant we won't event bother
+		to go forward.
+		We will find the periods of signup and activity of the records.
+		Cohort of a user is the earliest period of the signup.
+		Then we find the distinct users active in each period after signup
+		for each cohort.
+		Then we find the notable cohorts and the trend of the retention after
+		the first period.
+	*/
+	//Checking whether the existing relevance of the insight
+	if !c.relevant {
+		return
+	}
+
+	//finding the users and periods
+	users, ok := keys(c.dt, c.ms[0])
+	if !ok {
+		c.relevant = false
+		return
+	}
+	signup, activity, valid, ok := c.periods()
+	if !ok {
+		c.relevant = false
+		return
+	}
+	//records without the user can't be attributed to a cohort
+	for i, u := range users {
+		if !c.dt.Valid(c.ms[0].Name, i) || len(u) == 0 {
+			valid[i] = false
+		}
+	}
+
+	//finding the cohort of each user and the last period observed
+	cohortOf := map[string]int{}
+	last := math.MinInt32
+	for i, u := range users {
+		if !valid[i] {
+			continue
+		}
+		if p, ok := cohortOf[u]; !ok || signup[i] < p {
+			cohortOf[u] = signup[i]
+		}
+		if activity[i] > last {
+			last = activity[i]
+		}
+	}
+
+	//finding the active users of the cohorts in each period
+	sizes := map[int]int{}
+	for _, p := range cohortOf {
+		sizes[p]++
+	}
+	active := map[int]map[int]map[string]bool{}
+	for i, u := range users {
+		if !valid[i] {
+			continue
+		}
+		p := cohortOf[u]
+		k := activity[i] - p
+		if k < 0 {
+			continue
+		}
+		if active[p] == nil {
+			active[p] = map[int]map[string]bool{}
+		}
+		if active[p][k] == nil {
+			active[p][k] = map[string]bool{}
+		}
+		active[p][k][u] = true
+	}
+
+	//creating the retention of the cohorts
+	ps := make([]int, 0, len(sizes))
+	for p := range sizes {
+		ps = append(ps, p)
+	}
+	sort.Ints(ps)
+	c.cohorts = make([]CohortRetention, len(ps))
+	for i, p := range ps {
+		ret := make([]float64, last-p+1)
+		for k := range ret {
+			ret[k] = float64(len(active[p][k])) / float64(sizes[p])
+		}
+		c.cohorts[i] = CohortRetention{c.label(p), sizes[p], ret}
+	}
+
+	//finding the notable cohorts
+	if !c.notable() {
+		c.relevant = false
+		return
+	}
+	c.relevant = true
+	c.visual = c.heatmap()
+}
+
+//periods returns the periods of the signup and the activity of the records.
+//Times stored as strings are bucketed by the granularity suitable for their
+//span. Float times are taken as the period numbers. Records whose times
+//couldn't be parsed are marked as invalid.
+func (c *Cohort) periods() ([]int, []int, []bool, bool) {
+	/*
+		For the float times, we will simply floor them.
+		For the string times, we will parse them and find the span of the times.
+		Then we will find the periods using the granularity for the span.
+		Cohorts are weekly if the span is less than 3 months. Else they are
+		atleast monthly as the weekly cohorts would be too many.
+	*/
+	n := int(c.dt.Length)
+	signup := make([]int, n)
+	activity := make([]int, n)
+	valid := make([]bool, n)
+	sm, am := c.ms[1], c.ms[2]
+
+	//float times
+	if sm.DataType == Float {
+		if sm.Index >= len(c.dt.DataF) || am.Index >= len(c.dt.DataF) {
+			return nil, nil, nil, false
+		}
+		for i := 0; i < n; i++ {
+			s, a := c.dt.DataF[sm.Index][i], c.dt.DataF[am.Index][i]
+			if math.IsNaN(s) || math.IsNaN(a) {
+				continue
+			}
+			signup[i], activity[i] = int(math.Floor(s)), int(math.Floor(a))
+			valid[i] = true
+		}
+		c.granularity = ""
+		return signup, activity, valid, true
+	}
+
+	//string times
+	sv, ok1 := keys(c.dt, sm)
+	av, ok2 := keys(c.dt, am)
+	if !ok1 || !ok2 {
+		return nil, nil, nil, false
+	}
+	st := make([]time.Time, n)
+	at := make([]time.Time, n)
+	var first, lastT time.Time
+	for i := 0; i < n; i++ {
+		s, _, ok1 := parseTime(sv[i])
+		a, _, ok2 := parseTime(av[i])
+		if !ok1 || !ok2 {
+			continue
+		}
+		st[i], at[i] = s, a
+		for _, t := range []time.Time{s, a} {
+			if first.IsZero() || t.Before(first) {
+				first = t
+			}
+			if lastT.IsZero() || t.After(lastT) {
+				lastT = t
+			}
+		}
+		valid[i] = true
+	}
+	if first.IsZero() {
+		return nil, nil, nil, false
+	}
+	span := lastT.Sub(first)
+	c.granularity = granularity(span)
+	if span <= 90*24*time.Hour {
+		c.granularity = granularityWeek
+	} else if c.granularity == granularityWeek {
+		c.granularity = granularityMonth
+	}
+	for i := 0; i < n; i++ {
+		if !valid[i] {
+			continue
+		}
+		signup[i] = periodIndex(st[i], c.granularity)
+		activity[i] = periodIndex(at[i], c.granularity)
+	}
+	return signup, activity, valid, true
+}
+
+//label returns the label of the period
+func (c *Cohort) label(p int) string {
+	if c.granularity == "" {
+		return strconv.Itoa(p)
+	}
+	return periodLabel(p, c.granularity)
+}
+
+//notable finds the cohorts with the best and worst retention after the first
+//period and the trend of the retention. It returns false if there aren't
+//enough cohorts with the retention after the first period.
+func (c *Cohort) notable() bool {
+	xs := []float64{}
+	ys := []float64{}
+	c.best, c.worst = -1, -1
+	for i, ch := range c.cohorts {
+		if len(ch.Retention) < 2 {
+			continue
+		}
+		r := ch.Retention[1]
+		xs = append(xs, float64(len(xs)))
+		ys = append(ys, r)
+		if c.best == -1 || r > c.cohorts[c.best].Retention[1] {
+			c.best = i
+		}
+		if c.worst == -1 || r < c.cohorts[c.worst].Retention[1] {
+			c.worst = i
+		}
+	}
+	if len(ys) < minCohorts {
+		return false
+	}
+	_, c.trend = stat.LinearRegression(xs, ys, nil, false)
+	return true
+}
+
+//heatmap returns the heat map of the retention matrix
+func (c *Cohort) heatmap() visualizations.HeatMap {
+	period := "period"
+	if c.granularity != "" {
+		period = c.granularity
+	}
+	best, worst := c.Best(), c.Worst()
+	desc := "Cohort " + best.Cohort + " has the best retention with " +
+		formatFloat(best.Retention[1]*100) + "% users active after a " + period +
+		" and cohort " + worst.Cohort + " has the worst with " +
+		formatFloat(worst.Retention[1]*100) + "%. Retention after a " + period
+	switch {
+	case c.trend > 0:
+		desc += " is improving by " + formatFloat(c.trend*100) +
+			" points every cohort"
+	case c.trend < 0:
+		desc += " is declining by " + formatFloat(-c.trend*100) +
+			" points every cohort"
+	default:
+		desc += " is steady across the cohorts"
+	}
+	visual := visualizations.HeatMap{
+		T: "Retention of the users by their signup " + period,
+		D: desc,
+		M: []visualizations.Metric{
+			{Name: "period", DisplayName: "Periods since signup",
+				DataType: Float, Dimension: 0},
+			{Name: "cohort", DisplayName: "Cohort", DataType: String,
+				Dimension: 1},
+			{Name: "retention", DisplayName: "Retention", DataType: Float,
+				Dimension: 2, PostplacementUnit: "%"},
+		},
+	}
+	data := []map[string]interface{}{}
+	for _, ch := range c.cohorts {
+		for k, r := range ch.Retention {
+			data = append(data, map[string]interface{}{
+				"period":    float64(k),
+				"cohort":    ch.Cohort,
+				"retention": math.Round(r*10000) / 100,
+			})
+		}
+	}
+	visual.Dt = data
+	return visual
+}
+
+//Propose suggests the possible insights from the domain knowledge.
+//Datasets having the user id, signup time and activity time are proposed
+//for the cohort retention.
+func (c *Cohort) Propose(d Dataset) []ProposedInsight {
+	u, ok1 := metricWithSemantic(d, SemanticUser)
+	s, ok2 := metricWithSemantic(d, SemanticSignup)
+	a, ok3 := metricWithSemantic(d, SemanticActivity)
+	if !ok1 || !ok2 || !ok3 {
+		return []ProposedInsight{}
+	}
+	ms := []Metric{u, s, a}
+	return []ProposedInsight{{c.New(d, ms), ms}}
+}
@@ -0,0 +1,171 @@
+package insights
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/cuttle-ai/brain/visualizations"
+)
+
+/*
+	This file contains the tests for the cohort retention insight
+*/
+
+//cohortMetrics are the metrics of the user activity datasets
+var cohortMetrics = []Metric{
+	{Name: "user", DataType: String, Semantic: SemanticUser},
+	{Name: "signup", DataType: String, Semantic: SemanticSignup},
+	{Name: "active", DataType: String, Semantic: SemanticActivity},
+}
+
+//userActivity returns the activity of users signed up over 4 months.
+//10 users sign up every month. Retention after a month is 50%, 40%, 30% for
+//the first three cohorts.
+func userActivity() Dataset {
+	users, signups, actives := []string{}, []string{}, []string{}
+	months := []string{"2019-01", "2019-02", "2019-03", "2019-04"}
+	returning := []int{5, 4, 3, 0}
+	for c, m := range months {
+		for u := 0; u < 10; u++ {
+			id := m + "-" + string(rune('a'+u))
+			users = append(users, id)
+			signups = append(signups, m+"-01")
+			actives = append(actives, m+"-02")
+			if u < returning[c] {
+				users = append(users, id, id)
+				signups = append(signups, m+"-01", m+"-01")
+				actives = append(actives, months[c+1]+"-10", months[c+1]+"-20")
+			}
+		}
+	}
+	d := NewDataset()
+	d.AddMetric(cohortMetrics[0], users)
+	d.AddMetric(cohortMetrics[1], signups)
+	d.AddMetric(cohortMetrics[2], actives)
+	return d
+}
+
+func TestCohort_New(t *testing.T) {
+	d := userActivity()
+	ci := (&Cohort{}).New(d, cohortMetrics)
+	c, ok := ci.(*Cohort)
+	if !ok {
+		t.Fatal("Expected a cohort. Got", reflect.TypeOf(ci))
+	}
+	if len(c.ms) != 3 {
+		t.Fatal("Expected 3 metrics. Got", len(c.ms))
+	}
+}
+
+func TestCohort_Type(t *testing.T) {
+	c := &Cohort{}
+	if c.Type() != COHORT {
+		t.Fatal("Expected insight type is", COHORT, "Got", c.Type())
+	}
+}
+
+func TestCohort_FSFA(t *testing.T) {
+	d := userActivity()
+	c := &Cohort{dt: d, ms: cohortMetrics[:2]}
+	c.FSFA()
+	if c.Relevant() {
+		t.Fatal("Expected cohort to be irrelevant without activity metric.",
+			"Got it as relevant")
+	}
+	c = &Cohort{dt: d, ms: cohortMetrics}
+	c.FSFA()
+	if !c.Relevant() {
+		t.Fatal("Expected cohort to be relevant with normal conditions.",
+			"Got it as irrelevant")
+	}
+}
+
+func TestCohort_Generate(t *testing.T) {
+	t.Run("Testing generate with monthly cohorts", func(t *testing.T) {
+		d := userActivity()
+		c := (&Cohort{}).Propose(d)[0].I.(*Cohort)
+		c.FSFA()
+		c.Generate()
+		if !c.Relevant() {
+			t.Fatal("Expected cohort to be relevant. Got irrelevant")
+		}
+		if len(c.Cohorts()) != 4 {
+			t.Fatal("Expected 4 cohorts. Got", len(c.Cohorts()))
+		}
+		first := c.Cohorts()[0]
+		if first.Cohort != "2019-01" || first.Size != 10 ||
+			len(first.Retention) != 4 || first.Retention[0] != 1 ||
+			first.Retention[1] != 0.5 {
+			t.Fatal("Expected 2019-01 cohort of 10 users with 50% retention.",
+				"Got", first)
+		}
+		if c.Best().Cohort != "2019-01" || c.Worst().Cohort != "2019-03" {
+			t.Fatal("Expected 2019-01 as best and 2019-03 as worst cohort. Got",
+				c.Best().Cohort, c.Worst().Cohort)
+		}
+		if math.Abs(c.Trend()+0.1) > 1e-9 {
+			t.Fatal("Expected a trend of -0.1. Got", c.Trend())
+		}
+		h, ok := c.Visual().(visualizations.HeatMap)
+		if !ok {
+			t.Fatal("Expected a heat map. Got", reflect.TypeOf(c.Visual()))
+		}
+		if len(h.Data()) != 4+3+2+1 {
+			t.Fatal("Expected 10 cells in the heat map. Got", len(h.Data()))
+		}
+	})
+
+	t.Run("Testing generate with missing users", func(t *testing.T) {
+		//anonymous events before and after the signups shouldn't form a user
+		d := userActivity()
+		users := append(d.DataS[d.Metrics["user"].Index], "", "", "x")
+		valid := make([]bool, len(users))
+		for i := range valid {
+			valid[i] = i != len(users)-1
+		}
+		e := NewDataset()
+		e.AddNullableMetric(cohortMetrics[0], users, valid)
+		e.AddMetric(cohortMetrics[1], append(d.DataS[d.Metrics["signup"].Index],
+			"2018-12-01", "2018-12-01", "2018-12-01"))
+		e.AddMetric(cohortMetrics[2], append(d.DataS[d.Metrics["active"].Index],
+			"2019-02-01", "2019-03-01", "2019-04-01"))
+		c := (&Cohort{}).Propose(e)[0].I.(*Cohort)
+		c.FSFA()
+		c.Generate()
+		if len(c.Cohorts()) != 4 {
+			t.Fatal("Expected 4 cohorts. Got", len(c.Cohorts()))
+		}
+		if first := c.Cohorts()[0]; first.Cohort != "2019-01" || first.Size != 10 {
+			t.Fatal("Expected 2019-01 cohort of 10 users. Got", first)
+		}
+	})
+
+	t.Run("Testing generate with too few cohorts", func(t *testing.T) {
+		d := NewDataset()
+		d.AddMetric(cohortMetrics[0], []string{"a", "a", "b"})
+		d.AddMetric(cohortMetrics[1], []string{"2019-01-01", "2019-01-01",
+			"2019-02-01"})
+		d.AddMetric(cohortMetrics[2], []string{"2019-01-01", "2019-02-01",
+			"2019-02-01"})
+		c := &Cohort{dt: d, ms: cohortMetrics}
+		c.FSFA()
+		c.Generate()
+		if c.Relevant() {
+			t.Fatal("Expected cohort to be irrelevant with a single mature",
+				"cohort. Got relevant")
+		}
+	})
+}
+
+func TestCohort_Propose(t *testing.T) {
+	d := userActivity()
+	if pro := (&Cohort{}).Propose(d); len(pro) != 1 {
+		t.Fatal("Expected 1 proposal. Got", len(pro))
+	}
+	d = NewDataset()
+	d.AddMetric(Metric{Name: "user", DataType: String}, []string{"a"})
+	if pro := (&Cohort{}).Propose(d); len(pro) != 0 {
+		t.Fatal("Expected no proposals. Got", len(pro))
+	}
+}
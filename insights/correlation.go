@@ -2,6 +2,7 @@ package insights
 
 import (
 	"log"
+	"math"
 	"strconv"
 
 	"github.com/cuttle-ai/brain/visualizations"
@@ -25,6 +26,12 @@ type Correlation struct {
 	dt       Dataset //dt is the dataset to be used for the corelation
 	//ms is the list of metrics on which correlation has to be found
 	ms []Metric
+	//spurious is true if both the metrics are non stationary over time.
+	//Such metrics are often correlated just because both drift over time.
+	spurious bool
+	//dropped is the no. of records left out as they have missing values in
+	//either of the metrics
+	dropped int
 }
 
 //New returns a new instance of the Correlation with
@@ -49,6 +56,19 @@ func (c *Correlation) Relevant() bool {
 	return c.relevant
 }
 
+//Spurious returns whether the correlation may be spurious as both the metrics
+//have unit roots when ordered in time. It is false if the dataset doesn't
+//have a time metric.
+func (c *Correlation) Spurious() bool {
+	return c.spurious
+}
+
+//Dropped returns the no. of records left out from the correlation as they
+//have missing values in either of the metrics
+func (c *Correlation) Dropped() int {
+	return c.dropped
+}
+
 //FSFA does the fast statistical feasibilty analysis over the dataset
 //with the given metrics whether the correlation is statistically
 //possible between the metrics. Note this function is still under development.
@@ -112,25 +132,40 @@ func (c *Correlation) Generate() {
 	}
 
 	//Running the correlation
-	corr, err := c.dt.Correlation(c.ms[0].Name, c.ms[1].Name, weights)
+	corr, dropped, err := c.dt.PairwiseCorrelation(c.ms[0].Name, c.ms[1].Name,
+		weights)
 	if err != nil {
 		//Error while generating the correlation between the variables
 		log.Println(err)
 		c.relevant = false
 		return
 	}
-	if corr < 0.7 {
-		//Don't bother to look for the correlation
+	if math.Abs(corr) < 0.7 {
+		//Don't bother to look for the correlation. Strong negative
+		//correlations are reported as well.
 		c.relevant = false
 		return
 	}
 
 	//Now we have a correlation.
+	//Checking whether it may be spurious
+	c.spurious = c.nonStationary()
+	c.dropped = dropped
+	desc := "have a correlation of " + strconv.FormatFloat(corr, 'f', -1, 64)
+	if c.spurious {
+		desc += ". Both are non stationary over time so the correlation may " +
+			"be spurious"
+	}
+	if c.dropped > 0 {
+		desc += ". " + strconv.Itoa(c.dropped) + " records with missing " +
+			"values were left out"
+	}
+
 	//Will create the visual for the same.
 	c.relevant = true
 	visual := visualizations.ScatterPlot{
 		T: c.ms[0].DisplayName + " and " + c.ms[1].DisplayName,
-		D: "have a correlation of " + strconv.FormatFloat(corr, 'f', -1, 64),
+		D: desc,
 		M: []visualizations.Metric{
 			{
 				Name:        c.ms[0].Name,
@@ -147,61 +182,54 @@ func (c *Correlation) Generate() {
 		},
 	}
 
-	data := make([]map[string]interface{}, len(c.dt.DataF[c.ms[0].Index]))
+	data := make([]map[string]interface{}, 0, len(c.dt.DataF[c.ms[0].Index]))
 
 	//Now we need to add data to the records
-	//Will iterate through each metric's data array and add them to the
-	//visualization data. First we will add the data from the first metric.
-	//Then from the second metric.
+	//Will iterate through the records and add the values of both the metrics
+	//to the visualization data. Records having missing values are skipped.
 	for i, v := range c.dt.DataF[c.ms[0].Index] {
-		data[i] = map[string]interface{}{}
-		data[i][c.ms[0].Name] = v
-	}
-	for i, v := range c.dt.DataF[c.ms[1].Index] {
-		data[i][c.ms[1].Name] = v
+		if !c.dt.Valid(c.ms[0].Name, i) || !c.dt.Valid(c.ms[1].Name, i) {
+			continue
+		}
+		data = append(data, map[string]interface{}{
+			c.ms[0].Name: v,
+			c.ms[1].Name: c.dt.DataF[c.ms[1].Index][i],
+		})
 	}
 	visual.Dt = data
 	c.visual = visual
 }
 
+//nonStationary returns whether both the metrics have unit roots when they
+//are ordered by the time metric of the dataset
+func (c *Correlation) nonStationary() bool {
+	tm, ok := timeMetric(c.dt)
+	if !ok || tm.Name == c.ms[0].Name || tm.Name == c.ms[1].Name {
+		return false
+	}
+	ax, ok := newTimeAxis(c.dt, tm)
+	if !ok {
+		return false
+	}
+	x, y, _ := ax.pairs(c.dt, c.ms[0], c.ms[1])
+	return nonStationary(x) && nonStationary(y)
+}
+
 //Propose suggests the possible insights from the domain knowledge.
 //This is a WIP. Please dont use it in production
 func (c *Correlation) Propose(d Dataset) []ProposedInsight {
 	/*
-		We will iterate through the metrics in the data set and select the
-		variables that have float data type.
-		We will get the combination of all the selected metrics as the group of
+		We will get the combination of all the float metrics as the group of
 		two. Then add it as proposed insight.
 	*/
 	//variable for storing the result
 	result := []ProposedInsight{}
-	//variable to store the float variables
-	svars := []Metric{}
-
-	//iterating through the dataset metrics to select the ones with float
-	//datatype.
-	for _, v := range d.Metrics {
-		if v.DataType != Float {
-			//We need to select only the float metrics
-			continue
-		}
-		svars = append(svars, v)
-	}
-
-	//iterating through the variables to create the proposals with the
-	//combination of all the float variables
-	for i := 0; i < len(svars)-1; i++ {
-		//We need to iterate from zeroth element to the penaltimate element
-		//Then again iterate to the elements starting from i+1 th element to the
-		//last element in the selected metric array.
-		for j := i + 1; j < len(svars); j++ {
-			//Now we have a combination we may now propose a insight
-			metrics := []Metric{svars[i], svars[j]}
-			result = append(result, ProposedInsight{
-				c.New(d, metrics),
-				metrics,
-			})
-		}
+	for _, metrics := range floatPairs(d, "") {
+		//Now we have a combination we may now propose a insight
+		result = append(result, ProposedInsight{
+			c.New(d, metrics),
+			metrics,
+		})
 	}
 	//Returning the resultset
 	return result
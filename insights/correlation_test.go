@@ -1,6 +1,7 @@
 package insights
 
 import (
+	"math/rand"
 	"reflect"
 	"testing"
 
@@ -156,6 +157,17 @@ var cGenerateTCs = []cGenerateTC{
 			{Name: "age", DataType: Float, Index: 0},
 			{Name: "height", DataType: Float, Index: 1},
 		}, true},
+	{"4", "Negatively correlated metrics", NewDataset(),
+		[]Metric{
+			{Name: "price", DataType: Float},
+			{Name: "demand", DataType: Float},
+		}, []interface{}{
+			[]float64{10, 20, 30},
+			[]float64{90, 60, 20},
+		}, []Metric{
+			{Name: "price", DataType: Float, Index: 0},
+			{Name: "demand", DataType: Float, Index: 1},
+		}, true},
 }
 
 func TestCorrelation_Generate(t *testing.T) {
@@ -255,3 +267,58 @@ func TestCorrelation_Propose(t *testing.T) {
 		})
 	}
 }
+
+func TestCorrelation_Spurious(t *testing.T) {
+	//random walk and a metric moving with it are correlated but both are
+	//non stationary. Noise and a metric moving with it are stationary.
+	r := rand.New(rand.NewSource(1))
+	days, walk, noise := make([]float64, 200), make([]float64, 200),
+		make([]float64, 200)
+	for i := range days {
+		days[i] = float64(i)
+		noise[i] = r.NormFloat64()
+		walk[i] = noise[i]
+		if i > 0 {
+			walk[i] += walk[i-1]
+		}
+	}
+	for _, v := range []struct {
+		name     string
+		series   []float64
+		spurious bool
+	}{{"random walk", walk, true}, {"noise", noise, false}} {
+		follower := make([]float64, len(v.series))
+		for i, x := range v.series {
+			follower[i] = 2*x + r.NormFloat64()*0.1
+		}
+		d := NewDataset()
+		d.AddMetric(Metric{Name: "day", DataType: Float,
+			Semantic: SemanticTime}, days)
+		d.AddMetric(Metric{Name: "x", DataType: Float}, v.series)
+		d.AddMetric(Metric{Name: "y", DataType: Float}, follower)
+		c := &Correlation{dt: d, ms: []Metric{d.Metrics["x"], d.Metrics["y"]}}
+		c.FSFA()
+		c.Generate()
+		if !c.Relevant() || c.Spurious() != v.spurious {
+			t.Fatal("Expected correlation with", v.name, "to be relevant with",
+				"spurious", v.spurious, "Got", c.Relevant(), c.Spurious())
+		}
+	}
+}
+
+func TestCorrelation_Dropped(t *testing.T) {
+	d := NewDataset()
+	d.AddNullableMetric(Metric{Name: "x", DataType: Float},
+		[]float64{1, 2, 3, 4, 5}, []bool{true, true, false, true, true})
+	d.AddMetric(Metric{Name: "y", DataType: Float}, []float64{2, 4, 0, 8, 10})
+	c := &Correlation{dt: d, ms: []Metric{d.Metrics["x"], d.Metrics["y"]}}
+	c.FSFA()
+	c.Generate()
+	if !c.Relevant() || c.Dropped() != 1 {
+		t.Fatal("Expected correlation with 1 dropped record. Got", c.Relevant(),
+			c.Dropped())
+	}
+	if n := len(c.Visual().Data()); n != 4 {
+		t.Fatal("Expected the complete records in the visual. Got", n)
+	}
+}
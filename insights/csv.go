@@ -0,0 +1,274 @@
+package insights
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+/*
+	This file contains the utilities for loading the datasets from CSV
+*/
+
+//DefaultNullMarkers are the values considered missing while reading a CSV
+//if the null markers are not given in the options
+var DefaultNullMarkers = []string{"NA", "N/A", "null", "NULL"}
+
+//CSVOptions are the options for reading a CSV
+type CSVOptions struct {
+	//Delimiter is the delimiter of the fields. It is comma if not set.
+	Delimiter rune
+	//NoHeader is true if the first row of the CSV is a record and not the
+	//header. Columns are named column_1, column_2 and so on in such cases.
+	NoHeader bool
+	//ThousandsSeparator is the separator of the thousands in the numbers like
+	//comma in 1,000. It is removed before parsing the numbers. Numbers are
+	//not expected to have separators if it is not set.
+	ThousandsSeparator rune
+	//DecimalSeparator is the separator of the decimals in the numbers. It is
+	//dot if not set.
+	DecimalSeparator rune
+	//NullMarkers are the values considered missing like NA. Empty values
+	//are always considered missing. DefaultNullMarkers are used if it is nil.
+	NullMarkers []string
+	//Types are the data types of the columns mapped to their names in the
+	//dataset. Types of the other columns are inferred. Numbers are inferred
+	//as Float so that the insights can use them. Int has to be given here
+	//for storing them as Int.
+	Types map[string]string
+	//LazyQuotes allows the quotes to appear in the unquoted fields and the
+	//non doubled quotes to appear in the quoted fields
+	LazyQuotes bool
+}
+
+//ReadCSV reads the CSV into a dataset. Data type of each column is inferred
+//as Bool, Float, Time or String in the order unless it is given in the
+//options. Column is inferred as a type only if all of its values that are
+//not missing can be parsed as the type. Names of the metrics are derived
+//from the headers like unit_price from Unit Price and the headers are used as
+//the display names. Missing values are stored as the missing values of the
+//dataset. It returns an error if the CSV couldn't be read or a value couldn't
+//be parsed as the data type given in the options.
+func ReadCSV(r io.Reader, opts CSVOptions) (Dataset, error) {
+	/*
+		We will read all the records of the CSV.
+		Then we take the header from the first record and name the columns.
+		Then for each column, we find the missing values and infer the data
+		type from the rest.
+		Then we parse the values and add the column to the dataset.
+	*/
+	d := NewDataset()
+
+	//reading the records
+	cr := csv.NewReader(r)
+	if opts.Delimiter != 0 {
+		cr.Comma = opts.Delimiter
+	}
+	cr.LazyQuotes = opts.LazyQuotes
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return d, &Error{ErrMReadCSV + err.Error(), ErrCReadFailed}
+	}
+	if len(rows) == 0 {
+		return d, nil
+	}
+
+	//naming the columns
+	headers := make([]string, len(rows[0]))
+	for i := range headers {
+		headers[i] = "Column " + strconv.Itoa(i+1)
+	}
+	if !opts.NoHeader {
+		for i, h := range rows[0] {
+			if h = strings.TrimSpace(h); len(h) > 0 {
+				headers[i] = h
+			}
+		}
+		rows = rows[1:]
+	}
+	names := metricNames(headers)
+
+	//adding the columns
+	markers := opts.NullMarkers
+	if markers == nil {
+		markers = DefaultNullMarkers
+	}
+	nulls := map[string]bool{"": true}
+	for _, m := range markers {
+		nulls[m] = true
+	}
+	for c := range headers {
+		vals := make([]string, len(rows))
+		valid := make([]bool, len(rows))
+		for i, row := range rows {
+			vals[i] = strings.TrimSpace(row[c])
+			valid[i] = !nulls[vals[i]]
+		}
+		dt, ok := opts.Types[names[c]]
+		if !ok {
+			dt = inferType(vals, valid, opts)
+		}
+		data, err := parseColumn(vals, valid, dt, opts)
+		if err != nil {
+			return d, &Error{ErrMParseCSV + names[c] + " as " + dt + ". " +
+				err.Error(), ErrCDataTypeMismatch}
+		}
+		m := Metric{Name: names[c], DisplayName: headers[c], DataType: dt}
+		if err := d.AddNullableMetric(m, data, valid); err != nil {
+			return d, err
+		}
+	}
+	return d, nil
+}
+
+//metricNames returns the names of the metrics for the headers. Headers are
+//lower cased and the runs of characters other than letters and digits are
+//replaced with underscores. Duplicate names are suffixed with their count,
+//skipping the suffixes already taken by other headers.
+func metricNames(headers []string) []string {
+	names := make([]string, len(headers))
+	seen := map[string]int{}
+	used := map[string]bool{}
+	for i, h := range headers {
+		var b strings.Builder
+		sep := false
+		for _, r := range strings.ToLower(h) {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				if sep && b.Len() > 0 {
+					b.WriteRune('_')
+				}
+				b.WriteRune(r)
+				sep = false
+				continue
+			}
+			sep = true
+		}
+		name := b.String()
+		if len(name) == 0 {
+			name = "column_" + strconv.Itoa(i+1)
+		}
+		//the suffixed name can itself be a header. So we keep counting till
+		//we find a name not taken yet.
+		base := name
+		for used[name] {
+			seen[base]++
+			name = base + "_" + strconv.Itoa(seen[base]+1)
+		}
+		used[name] = true
+		names[i] = name
+	}
+	return names
+}
+
+//inferType returns the data type of the column. It is the first of Bool,
+//Float and Time as which all the valid values can be parsed. Else it is
+//String. Columns without valid values are String.
+func inferType(vals []string, valid []bool, opts CSVOptions) string {
+	for _, dt := range []string{Bool, Float, Time} {
+		ok, found := true, false
+		for i, v := range vals {
+			if !valid[i] {
+				continue
+			}
+			found = true
+			if _, err := parseValue(v, dt, opts); err != nil {
+				ok = false
+				break
+			}
+		}
+		if ok && found {
+			return dt
+		}
+	}
+	return String
+}
+
+//parseColumn parses the valid values of the column as the data type. It
+//returns the data array for adding the column to the dataset.
+func parseColumn(vals []string, valid []bool, dt string, opts CSVOptions) (interface{}, error) {
+	n := len(vals)
+	var fs []float64
+	var is []int64
+	var bs []bool
+	var ts []time.Time
+	switch dt {
+	case Float:
+		fs = make([]float64, n)
+	case Int:
+		is = make([]int64, n)
+	case Bool:
+		bs = make([]bool, n)
+	case Time:
+		ts = make([]time.Time, n)
+	case String:
+		return vals, nil
+	default:
+		return nil, &Error{ErrMDAddMetricUnsupportedType + dt,
+			ErrCUnsupportedDataType}
+	}
+	for i, s := range vals {
+		if !valid[i] {
+			continue
+		}
+		v, err := parseValue(s, dt, opts)
+		if err != nil {
+			return nil, err
+		}
+		switch dt {
+		case Float:
+			fs[i] = v.(float64)
+		case Int:
+			is[i] = v.(int64)
+		case Bool:
+			bs[i] = v.(bool)
+		case Time:
+			ts[i] = v.(time.Time)
+		}
+	}
+	switch dt {
+	case Float:
+		return fs, nil
+	case Int:
+		return is, nil
+	case Bool:
+		return bs, nil
+	}
+	return ts, nil
+}
+
+//parseValue parses the value of a CSV field as the data type. Numbers are
+//parsed after removing the thousands separators and replacing the decimal
+//separator with dot. Bools are true, false, yes or no in any case.
+func parseValue(s, dt string, opts CSVOptions) (interface{}, error) {
+	switch dt {
+	case Float, Int:
+		if opts.ThousandsSeparator != 0 {
+			s = strings.Replace(s, string(opts.ThousandsSeparator), "", -1)
+		}
+		if opts.DecimalSeparator != 0 && opts.DecimalSeparator != '.' {
+			s = strings.Replace(s, string(opts.DecimalSeparator), ".", -1)
+		}
+		if dt == Int {
+			return strconv.ParseInt(s, 10, 64)
+		}
+		return strconv.ParseFloat(s, 64)
+	case Bool:
+		switch strings.ToLower(s) {
+		case "true", "yes":
+			return true, nil
+		case "false", "no":
+			return false, nil
+		}
+		return nil, &Error{ErrMParseValue + s, ErrCDataTypeMismatch}
+	case Time:
+		t, _, ok := parseTime(s)
+		if !ok {
+			return nil, &Error{ErrMParseValue + s, ErrCDataTypeMismatch}
+		}
+		return t, nil
+	}
+	return s, nil
+}
@@ -0,0 +1,134 @@
+package insights
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+/*
+	This file contains the tests for the CSV loader
+*/
+
+func TestReadCSV(t *testing.T) {
+	in := `Order Date,Unit Price,City,Paid,Qty
+2019-01-02,"1,250.50","Kochi, KL",yes,3
+2019-01-03,NA,Pune,no,4
+,99,"Say ""hi""",YES,
+`
+	d, err := ReadCSV(strings.NewReader(in), CSVOptions{ThousandsSeparator: ',',
+		Types: map[string]string{"qty": Int}})
+	if err != nil {
+		t.Fatal("Error while reading the CSV", err)
+	}
+	if d.Length != 3 || len(d.Metrics) != 5 {
+		t.Fatal("Expected 3 records of 5 metrics. Got", d.Length, len(d.Metrics))
+	}
+	types := map[string]string{"order_date": Time, "unit_price": Float,
+		"city": String, "paid": Bool, "qty": Int}
+	for name, dt := range types {
+		if m, ok := d.Metrics[name]; !ok || m.DataType != dt {
+			t.Fatal("Expected", name, "of type", dt, "Got", m, ok)
+		}
+	}
+	if d.Metrics["unit_price"].DisplayName != "Unit Price" {
+		t.Fatal("Expected the header as the display name. Got",
+			d.Metrics["unit_price"].DisplayName)
+	}
+	price := d.DataF[d.Metrics["unit_price"].Index]
+	if price[0] != 1250.5 || !math.IsNaN(price[1]) || price[2] != 99 {
+		t.Fatal("Expected the prices [1250.5 NaN 99]. Got", price)
+	}
+	if city := d.DataS[d.Metrics["city"].Index]; city[0] != "Kochi, KL" ||
+		city[2] != `Say "hi"` {
+		t.Fatal("Expected the quoted cities. Got", city)
+	}
+	if day := d.DataT[d.Metrics["order_date"].Index][0]; !day.Equal(
+		time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Fatal("Expected the order date 2019-01-02. Got", day)
+	}
+	if paid := d.DataB[d.Metrics["paid"].Index]; !paid[0] || paid[1] || !paid[2] {
+		t.Fatal("Expected paid [true false true]. Got", paid)
+	}
+	for name, nulls := range map[string]int{"order_date": 1, "unit_price": 1,
+		"qty": 1, "city": 0} {
+		if n := d.NullCount(name); n != nulls {
+			t.Fatal("Expected", nulls, "missing values in", name, "Got", n)
+		}
+	}
+}
+
+type readCSVTC struct {
+	ID          string
+	Description string
+	Input       string
+	Options     CSVOptions
+	Names       []string
+	Types       []string
+	Expected    error
+}
+
+var readCSVTCs = []readCSVTC{
+	{"1", "No header with semicolon delimiter", "1;a\n2;b\n",
+		CSVOptions{Delimiter: ';', NoHeader: true},
+		[]string{"column_1", "column_2"}, []string{Float, String}, nil},
+	{"2", "Decimal comma with dot thousands", "amount\n\"1.234,5\"\n\"7,25\"\n",
+		CSVOptions{ThousandsSeparator: '.', DecimalSeparator: ','},
+		[]string{"amount"}, []string{Float}, nil},
+	{"3", "Duplicate and empty headers", "Name,name,\nx,y,z\n", CSVOptions{},
+		[]string{"name", "name_2", "column_3"},
+		[]string{String, String, String}, nil},
+	{"4", "Custom null markers", "score\n-\n5\n",
+		CSVOptions{NullMarkers: []string{"-"}},
+		[]string{"score"}, []string{Float}, nil},
+	{"5", "Only missing values", "score\nNA\n\n", CSVOptions{},
+		[]string{"score"}, []string{String}, nil},
+	{"6", "Uneven records", "a,b\n1\n", CSVOptions{}, nil, nil,
+		&Error{ErrMReadCSV, ErrCReadFailed}},
+	{"7", "Value not of the given type", "qty\n1.5\n",
+		CSVOptions{Types: map[string]string{"qty": Int}}, nil, nil,
+		&Error{ErrMParseCSV, ErrCDataTypeMismatch}},
+	{"8", "Header same as a suffixed duplicate", "a,a,a_2\n1,2,3\n", CSVOptions{},
+		[]string{"a", "a_2", "a_2_2"}, []string{Float, Float, Float}, nil},
+}
+
+func TestReadCSV_Options(t *testing.T) {
+	for _, v := range readCSVTCs {
+		t.Run(v.ID, func(t *testing.T) {
+			d, err := ReadCSV(strings.NewReader(v.Input), v.Options)
+			if v.Expected != nil {
+				e, ok := err.(*Error)
+				if !ok || e.Code != v.Expected.(*Error).Code ||
+					!strings.HasPrefix(e.Message, v.Expected.(*Error).Message) {
+					t.Fatal("Expected error", v.Expected, "Got", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("Error while reading the CSV", err)
+			}
+			if len(d.Metrics) != len(v.Names) {
+				t.Fatal("Expected", len(v.Names), "metrics. Got", len(d.Metrics))
+			}
+			for i, name := range v.Names {
+				if m, ok := d.Metrics[name]; !ok || m.DataType != v.Types[i] {
+					t.Fatal("Expected", name, "of type", v.Types[i], "Got", m, ok)
+				}
+			}
+		})
+	}
+}
+
+func TestReadCSV_Values(t *testing.T) {
+	d, _ := ReadCSV(strings.NewReader("amount\n\"1.234,5\"\n\"7,25\"\n"),
+		CSVOptions{ThousandsSeparator: '.', DecimalSeparator: ','})
+	if a := d.DataF[0]; a[0] != 1234.5 || a[1] != 7.25 {
+		t.Fatal("Expected the amounts [1234.5 7.25]. Got", a)
+	}
+	d, _ = ReadCSV(strings.NewReader("score\n-\n5\n"),
+		CSVOptions{NullMarkers: []string{"-"}})
+	if d.NullCount("score") != 1 {
+		t.Fatal("Expected 1 missing score. Got", d.NullCount("score"))
+	}
+}
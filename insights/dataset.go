@@ -2,6 +2,10 @@ package insights
 
 import (
 	"log"
+	"math"
+	"sort"
+	"strconv"
+	"time"
 
 	"github.com/gonum/stat"
 )
@@ -16,6 +20,52 @@ const (
 	Float = "float64"
 	//String is used to denote the variables with data type string
 	String = "string"
+	//Int is used to denote the variables with data type int64
+	Int = "int64"
+	//Bool is used to denote the variables with data type bool
+	Bool = "bool"
+	//Time is used to denote the variables with data type time.Time.
+	//Metrics of the data type are used as the time axis by the insights
+	//unless they have a semantic other than SemanticTime.
+	Time = "time.Time"
+)
+
+const (
+	//SemanticTime is used to denote the metrics having the time at which the
+	//records occurred. Insights like forecast use it as the time axis.
+	SemanticTime = "time"
+	//SemanticUser is used to denote the metrics having the id of the user
+	//associated with the records
+	SemanticUser = "user"
+	//SemanticSignup is used to denote the metrics having the time at which
+	//the user of the records signed up
+	SemanticSignup = "signup"
+	//SemanticActivity is used to denote the metrics having the time at which
+	//the user of the records was active
+	SemanticActivity = "activity"
+	//SemanticStage is used to denote the metrics having the stages of a
+	//process like a funnel. Float metrics with the semantic have the no. of
+	//records reaching the stage. String metrics with the semantic have the
+	//name of the stage reached by the record.
+	SemanticStage = "stage"
+	//SemanticSegment is used to denote the metrics having the segment of
+	//the records like region or channel. Insights are compared across the
+	//segments.
+	SemanticSegment = "segment"
+	//SemanticTransaction is used to denote the metrics having the id of the
+	//transaction like an order or a basket to which the records belong
+	SemanticTransaction = "transaction"
+	//SemanticItem is used to denote the metrics having the item of the
+	//transaction like a product in an order
+	SemanticItem = "item"
+	//SemanticMonetary is used to denote the float metrics having amounts of
+	//money like revenue. They are divided by the count metrics for finding
+	//the ratios like average order value.
+	SemanticMonetary = "monetary"
+	//SemanticCount is used to denote the float metrics having the no. of
+	//occurrences like orders or clicks. They are the denominators of the
+	//ratios.
+	SemanticCount = "count"
 )
 
 //Dataset stores a data in columnar form
@@ -24,6 +74,17 @@ type Dataset struct {
 	DataF [][]float64
 	//DataS contains the metrics in the dataset that are of data type String
 	DataS [][]string
+	//DataI contains the metrics in the dataset that are of data type Int
+	DataI [][]int64
+	//DataB contains the metrics in the dataset that are of data type Bool
+	DataB [][]bool
+	//DataT contains the metrics in the dataset that are of data type Time
+	DataT [][]time.Time
+	//Validity has the validity bitmaps of the metrics having missing values
+	//mapped to their names. Bit of a record is set if its value is not
+	//missing. Metrics without a bitmap don't have missing values other than
+	//the NaN values of the float metrics.
+	Validity map[string]Bitmap
 	//Metrics has the map of metrics in a data set mapped to their names
 	Metrics map[string]Metric
 	//Length is the no of records in the dataset. It is set after the first
@@ -39,12 +100,361 @@ type Metric struct {
 	DataType string //DataType is the data type of the metric
 	//DisplayName is a friendly name to be used for displaying the metric name.
 	DisplayName string
+	//Semantic is the meaning of the metric in the domain like time.
+	//Insights use it for proposing the insights with domain knowledge.
+	Semantic string
+	//Thresholds are the limits the metric is expected to stay within like
+	//latency <= 200 or the targets it is expected to reach like
+	//revenue >= 1000. They are optional. Insights like threshold report the
+	//breaches of the limits and forecast flags when the metric is projected
+	//to cross them.
+	Thresholds []Limit
+	//Numerator and Denominator are the names of the metrics of which the
+	//metric is the ratio. They are set for the ratios derived by the
+	//WithRatios method of the dataset.
+	Numerator   string
+	Denominator string
+}
+
+const (
+	//AtLeast is the operator of the thresholds that the values should be
+	//greater than or equal to
+	AtLeast = ">="
+	//AtMost is the operator of the thresholds that the values should be
+	//less than or equal to
+	AtMost = "<="
+	//Above is the operator of the thresholds that the values should be
+	//greater than
+	Above = ">"
+	//Below is the operator of the thresholds that the values should be
+	//less than
+	Below = "<"
+)
+
+//Limit is a threshold the values of a metric are expected to satisfy
+type Limit struct {
+	//Operator is the comparison the values should satisfy. It is one of
+	//AtLeast, AtMost, Above and Below.
+	Operator string
+	Value    float64 //Value is the limit with which the values are compared
+}
+
+//Met returns whether the value satisfies the threshold. NaN values and
+//unknown operators doesn't satisfy any threshold.
+func (t Limit) Met(v float64) bool {
+	switch t.Operator {
+	case AtLeast:
+		return v >= t.Value
+	case AtMost:
+		return v <= t.Value
+	case Above:
+		return v > t.Value
+	case Below:
+		return v < t.Value
+	}
+	return false
+}
+
+//String returns the threshold in a readable form like >= 3
+func (t Limit) String() string {
+	return t.Operator + " " + strconv.FormatFloat(t.Value, 'f', -1, 64)
+}
+
+//displayName returns the display name of the metric. If the display name
+//of the metric is not set, name of the metric is returned.
+func displayName(m Metric) string {
+	if len(m.DisplayName) == 0 {
+		return m.Name
+	}
+	return m.DisplayName
+}
+
+//metricWithSemantic returns the metric in the dataset having the given
+//semantic. If there are more than one such metrics, the one with the least
+//name is returned. If the dataset doesn't have any, false is returned.
+func metricWithSemantic(d Dataset, semantic string) (Metric, bool) {
+	for _, m := range sortedMetrics(d) {
+		if m.Semantic == semantic {
+			return m, true
+		}
+	}
+	return Metric{}, false
+}
+
+//take returns the values of the metric in the given records as a data array
+//of its data type. Metrics of unsupported data types return nil.
+func take(d Dataset, m Metric, rows []int) interface{} {
+	switch m.DataType {
+	case Float:
+		vals := make([]float64, len(rows))
+		for i, r := range rows {
+			vals[i] = d.DataF[m.Index][r]
+		}
+		return vals
+	case String:
+		vals := make([]string, len(rows))
+		for i, r := range rows {
+			vals[i] = d.DataS[m.Index][r]
+		}
+		return vals
+	case Int:
+		vals := make([]int64, len(rows))
+		for i, r := range rows {
+			vals[i] = d.DataI[m.Index][r]
+		}
+		return vals
+	case Bool:
+		vals := make([]bool, len(rows))
+		for i, r := range rows {
+			vals[i] = d.DataB[m.Index][r]
+		}
+		return vals
+	case Time:
+		vals := make([]time.Time, len(rows))
+		for i, r := range rows {
+			vals[i] = d.DataT[m.Index][r]
+		}
+		return vals
+	}
+	return nil
+}
+
+//takeValid returns the validities of the metric in the given records
+func takeValid(d Dataset, name string, rows []int) []bool {
+	valid := make([]bool, len(rows))
+	for i, r := range rows {
+		valid[i] = d.Valid(name, r)
+	}
+	return valid
+}
+
+//keys returns the values of the metric as strings so that they can be used
+//as keys for grouping the records. Float, int and bool values are formatted
+//as strings. Times are formatted as per RFC 3339 so that they can be parsed
+//again. Missing values other than NaN are returned as empty strings.
+//If the metric doesn't have data in the dataset, false is returned.
+func keys(d Dataset, m Metric) ([]string, bool) {
+	switch m.DataType {
+	case Float:
+		if m.Index >= len(d.DataF) || int64(len(d.DataF[m.Index])) != d.Length {
+			return nil, false
+		}
+		ks := make([]string, d.Length)
+		for i, v := range d.DataF[m.Index] {
+			ks[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+		return ks, true
+	case String:
+		if m.Index >= len(d.DataS) || int64(len(d.DataS[m.Index])) != d.Length {
+			return nil, false
+		}
+		return d.DataS[m.Index], true
+	case Int:
+		if m.Index >= len(d.DataI) || int64(len(d.DataI[m.Index])) != d.Length {
+			return nil, false
+		}
+		ks := make([]string, d.Length)
+		bits, nullable := d.Validity[m.Name]
+		for i, v := range d.DataI[m.Index] {
+			if nullable && !bits.Get(i) {
+				continue
+			}
+			ks[i] = strconv.FormatInt(v, 10)
+		}
+		return ks, true
+	case Bool:
+		if m.Index >= len(d.DataB) || int64(len(d.DataB[m.Index])) != d.Length {
+			return nil, false
+		}
+		ks := make([]string, d.Length)
+		bits, nullable := d.Validity[m.Name]
+		for i, v := range d.DataB[m.Index] {
+			if nullable && !bits.Get(i) {
+				continue
+			}
+			ks[i] = strconv.FormatBool(v)
+		}
+		return ks, true
+	case Time:
+		if m.Index >= len(d.DataT) || int64(len(d.DataT[m.Index])) != d.Length {
+			return nil, false
+		}
+		ks := make([]string, d.Length)
+		for i, v := range d.DataT[m.Index] {
+			if v.IsZero() {
+				continue
+			}
+			ks[i] = v.Format(time.RFC3339Nano)
+		}
+		return ks, true
+	}
+	return nil, false
+}
+
+//sortedMetrics returns the metrics in the dataset sorted by their names.
+//It is used to keep the proposals of the insights reproducible.
+func sortedMetrics(d Dataset) []Metric {
+	ms := make([]Metric, 0, len(d.Metrics))
+	for _, m := range d.Metrics {
+		ms = append(ms, m)
+	}
+	sort.Slice(ms, func(i, j int) bool {
+		return ms[i].Name < ms[j].Name
+	})
+	return ms
+}
+
+//floatPairs returns the combinations of the float metrics in the dataset as
+//groups of two. The metric with the skip name is left out. It can be used to
+//leave out the time metric.
+func floatPairs(d Dataset, skip string) [][]Metric {
+	/*
+		We will iterate through the metrics in the data set and select the
+		variables that have float data type.
+		Then we iterate from zeroth element to the penaltimate element
+		Then again iterate to the elements starting from i+1 th element to the
+		last element in the selected metric array.
+	*/
+	svars := []Metric{}
+	for _, v := range sortedMetrics(d) {
+		if v.DataType != Float || v.Name == skip {
+			continue
+		}
+		svars = append(svars, v)
+	}
+	result := [][]Metric{}
+	for i := 0; i < len(svars)-1; i++ {
+		for j := i + 1; j < len(svars); j++ {
+			if derives(svars[i], svars[j]) || derives(svars[j], svars[i]) {
+				//ratios are related to their components by definition
+				continue
+			}
+			result = append(result, []Metric{svars[i], svars[j]})
+		}
+	}
+	return result
+}
+
+//derives returns whether the metric is a ratio derived from the other metric
+func derives(m, other Metric) bool {
+	return m.Numerator == other.Name || m.Denominator == other.Name
+}
+
+//WithRatios returns a copy of the dataset with the meaningful ratios of its
+//float metrics added as metrics. Monetary metrics are divided by the count
+//metrics like revenue per order. Among two count metrics, the one with the
+//smaller total is divided by the other like clicks per impression.
+//Records with zero or missing denominators have NaN ratios. Ratios whose
+//names are already in the dataset are not added again. So the method can be
+//called more than once. Pairs having a metric without data for all the
+//records are skipped. The data arrays of the existing metrics are shared
+//with the copy.
+func (d Dataset) WithRatios() Dataset {
+	/*
+		We will copy the data arrays and the metrics so that the given
+		dataset is not modified.
+		Then we find the pairs of the numerator and the denominator and add
+		the ratio of each pair.
+	*/
+	r := Dataset{
+		DataF:    append([][]float64{}, d.DataF...),
+		DataS:    append([][]string{}, d.DataS...),
+		DataI:    append([][]int64{}, d.DataI...),
+		DataB:    append([][]bool{}, d.DataB...),
+		DataT:    append([][]time.Time{}, d.DataT...),
+		Validity: make(map[string]Bitmap, len(d.Validity)),
+		Metrics:  make(map[string]Metric, len(d.Metrics)),
+		Length:   d.Length,
+	}
+	for k, m := range d.Metrics {
+		r.Metrics[k] = m
+	}
+	for k, b := range d.Validity {
+		r.Validity[k] = b
+	}
+	for _, pair := range ratioPairs(d) {
+		num, den := pair[0], pair[1]
+		name := num.Name + "_per_" + den.Name
+		if _, ok := r.Metrics[name]; ok {
+			continue
+		}
+		ns, nok := numbers(d, num)
+		ds, dok := numbers(d, den)
+		if !nok || !dok {
+			continue
+		}
+		vals := make([]float64, len(ns))
+		for i := range ns {
+			if ds[i] == 0 {
+				vals[i] = math.NaN()
+				continue
+			}
+			vals[i] = ns[i] / ds[i]
+		}
+		//the ratios are floats of the length of the dataset as the data of
+		//the pair is checked above. So adding them can't fail. The pair is
+		//skipped as the other pairs without data if it ever does.
+		if err := r.AddMetric(Metric{Name: name, DataType: Float,
+			DisplayName: displayName(num) + " per " + displayName(den),
+			Numerator:   num.Name, Denominator: den.Name}, vals); err != nil {
+			continue
+		}
+	}
+	return r
+}
+
+//ratioPairs returns the pairs of the numerator and the denominator metrics
+//of the meaningful ratios in the dataset
+func ratioPairs(d Dataset) [][]Metric {
+	ms := []Metric{}
+	for _, m := range sortedMetrics(d) {
+		if m.DataType != Float || m.Index >= len(d.DataF) ||
+			int64(len(d.DataF[m.Index])) != d.Length ||
+			(m.Semantic != SemanticMonetary && m.Semantic != SemanticCount) {
+			continue
+		}
+		ms = append(ms, m)
+	}
+	total := func(m Metric) float64 {
+		t := 0.0
+		for _, v := range d.DataF[m.Index] {
+			if !math.IsNaN(v) {
+				t += v
+			}
+		}
+		return t
+	}
+	result := [][]Metric{}
+	for _, num := range ms {
+		for _, den := range ms {
+			if num.Name == den.Name || den.Semantic != SemanticCount {
+				continue
+			}
+			if num.Semantic == SemanticCount {
+				//rates like clicks per impression are kept
+				tn, td := total(num), total(den)
+				if tn > td || (tn == td && num.Name > den.Name) {
+					continue
+				}
+			}
+			result = append(result, []Metric{num, den})
+		}
+	}
+	return result
 }
 
 //NewDataset returns an initialized Dataset.
 //The data arrays are initialized in the Dataset that is returned.
 func NewDataset() Dataset {
-	return Dataset{[][]float64{}, [][]string{}, map[string]Metric{}, 0}
+	return Dataset{
+		DataF:    [][]float64{},
+		DataS:    [][]string{},
+		DataI:    [][]int64{},
+		DataB:    [][]bool{},
+		DataT:    [][]time.Time{},
+		Validity: map[string]Bitmap{},
+		Metrics:  map[string]Metric{},
+	}
 }
 
 //AddMetric adds a metric to the dataset.
@@ -114,26 +524,247 @@ func (d *Dataset) AddMetric(m Metric, data interface{}) error {
 			d.Length = int64(len(ds))
 		}
 		break
+	case Int:
+		//We will try to do a type assertion for int
+		di, ok := data.([]int64)
+		if !ok {
+			//The given array is not int
+			return &Error{ErrMDAddMetricIntMismatch, ErrCDataTypeMismatch}
+		}
+		//Checking the length of the metric
+		if len(d.Metrics) != 0 && d.Length != int64(len(di)) {
+			//The given metric has incorrect no. of records
+			return &Error{ErrMMetricsDatasizeIncorrect, ErrCMetricSizeMismatch}
+		}
+
+		//Adding the metric to the dataset
+		d.DataI = append(d.DataI, di)
+		m.Index = len(d.DataI) - 1
+		d.Metrics[m.Name] = m
+
+		//Checking whether the metric was the first one
+		if len(d.Metrics) == 1 {
+			d.Length = int64(len(di))
+		}
+		break
+	case Bool:
+		//We will try to do a type assertion for bool
+		db, ok := data.([]bool)
+		if !ok {
+			//The given array is not bool
+			return &Error{ErrMDAddMetricBoolMismatch, ErrCDataTypeMismatch}
+		}
+		//Checking the length of the metric
+		if len(d.Metrics) != 0 && d.Length != int64(len(db)) {
+			//The given metric has incorrect no. of records
+			return &Error{ErrMMetricsDatasizeIncorrect, ErrCMetricSizeMismatch}
+		}
+
+		//Adding the metric to the dataset
+		d.DataB = append(d.DataB, db)
+		m.Index = len(d.DataB) - 1
+		d.Metrics[m.Name] = m
+
+		//Checking whether the metric was the first one
+		if len(d.Metrics) == 1 {
+			d.Length = int64(len(db))
+		}
+		break
+	case Time:
+		//We will try to do a type assertion for time
+		dt, ok := data.([]time.Time)
+		if !ok {
+			//The given array is not time
+			return &Error{ErrMDAddMetricTimeMismatch, ErrCDataTypeMismatch}
+		}
+		//Checking the length of the metric
+		if len(d.Metrics) != 0 && d.Length != int64(len(dt)) {
+			//The given metric has incorrect no. of records
+			return &Error{ErrMMetricsDatasizeIncorrect, ErrCMetricSizeMismatch}
+		}
+
+		//Adding the metric to the dataset
+		d.DataT = append(d.DataT, dt)
+		m.Index = len(d.DataT) - 1
+		d.Metrics[m.Name] = m
+
+		//Checking whether the metric was the first one
+		if len(d.Metrics) == 1 {
+			d.Length = int64(len(dt))
+		}
+		break
 	default:
 		return &Error{ErrMDAddMetricUnsupportedType + m.DataType,
 			ErrCUnsupportedDataType}
 	}
 
+	//Values of the metric replaced, if any, are no more missing
+	delete(d.Validity, m.Name)
+
 	//Everything went right
 	return nil
 }
 
+//AddNullableMetric adds a metric having missing values to the dataset.
+//The validity of each record has to be passed along with the data. It is
+//false for the records whose values are missing. Missing values are stored
+//as NaN for the float metrics, empty strings for the string metrics and
+//zero values for the others so that the insights skipping them work as
+//before. The given data is not modified. It returns the errors same as
+//AddMetric. It will also return an error if the no. of validities doesn't
+//match the no. of records in the data.
+func (d *Dataset) AddNullableMetric(m Metric, data interface{}, valid []bool) error {
+	/*
+		First we will replace the missing values in a copy of the data.
+		Then we add the metric with the data.
+		If any value is missing we store the validity bitmap of the metric.
+	*/
+	masked, n := maskMissing(data, valid)
+	if n >= 0 && n != len(valid) {
+		return &Error{ErrMValidityDatasizeIncorrect, ErrCMetricSizeMismatch}
+	}
+	if err := d.AddMetric(m, masked); err != nil {
+		return err
+	}
+	bits := NewBitmap(len(valid), true)
+	missing := false
+	for i, v := range valid {
+		if !v {
+			bits.Set(i, false)
+			missing = true
+		}
+	}
+	if !missing {
+		return nil
+	}
+	if d.Validity == nil {
+		d.Validity = map[string]Bitmap{}
+	}
+	d.Validity[m.Name] = bits
+	return nil
+}
+
+//maskMissing returns a copy of the data with the missing values replaced.
+//It returns the no. of records in the data. If the data is not of a
+//supported type, it is returned as such with -1 as the no. of records. If
+//the no. of records doesn't match the no. of validities, data is returned as
+//such.
+func maskMissing(data interface{}, valid []bool) (interface{}, int) {
+	switch v := data.(type) {
+	case []float64:
+		if len(v) != len(valid) {
+			return data, len(v)
+		}
+		c := append([]float64{}, v...)
+		for i := range c {
+			if !valid[i] {
+				c[i] = math.NaN()
+			}
+		}
+		return c, len(c)
+	case []string:
+		if len(v) != len(valid) {
+			return data, len(v)
+		}
+		c := append([]string{}, v...)
+		for i := range c {
+			if !valid[i] {
+				c[i] = ""
+			}
+		}
+		return c, len(c)
+	case []int64:
+		if len(v) != len(valid) {
+			return data, len(v)
+		}
+		c := append([]int64{}, v...)
+		for i := range c {
+			if !valid[i] {
+				c[i] = 0
+			}
+		}
+		return c, len(c)
+	case []bool:
+		if len(v) != len(valid) {
+			return data, len(v)
+		}
+		c := append([]bool{}, v...)
+		for i := range c {
+			if !valid[i] {
+				c[i] = false
+			}
+		}
+		return c, len(c)
+	case []time.Time:
+		if len(v) != len(valid) {
+			return data, len(v)
+		}
+		c := append([]time.Time{}, v...)
+		for i := range c {
+			if !valid[i] {
+				c[i] = time.Time{}
+			}
+		}
+		return c, len(c)
+	}
+	return data, -1
+}
+
+//Valid returns whether the value of the metric for the ith record is not
+//missing. NaN values of the float metrics are also considered missing.
+//It returns false if the metric or the record doesn't exist.
+func (d Dataset) Valid(name string, i int) bool {
+	m, ok := d.Metrics[name]
+	if !ok || i < 0 || int64(i) >= d.Length {
+		return false
+	}
+	if bits, ok := d.Validity[name]; ok && !bits.Get(i) {
+		return false
+	}
+	if m.DataType == Float {
+		return m.Index < len(d.DataF) && i < len(d.DataF[m.Index]) &&
+			!math.IsNaN(d.DataF[m.Index][i])
+	}
+	return true
+}
+
+//NullCount returns the no. of records whose values of the metric are
+//missing. NaN values of the float metrics are also counted.
+func (d Dataset) NullCount(name string) int {
+	c := 0
+	for i := 0; i < int(d.Length); i++ {
+		if !d.Valid(name, i) {
+			c++
+		}
+	}
+	return c
+}
+
 //Correlation finds the correlation between two variables in the dataset.
 //For finding the correlation between two variables, they must have same data
 // types and their data type must be Float. In these cases correlation will
-// be zero and an error will be returned
+// be zero and an error will be returned.
+//Records having missing values in either of the variables are left out.
+//Use PairwiseCorrelation for knowing the no. of records left out.
 func (d Dataset) Correlation(var1, var2 string, weights []float64) (
 	float64, error) {
+	corr, _, err := d.PairwiseCorrelation(var1, var2, weights)
+	return corr, err
+}
+
+//PairwiseCorrelation finds the correlation between two variables in the
+//dataset using the pairwise complete observations. Records having missing
+//values or NaN in either of the variables or NaN weights are left out and
+//their no. is returned along with the correlation. It returns the errors
+//same as Correlation.
+func (d Dataset) PairwiseCorrelation(var1, var2 string, weights []float64) (
+	corr float64, dropped int, err error) {
 	/*
 		First we will check whether the variables exists in the dataset
 		If the variables doesn't exist in the dataset,
 		or their data types are mismatch or their data type isn't Float
 		we will simply return 0.0 and an error
+		Then we will take the records having both the variables.
 		Else we will find the correlation and return them.
 	*/
 	//Checking whether the variabls exist in the dataset
@@ -142,17 +773,17 @@ func (d Dataset) Correlation(var1, var2 string, weights []float64) (
 
 	//If the variables doesn't exist we will return 0.0
 	if !ok1 || !ok2 {
-		return float64(0.0), &Error{ErrMDCorrelationNoVaraible, ErrCGeneric}
+		return float64(0.0), 0, &Error{ErrMDCorrelationNoVaraible, ErrCGeneric}
 	}
 	//If the data types doesn't match
 	if m1.DataType != m2.DataType {
-		return float64(0.0), &Error{ErrMDCorrelationDatatypeMismatch + m1.Name +
-			"(" + m1.DataType + ") and " + m2.Name + "(" +
+		return float64(0.0), 0, &Error{ErrMDCorrelationDatatypeMismatch +
+			m1.Name + "(" + m1.DataType + ") and " + m2.Name + "(" +
 			m2.DataType + ")", ErrCDataTypeMismatch}
 	}
 	//If the data types aren't Float
 	if m1.DataType != Float {
-		return float64(0.0), &Error{ErrMDCorrelationNonFloat + m1.DataType,
+		return float64(0.0), 0, &Error{ErrMDCorrelationNonFloat + m1.DataType,
 			ErrCUnsupportedDataType}
 	}
 
@@ -163,5 +794,28 @@ func (d Dataset) Correlation(var1, var2 string, weights []float64) (
 			log.Println(r)
 		}
 	}()
-	return stat.Correlation(d.DataF[m1.Index], d.DataF[m2.Index], weights), nil
+
+	//taking the pairwise complete observations
+	xs, ys := d.DataF[m1.Index], d.DataF[m2.Index]
+	b1, n1 := d.Validity[m1.Name]
+	b2, n2 := d.Validity[m2.Name]
+	x := make([]float64, 0, len(xs))
+	y := make([]float64, 0, len(ys))
+	var w []float64
+	if weights != nil {
+		w = make([]float64, 0, len(weights))
+	}
+	for i := range xs {
+		if math.IsNaN(xs[i]) || math.IsNaN(ys[i]) || (n1 && !b1.Get(i)) ||
+			(n2 && !b2.Get(i)) || (weights != nil && math.IsNaN(weights[i])) {
+			dropped++
+			continue
+		}
+		x = append(x, xs[i])
+		y = append(y, ys[i])
+		if weights != nil {
+			w = append(w, weights[i])
+		}
+	}
+	return stat.Correlation(x, y, w), dropped, nil
 }
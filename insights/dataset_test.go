@@ -1,7 +1,9 @@
 package insights
 
 import (
+	"math"
 	"testing"
+	"time"
 )
 
 /*
@@ -55,6 +57,27 @@ var dAddMetricTCs = []dAddMetricTC{
 			Name: "Company"}}},
 		Metric{Name: "Cars", DataType: Float}, []float64{1.1},
 		&Error{ErrMMetricsDatasizeIncorrect, ErrCMetricSizeMismatch}},
+	{"8", "Normal case Int", NewDataset(),
+		Metric{Name: "Cars", DataType: Int}, []int64{3}, nil},
+	{"9", "Normal case Bool", NewDataset(),
+		Metric{Name: "Electric", DataType: Bool}, []bool{true}, nil},
+	{"10", "Normal case Time", NewDataset(),
+		Metric{Name: "Launched", DataType: Time}, []time.Time{time.Now()}, nil},
+	{"11", "Metric data type given as int but provided data is different",
+		NewDataset(), Metric{Name: "Cars", DataType: Int}, []int{3},
+		&Error{ErrMDAddMetricIntMismatch, ErrCDataTypeMismatch}},
+	{"12", "Metric data type given as bool but provided data is different",
+		NewDataset(), Metric{Name: "Electric", DataType: Bool}, []string{"yes"},
+		&Error{ErrMDAddMetricBoolMismatch, ErrCDataTypeMismatch}},
+	{"13", "Metric data type given as time but provided data is different",
+		NewDataset(), Metric{Name: "Launched", DataType: Time},
+		[]string{"2019-01-01"},
+		&Error{ErrMDAddMetricTimeMismatch, ErrCDataTypeMismatch}},
+	{"14", "Metric data has unequal no. of records for time",
+		Dataset{Length: 2, Metrics: map[string]Metric{"Company": {
+			Name: "Company"}}},
+		Metric{Name: "Launched", DataType: Time}, []time.Time{{}},
+		&Error{ErrMMetricsDatasizeIncorrect, ErrCMetricSizeMismatch}},
 }
 
 func TestDataset_AddMetric(t *testing.T) {
@@ -154,3 +177,188 @@ func TestDataset_Correlation(t *testing.T) {
 		})
 	}
 }
+
+//ratioDataset returns a dataset with the revenue, the orders and the visits
+//of 4 records
+func ratioDataset() Dataset {
+	d := NewDataset()
+	d.AddMetric(Metric{Name: "revenue", DisplayName: "Revenue", DataType: Float,
+		Semantic: SemanticMonetary}, []float64{100, 50, 0, 30})
+	d.AddMetric(Metric{Name: "orders", DisplayName: "Orders", DataType: Float,
+		Semantic: SemanticCount}, []float64{2, 1, 0, 3})
+	d.AddMetric(Metric{Name: "visits", DisplayName: "Visits", DataType: Float,
+		Semantic: SemanticCount}, []float64{10, 10, 5, 10})
+	d.AddMetric(Metric{Name: "age", DataType: Float}, []float64{1, 2, 3, 4})
+	return d
+}
+
+func TestDataset_WithRatios(t *testing.T) {
+	d := ratioDataset()
+	r := d.WithRatios()
+	if len(d.Metrics) != 4 || len(d.DataF) != 4 {
+		t.Fatal("Expected the dataset not to be modified. Got", len(d.Metrics),
+			"metrics")
+	}
+	expected := map[string][]float64{
+		"revenue_per_orders": {50, 50, math.NaN(), 10},
+		"revenue_per_visits": {10, 5, 0, 3},
+		"orders_per_visits":  {0.2, 0.1, 0, 0.3},
+	}
+	if len(r.Metrics) != 4+len(expected) {
+		t.Fatal("Expected", len(expected), "ratios. Got", len(r.Metrics)-4)
+	}
+	for name, vals := range expected {
+		m, ok := r.Metrics[name]
+		if !ok {
+			t.Fatal("Expected the ratio", name, "Got", r.Metrics)
+		}
+		for i, v := range r.DataF[m.Index] {
+			if (math.IsNaN(vals[i]) && !math.IsNaN(v)) ||
+				(!math.IsNaN(vals[i]) && math.Abs(v-vals[i]) > 1e-9) {
+				t.Fatal("Expected", vals, "for", name, "Got", r.DataF[m.Index])
+			}
+		}
+	}
+	if m := r.Metrics["revenue_per_orders"]; m.DisplayName != "Revenue per Orders" ||
+		m.Numerator != "revenue" || m.Denominator != "orders" {
+		t.Fatal("Expected the ratio to refer to its components. Got", m)
+	}
+	if rr := r.WithRatios(); len(rr.Metrics) != len(r.Metrics) {
+		t.Fatal("Expected the ratios not to be added again. Got",
+			len(rr.Metrics), "metrics")
+	}
+
+	//pairs having a metric without data for all the records are skipped
+	d.DataF[d.Metrics["orders"].Index] = []float64{2, 1}
+	r = d.WithRatios()
+	if _, ok := r.Metrics["revenue_per_visits"]; !ok || len(r.Metrics) != 5 {
+		t.Fatal("Expected only the revenue per visits. Got", r.Metrics)
+	}
+}
+
+func TestFloatPairs(t *testing.T) {
+	d := ratioDataset().WithRatios()
+	for _, pair := range floatPairs(d, "age") {
+		if pair[0].Name == "age" || pair[1].Name == "age" {
+			t.Fatal("Expected age to be skipped. Got", pair[0].Name, pair[1].Name)
+		}
+		if derives(pair[0], pair[1]) || derives(pair[1], pair[0]) {
+			t.Fatal("Expected the ratios not to be paired with their",
+				"components. Got", pair[0].Name, pair[1].Name)
+		}
+	}
+	if pairs := floatPairs(d, "age"); len(pairs) != 9 {
+		t.Fatal("Expected 9 pairs. Got", len(pairs))
+	}
+}
+
+func TestKeys(t *testing.T) {
+	d := NewDataset()
+	d.AddMetric(Metric{Name: "id", DataType: Int}, []int64{7, -2})
+	d.AddMetric(Metric{Name: "paid", DataType: Bool}, []bool{true, false})
+	d.AddMetric(Metric{Name: "at", DataType: Time}, []time.Time{
+		time.Date(2019, 1, 2, 10, 0, 0, 0, time.UTC), {}})
+	expected := map[string][]string{
+		"id":   {"7", "-2"},
+		"paid": {"true", "false"},
+		"at":   {"2019-01-02T10:00:00Z", ""},
+	}
+	for name, vals := range expected {
+		ks, ok := keys(d, d.Metrics[name])
+		if !ok || len(ks) != 2 || ks[0] != vals[0] || ks[1] != vals[1] {
+			t.Fatal("Expected", vals, "for", name, "Got", ks, ok)
+		}
+	}
+}
+
+type dAddNullableMetricTC struct {
+	ID          string
+	Description string
+	Metric      Metric
+	Data        interface{}
+	Valid       []bool
+	Missing     int
+	Expected    error
+}
+
+var dAddNullableMetricTCs = []dAddNullableMetricTC{
+	{"1", "Float with missing values", Metric{Name: "age", DataType: Float},
+		[]float64{10, 20, 30}, []bool{true, false, true}, 1, nil},
+	{"2", "String with missing values", Metric{Name: "city", DataType: String},
+		[]string{"Kochi", "Pune", "Goa"}, []bool{false, true, false}, 2, nil},
+	{"3", "Int with missing values", Metric{Name: "id", DataType: Int},
+		[]int64{1, 2, 3}, []bool{true, true, false}, 1, nil},
+	{"4", "Bool with missing values", Metric{Name: "paid", DataType: Bool},
+		[]bool{true, false, true}, []bool{false, true, true}, 1, nil},
+	{"5", "Time with missing values", Metric{Name: "at", DataType: Time},
+		[]time.Time{time.Now(), time.Now(), time.Now()},
+		[]bool{true, false, false}, 2, nil},
+	{"6", "No missing values", Metric{Name: "age", DataType: Float},
+		[]float64{10, 20, 30}, []bool{true, true, true}, 0, nil},
+	{"7", "Validity of different length", Metric{Name: "age", DataType: Float},
+		[]float64{10, 20, 30}, []bool{true, true},
+		0, &Error{ErrMValidityDatasizeIncorrect, ErrCMetricSizeMismatch}},
+	{"8", "Data of different type", Metric{Name: "age", DataType: Float},
+		[]int{10, 20, 30}, []bool{true, true, true},
+		0, &Error{ErrMDAddMetricFloatMismatch, ErrCDataTypeMismatch}},
+}
+
+func TestDataset_AddNullableMetric(t *testing.T) {
+	for _, v := range dAddNullableMetricTCs {
+		t.Run(v.ID, func(t *testing.T) {
+			d := NewDataset()
+			err := d.AddNullableMetric(v.Metric, v.Data, v.Valid)
+			if (err == nil) != (v.Expected == nil) ||
+				(err != nil && err.Error() != v.Expected.Error()) {
+				t.Fatal("Failed", v.ID, "Expected:", v.Expected, "Got:", err)
+			}
+			if err != nil {
+				return
+			}
+			if n := d.NullCount(v.Metric.Name); n != v.Missing {
+				t.Fatal("Expected", v.Missing, "missing values. Got", n)
+			}
+			for i, valid := range v.Valid {
+				if d.Valid(v.Metric.Name, i) != valid {
+					t.Fatal("Expected validity", v.Valid, "Got a mismatch at", i)
+				}
+			}
+			if _, ok := d.Validity[v.Metric.Name]; ok != (v.Missing > 0) {
+				t.Fatal("Expected a bitmap only with missing values. Got", ok)
+			}
+		})
+	}
+
+	//the given data is not modified and the missing values are masked
+	data := []float64{1, 2}
+	d := NewDataset()
+	d.AddNullableMetric(Metric{Name: "age", DataType: Float}, data,
+		[]bool{false, true})
+	if data[0] != 1 || !math.IsNaN(d.DataF[0][0]) {
+		t.Fatal("Expected the missing value to be NaN in a copy. Got",
+			d.DataF[0], data)
+	}
+	//replacing the metric clears its bitmap
+	d.AddMetric(Metric{Name: "age", DataType: Float}, []float64{3, 4})
+	if d.NullCount("age") != 0 {
+		t.Fatal("Expected no missing values after replacing the metric")
+	}
+}
+
+func TestDataset_PairwiseCorrelation(t *testing.T) {
+	d := NewDataset()
+	d.AddNullableMetric(Metric{Name: "age", DataType: Float},
+		[]float64{10, 20, 30, 40, 50}, []bool{true, true, true, false, true})
+	d.AddMetric(Metric{Name: "height", DataType: Float},
+		[]float64{140, 178, 190, 150, math.NaN()})
+	corr, dropped, err := d.PairwiseCorrelation("age", "height", nil)
+	if err != nil || dropped != 2 {
+		t.Fatal("Expected 2 records to be dropped. Got", dropped, err)
+	}
+	if math.Abs(corr-0.9577677079477441) > 1e-12 {
+		t.Fatal("Expected the correlation of the complete records. Got", corr)
+	}
+	if c, err := d.Correlation("age", "height", nil); err != nil || c != corr {
+		t.Fatal("Expected the same correlation. Got", c, err)
+	}
+}
@@ -0,0 +1,393 @@
+package insights
+
+import (
+	"math"
+	"sort"
+
+	"github.com/cuttle-ai/brain/visualizations"
+	"github.com/gonum/stat"
+)
+
+/*
+	This file contains the utilities and structs required for non linear
+	dependence insights
+*/
+
+const (
+	//minDependenceRecords is the minimum no. of records having both the
+	//metrics required for finding the dependence
+	minDependenceRecords = 20
+	//maxDependenceSample is the maximum no. of records used for finding the
+	//distance correlation. Larger datasets are sampled as it takes quadratic
+	//time.
+	maxDependenceSample = 1000
+	//minDistanceCorrelation is the minimum distance correlation for the
+	//metrics to be dependent
+	minDistanceCorrelation = 0.4
+	//maxDependenceCorrelation is the maximum absolute correlation of the
+	//metrics. Metrics that are more correlated are reported by the
+	//correlation insight.
+	maxDependenceCorrelation = 0.7
+	//minDependenceGap is the minimum gap between the distance correlation and
+	//the absolute correlation for the dependence to be non linear
+	minDependenceGap = 0.2
+	//loessSpan is the fraction of the records used for smoothing each point
+	//of the LOESS curve
+	loessSpan = 0.3
+	//loessPoints is the maximum no. of points in the LOESS curve
+	loessPoints = 50
+)
+
+//Dependence is the non linear dependence insight.
+//It finds the metrics that are strongly dependent while their correlation is
+//weak. It uses the distance correlation which is zero only if the metrics are
+//independent. So it captures the U shaped and other non monotonic
+//relationships missed by the correlation.
+type Dependence struct {
+	//visual has the visualization to be used for showing the dependence.
+	//Scatter plot with the LOESS curve is used.
+	visual visualizations.Visual
+	//relevant stores the information whether the insight is relevant or not.
+	//This property is updated after running methods like FSFA and Generate
+	relevant bool
+	dt       Dataset //dt is the dataset to be used for the insight
+	//ms is the list of metrics. First one is plotted on the x axis and the
+	//second one on the y axis. Generate swaps them if the first one is better
+	//explained by the second one.
+	ms          []Metric
+	dcor        float64 //dcor is the distance correlation of the metrics
+	correlation float64 //correlation is the correlation of the metrics
+	//explained is the fraction of the variance of the second metric explained
+	//by its LOESS curve on the first metric
+	explained float64
+}
+
+//New returns a new instance of the Dependence with
+//initializations done for the given dataset
+func (de *Dependence) New(d Dataset, ms []Metric) Insight {
+	return &Dependence{dt: d, ms: ms}
+}
+
+//Visual returns the visualization to be used for visualizing the dependence
+func (de *Dependence) Visual() visualizations.Visual {
+	return de.visual
+}
+
+//Type returns the type string for the non linear dependence type of insight
+func (de *Dependence) Type() string {
+	return DEPENDENCE
+}
+
+//Relevant returns whether the insight is relevant or not for the given dataset.
+func (de *Dependence) Relevant() bool {
+	return de.relevant
+}
+
+//DistanceCorrelation returns the distance correlation of the metrics. It is
+//between 0 and 1 and is 0 only if the metrics are independent.
+func (de *Dependence) DistanceCorrelation() float64 {
+	return de.dcor
+}
+
+//Correlation returns the pearson correlation of the metrics
+func (de *Dependence) Correlation() float64 {
+	return de.correlation
+}
+
+//Explained returns the fraction of the variance of the metric on the y axis
+//explained by its LOESS curve
+func (de *Dependence) Explained() float64 {
+	return de.explained
+}
+
+//FSFA does the fast statistical feasibilty analysis over the dataset
+//with the given metrics whether the dependence can be found.
+//It requires two float metrics with atleast minDependenceRecords no. of
+//records.
+func (de *Dependence) FSFA() {
+	if len(de.ms) != 2 || de.ms[0].DataType != Float || de.ms[1].DataType != Float {
+		de.relevant = false
+		return
+	}
+	de.relevant = de.dt.Length >= minDependenceRecords
+}
+
+//Generate generates the non linear dependence insight for the datatset
+//associated with it for the provided metrics.
+//This method can only be run after running the FSFA.
+//Else the insight won't be generated
+func (de *Dependence) Generate() {
+	/*
+		If the insight is not relevant we won't event bother
+		to go forward.
+		We will take the records having both the metrics.
+		Then we find the correlation and the distance correlation on a sample
+		of the records.
+		The insight is relevant if the distance correlation is strong while
+		the correlation is weak.
+	*/
+	//Checking whether the existing relevance of the insight
+	if !de.relevant {
+		return
+	}
+	if de.ms[0].Index >= len(de.dt.DataF) || de.ms[1].Index >= len(de.dt.DataF) {
+		de.relevant = false
+		return
+	}
+
+	//taking the records having both the metrics
+	xs, ys := de.dt.DataF[de.ms[0].Index], de.dt.DataF[de.ms[1].Index]
+	x, y := []float64{}, []float64{}
+	for i := range xs {
+		if i >= len(ys) || math.IsNaN(xs[i]) || math.IsNaN(ys[i]) {
+			continue
+		}
+		x = append(x, xs[i])
+		y = append(y, ys[i])
+	}
+	if len(x) < minDependenceRecords {
+		de.relevant = false
+		return
+	}
+
+	//finding the dependence
+	de.correlation = stat.Correlation(x, y, nil)
+	if math.IsNaN(de.correlation) {
+		de.relevant = false
+		return
+	}
+	sx, sy := evenSample(x, maxDependenceSample), evenSample(y, maxDependenceSample)
+	de.dcor = distanceCorrelation(sx, sy)
+	abs := math.Abs(de.correlation)
+	if de.dcor < minDistanceCorrelation || abs >= maxDependenceCorrelation ||
+		de.dcor-abs < minDependenceGap {
+		de.relevant = false
+		return
+	}
+
+	//plotting the metric that is better explained by the other on the y axis
+	cx, cy, r2 := smooth(sx, sy)
+	if rx, ry, rr2 := smooth(sy, sx); rr2 > r2 {
+		de.ms = []Metric{de.ms[1], de.ms[0]}
+		sx, sy, cx, cy, r2 = sy, sx, rx, ry, rr2
+	}
+	de.explained = r2
+
+	de.relevant = true
+	de.visual = de.scatter(sx, sy, cx, cy)
+}
+
+//scatter returns the scatter plot of the metrics with the LOESS curve
+func (de *Dependence) scatter(x, y, cx, cy []float64) visualizations.ScatterPlot {
+	mx, my := de.ms[0], de.ms[1]
+	xname, yname := displayName(mx), displayName(my)
+	sname := my.Name + "_loess"
+	visual := visualizations.ScatterPlot{
+		T: yname + " depends on " + xname,
+		D: yname + " depends non linearly on " + xname + " with a distance " +
+			"correlation of " + formatFloat(de.dcor) + " though their " +
+			"correlation is only " + formatFloat(de.correlation) + ". The " +
+			"smoothed curve explains " + formatFloat(de.explained*100) + "% of " +
+			"the variance of " + yname,
+		M: []visualizations.Metric{
+			{Name: mx.Name, DisplayName: mx.DisplayName, DataType: Float,
+				Dimension: 0},
+			{Name: my.Name, DisplayName: my.DisplayName, DataType: Float,
+				Dimension: 1},
+			{Name: sname, DisplayName: "Smoothed " + yname, DataType: Float,
+				Dimension: 1},
+		},
+	}
+	data := make([]map[string]interface{}, 0, len(x)+loessPoints)
+	for i := range x {
+		data = append(data, map[string]interface{}{mx.Name: x[i], my.Name: y[i]})
+	}
+	for i := range cx {
+		data = append(data, map[string]interface{}{mx.Name: cx[i], sname: cy[i]})
+	}
+	visual.Dt = data
+	return visual
+}
+
+//Propose suggests the possible insights from the domain knowledge.
+//Each pair of float metrics is proposed. The time metric is not used.
+func (de *Dependence) Propose(d Dataset) []ProposedInsight {
+	result := []ProposedInsight{}
+	skip := ""
+	if tm, ok := timeMetric(d); ok {
+		skip = tm.Name
+	}
+	for _, metrics := range floatPairs(d, skip) {
+		result = append(result, ProposedInsight{de.New(d, metrics), metrics})
+	}
+	return result
+}
+
+//evenSample returns atmost max values evenly spaced in the given values
+func evenSample(v []float64, max int) []float64 {
+	if len(v) <= max {
+		return v
+	}
+	s := make([]float64, max)
+	for i := range s {
+		s[i] = v[i*len(v)/max]
+	}
+	return s
+}
+
+//distanceCorrelation returns the distance correlation of the series by
+//Szekely et al. (2007). It is between 0 and 1 and is 0 only if the series are
+//independent.
+func distanceCorrelation(x, y []float64) float64 {
+	/*
+		a[j][k] = |x[j] - x[k]| and b[j][k] = |y[j] - y[k]|.
+		A and B are a and b centered by subtracting the row and the column
+		means and adding the grand mean.
+		dCov^2 = mean(A*B), dVar^2(x) = mean(A*A) and dVar^2(y) = mean(B*B)
+		dCor = sqrt(dCov^2 / sqrt(dVar^2(x) * dVar^2(y)))
+		As the distances are symmetric, the column means are same as the row
+		means. So we find the centered distances without storing them.
+	*/
+	n := len(x)
+	if n == 0 {
+		return 0
+	}
+	rowA, rowB := make([]float64, n), make([]float64, n)
+	meanA, meanB := 0.0, 0.0
+	for j := 0; j < n; j++ {
+		for k := 0; k < n; k++ {
+			rowA[j] += math.Abs(x[j] - x[k])
+			rowB[j] += math.Abs(y[j] - y[k])
+		}
+		meanA += rowA[j]
+		meanB += rowB[j]
+		rowA[j] /= float64(n)
+		rowB[j] /= float64(n)
+	}
+	meanA /= float64(n * n)
+	meanB /= float64(n * n)
+	cov, varX, varY := 0.0, 0.0, 0.0
+	for j := 0; j < n; j++ {
+		for k := 0; k < n; k++ {
+			a := math.Abs(x[j]-x[k]) - rowA[j] - rowA[k] + meanA
+			b := math.Abs(y[j]-y[k]) - rowB[j] - rowB[k] + meanB
+			cov += a * b
+			varX += a * a
+			varY += b * b
+		}
+	}
+	if varX <= 0 || varY <= 0 {
+		return 0
+	}
+	r := cov / math.Sqrt(varX*varY)
+	if r <= 0 {
+		return 0
+	}
+	return math.Sqrt(r)
+}
+
+//loess returns the LOESS curve of y on x. Each point of the curve is the
+//local linear regression on the nearest span fraction of the records weighted
+//by the tricube of their distances. The curve is found at atmost the given
+//no. of points evenly spaced in the sorted x.
+func loess(x, y []float64, span float64, points int) ([]float64, []float64) {
+	n := len(x)
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool { return x[order[i]] < x[order[j]] })
+	k := int(math.Ceil(span * float64(n)))
+	if k < 3 {
+		k = 3
+	}
+	if k > n {
+		k = n
+	}
+	if points > n {
+		points = n
+	}
+	step := points - 1
+	if step < 1 {
+		step = 1
+	}
+	cx, cy := []float64{}, []float64{}
+	dist := make([]float64, n)
+	for p := 0; p < points; p++ {
+		x0 := x[order[p*(n-1)/step]]
+		if len(cx) > 0 && cx[len(cx)-1] == x0 {
+			continue
+		}
+		//finding the bandwidth as the distance of the kth nearest record
+		for i := range x {
+			dist[i] = math.Abs(x[i] - x0)
+		}
+		sorted := append([]float64{}, dist...)
+		sort.Float64s(sorted)
+		h := sorted[k-1]
+
+		//fitting the weighted linear regression
+		sw, swx, swy, swxx, swxy := 0.0, 0.0, 0.0, 0.0, 0.0
+		for i := range x {
+			w := 1.0
+			if h > 0 {
+				u := dist[i] / h
+				if u >= 1 {
+					continue
+				}
+				w = math.Pow(1-u*u*u, 3)
+			} else if dist[i] > 0 {
+				continue
+			}
+			dx := x[i] - x0
+			sw += w
+			swx += w * dx
+			swy += w * y[i]
+			swxx += w * dx * dx
+			swxy += w * dx * y[i]
+		}
+		if sw == 0 {
+			continue
+		}
+		//value of the fit at x0 is its intercept as x is centered at x0
+		det := sw*swxx - swx*swx
+		v := swy / sw
+		if det > 1e-12*sw*swxx {
+			v = (swxx*swy - swx*swxy) / det
+		}
+		cx = append(cx, x0)
+		cy = append(cy, v)
+	}
+	return cx, cy
+}
+
+//smooth returns the LOESS curve of y on x and the fraction of the variance of
+//y explained by it. Values of the curve between its points are interpolated
+//linearly.
+func smooth(x, y []float64) ([]float64, []float64, float64) {
+	cx, cy := loess(x, y, loessSpan, loessPoints)
+	if len(cx) == 0 {
+		return cx, cy, 0
+	}
+	mean := stat.Mean(y, nil)
+	rss, tss := 0.0, 0.0
+	for i, v := range x {
+		j := sort.SearchFloat64s(cx, v)
+		fit := 0.0
+		switch {
+		case j == 0:
+			fit = cy[0]
+		case j == len(cx):
+			fit = cy[len(cy)-1]
+		default:
+			f := (v - cx[j-1]) / (cx[j] - cx[j-1])
+			fit = cy[j-1] + f*(cy[j]-cy[j-1])
+		}
+		rss += (y[i] - fit) * (y[i] - fit)
+		tss += (y[i] - mean) * (y[i] - mean)
+	}
+	if tss == 0 {
+		return cx, cy, 0
+	}
+	return cx, cy, 1 - rss/tss
+}
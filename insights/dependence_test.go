@@ -0,0 +1,172 @@
+package insights
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/cuttle-ai/brain/visualizations"
+)
+
+/*
+	This file contains the tests for the non linear dependence insight
+*/
+
+//dependenceDataset returns a dataset of 300 records with the temperature and
+//the energy usage. Usage is U shaped in the temperature if shaped is true.
+//Else it is independent of the temperature.
+func dependenceDataset(shaped bool) Dataset {
+	r := rand.New(rand.NewSource(4))
+	n := 300
+	temp := make([]float64, n)
+	usage := make([]float64, n)
+	for i := range temp {
+		temp[i] = -10 + 20*r.Float64()
+		usage[i] = 100 + 5*r.NormFloat64()
+		if shaped {
+			usage[i] += temp[i] * temp[i]
+		}
+	}
+	d := NewDataset()
+	d.AddMetric(Metric{Name: "temp", DisplayName: "Temperature",
+		DataType: Float}, temp)
+	d.AddMetric(Metric{Name: "usage", DisplayName: "Energy usage",
+		DataType: Float}, usage)
+	return d
+}
+
+func TestDependence_New(t *testing.T) {
+	d := dependenceDataset(true)
+	di := (&Dependence{}).New(d, []Metric{d.Metrics["temp"], d.Metrics["usage"]})
+	de, ok := di.(*Dependence)
+	if !ok {
+		t.Fatal("Expected a dependence. Got", reflect.TypeOf(di))
+	}
+	if len(de.ms) != 2 {
+		t.Fatal("Expected 2 metrics. Got", len(de.ms))
+	}
+}
+
+func TestDependence_Type(t *testing.T) {
+	de := &Dependence{}
+	if de.Type() != DEPENDENCE {
+		t.Fatal("Expected insight type is", DEPENDENCE, "Got", de.Type())
+	}
+}
+
+func TestDependence_FSFA(t *testing.T) {
+	d := dailySeries([]float64{1, 2})
+	de := &Dependence{dt: d, ms: []Metric{d.Metrics["day"], d.Metrics["value"]}}
+	de.FSFA()
+	if de.Relevant() {
+		t.Fatal("Expected dependence to be irrelevant with 2 records.",
+			"Got it as relevant")
+	}
+	d = dependenceDataset(true)
+	de = &Dependence{dt: d, ms: []Metric{d.Metrics["temp"], d.Metrics["usage"]}}
+	de.FSFA()
+	if !de.Relevant() {
+		t.Fatal("Expected dependence to be relevant with normal conditions.",
+			"Got it as irrelevant")
+	}
+}
+
+func TestDependence_Generate(t *testing.T) {
+	t.Run("Testing generate with U shaped metrics", func(t *testing.T) {
+		d := dependenceDataset(true)
+		//usage is given on the x axis to check whether the axes are swapped
+		de := &Dependence{dt: d, ms: []Metric{d.Metrics["usage"],
+			d.Metrics["temp"]}}
+		de.FSFA()
+		de.Generate()
+		if !de.Relevant() {
+			t.Fatal("Expected dependence to be relevant. Got irrelevant",
+				de.DistanceCorrelation(), de.Correlation())
+		}
+		if math.Abs(de.Correlation()) > 0.3 || de.DistanceCorrelation() < 0.4 {
+			t.Fatal("Expected weak correlation and strong distance correlation.",
+				"Got", de.Correlation(), de.DistanceCorrelation())
+		}
+		if de.Explained() < 0.8 {
+			t.Fatal("Expected the curve to explain most of the usage. Got",
+				de.Explained())
+		}
+		s, ok := de.Visual().(visualizations.ScatterPlot)
+		if !ok {
+			t.Fatal("Expected a scatter plot. Got", reflect.TypeOf(de.Visual()))
+		}
+		ms := s.Metrics()
+		if len(ms) != 3 || ms[0].Name != "temp" || ms[1].Name != "usage" {
+			t.Fatal("Expected temperature on x axis with usage and its curve.",
+				"Got", ms)
+		}
+		if len(s.Data()) <= 300 {
+			t.Fatal("Expected the records and the curve. Got", len(s.Data()),
+				"records")
+		}
+	})
+
+	t.Run("Testing generate with independent metrics", func(t *testing.T) {
+		d := dependenceDataset(false)
+		de := &Dependence{dt: d, ms: []Metric{d.Metrics["temp"],
+			d.Metrics["usage"]}}
+		de.FSFA()
+		de.Generate()
+		if de.Relevant() {
+			t.Fatal("Expected independent metrics to be irrelevant. Got",
+				de.DistanceCorrelation())
+		}
+	})
+
+	t.Run("Testing generate with linear metrics", func(t *testing.T) {
+		d := dailySeries(stationarityCases()[TrendStationary])
+		de := &Dependence{dt: d, ms: []Metric{d.Metrics["day"],
+			d.Metrics["value"]}}
+		de.FSFA()
+		de.Generate()
+		if de.Relevant() {
+			t.Fatal("Expected linear metrics to be left to the correlation.",
+				"Got relevant")
+		}
+	})
+}
+
+func TestDependence_Propose(t *testing.T) {
+	d := dependenceDataset(true)
+	if pro := (&Dependence{}).Propose(d); len(pro) != 1 {
+		t.Fatal("Expected 1 proposal. Got", len(pro))
+	}
+	d = dailySeries([]float64{1, 2})
+	if pro := (&Dependence{}).Propose(d); len(pro) != 0 {
+		t.Fatal("Expected the time metric not to be proposed. Got", len(pro))
+	}
+}
+
+func TestDistanceCorrelation(t *testing.T) {
+	x := []float64{1, 2, 3, 4, 5}
+	if r := distanceCorrelation(x, []float64{3, 5, 7, 9, 11}); math.Abs(r-1) > 1e-9 {
+		t.Fatal("Expected distance correlation of 1 for linear series. Got", r)
+	}
+	if r := distanceCorrelation(x, []float64{2, 2, 2, 2, 2}); r != 0 {
+		t.Fatal("Expected distance correlation of 0 for constant series. Got", r)
+	}
+}
+
+func TestLoess(t *testing.T) {
+	x := make([]float64, 40)
+	y := make([]float64, 40)
+	for i := range x {
+		x[i] = float64(i)
+		y[i] = 2*x[i] + 1
+	}
+	cx, cy := loess(x, y, 0.3, 10)
+	if len(cx) != 10 {
+		t.Fatal("Expected 10 points. Got", len(cx))
+	}
+	for i := range cx {
+		if math.Abs(cy[i]-(2*cx[i]+1)) > 1e-9 {
+			t.Fatal("Expected the curve to follow the line. Got", cx[i], cy[i])
+		}
+	}
+}
@@ -19,6 +19,12 @@ const (
 	//ErrCMetricSizeMismatch indicates that the size of the metric provided is
 	//mistmatch with that of the existing
 	ErrCMetricSizeMismatch = 3
+	//ErrCReadFailed indicates that the data couldn't be read from the source
+	ErrCReadFailed = 4
+	//ErrCSchemaConflict indicates that the records have conflicting schemas
+	ErrCSchemaConflict = 5
+	//ErrCWriteFailed indicates that the data couldn't be written
+	ErrCWriteFailed = 6
 )
 
 const (
@@ -34,6 +40,24 @@ const (
 	//But provided data has different data type
 	ErrMDAddMetricStringMismatch = "The given data is not of the type []" +
 		String + " while the metric data type is" + String
+	//ErrMDAddMetricIntMismatch is the error message given by add metric
+	//method of the dataset
+	//when trying to add a int data metric.
+	//But provided data has different data type
+	ErrMDAddMetricIntMismatch = "The given data is not of the type []" +
+		Int + " while the metric data type is" + Int
+	//ErrMDAddMetricBoolMismatch is the error message given by add metric
+	//method of the dataset
+	//when trying to add a bool data metric.
+	//But provided data has different data type
+	ErrMDAddMetricBoolMismatch = "The given data is not of the type []" +
+		Bool + " while the metric data type is" + Bool
+	//ErrMDAddMetricTimeMismatch is the error message given by add metric
+	//method of the dataset
+	//when trying to add a time data metric.
+	//But provided data has different data type
+	ErrMDAddMetricTimeMismatch = "The given data is not of the type []" +
+		Time + " while the metric data type is" + Time
 	//ErrMDAddMetricUnsupportedType is the error message given by add metric of
 	//the dataset when trying to add a metric of unsupported datatype.
 	ErrMDAddMetricUnsupportedType = "Unsupported datatype. Got "
@@ -50,6 +74,87 @@ const (
 	//records in the /metric is != to that Length property of the dataset
 	ErrMMetricsDatasizeIncorrect = "The no. of records provided in the " +
 		"metric mismatch to that of the dataset"
+	//ErrMValidityDatasizeIncorrect is the error message informing the no. of
+	//validities provided for a nullable metric is != to the no. of records
+	//in the metric
+	ErrMValidityDatasizeIncorrect = "The no. of validities provided " +
+		"mismatch to the no. of records in the metric"
+	//ErrMReadCSV is the error message given by read csv when the CSV couldn't
+	//be read
+	ErrMReadCSV = "Couldn't read the CSV. Got "
+	//ErrMParseCSV is the error message given by read csv when the values of a
+	//column couldn't be parsed as the data type given in the options
+	ErrMParseCSV = "Couldn't parse the column "
+	//ErrMParseValue is the error message given when a value couldn't be
+	//parsed as the data type
+	ErrMParseValue = "Couldn't parse the value "
+	//ErrMReadJSON is the error message given by read json when the JSON
+	//couldn't be read
+	ErrMReadJSON = "Couldn't read the JSON. Got "
+	//ErrMJSONRecord is the error message given by read json when a record is
+	//not an object
+	ErrMJSONRecord = "Expected a JSON object as the record "
+	//ErrMSchemaConflict is the error message given when the records have
+	//conflicting types for a metric
+	ErrMSchemaConflict = "Conflicting schema for the metric "
+	//ErrMQuery is the error message given by read sql when the query couldn't
+	//be run
+	ErrMQuery = "Couldn't run the query. Got "
+	//ErrMSampleDialect is the error message given by read sql when the
+	//sampling is asked for an unknown SQL dialect
+	ErrMSampleDialect = "Sampling is not supported for the SQL dialect "
+	//ErrMSQLColumn is the error message given by read sql when the values of
+	//a column couldn't be converted to its data type
+	ErrMSQLColumn = "Couldn't convert the values of the SQL column "
+	//ErrMWriteArrow is the error message given by write arrow when the
+	//dataset couldn't be written as arrow IPC file
+	ErrMWriteArrow = "Couldn't write the arrow file. Got "
+	//ErrMReadArrow is the error message given by read arrow when the arrow
+	//IPC file couldn't be read
+	ErrMReadArrow = "Couldn't read the arrow file. Got "
+	//ErrMReadParquet is the error message given by read parquet when the
+	//parquet file couldn't be read
+	ErrMReadParquet = "Couldn't read the parquet file. Got "
+	//ErrMParquetColumn is the error message given by read parquet when a
+	//column to be read doesn't exist or is repeated
+	ErrMParquetColumn = "Couldn't find a non repeated column in the parquet file named "
+	//ErrMWriteSnapshot is the error message given by write snapshot when the
+	//snapshot couldn't be written
+	ErrMWriteSnapshot = "Couldn't write the snapshot. Got "
+	//ErrMReadSnapshot is the error message given when the snapshot couldn't
+	//be read
+	ErrMReadSnapshot = "Couldn't read the snapshot. Got "
+	//ErrMSnapshotVersion is the error message given when the snapshot is of
+	//a later version than the supported one
+	ErrMSnapshotVersion = "Unsupported version of the snapshot "
+	//ErrMSnapshotMetric is the error message given when the data of a metric
+	//in the snapshot mismatch to the no. of records in the dataset
+	ErrMSnapshotMetric = "The no. of records in the snapshot mismatch for the metric "
+	//ErrMSnapshotValidity is the error message given when the validities in
+	//the snapshot are not of a metric or don't cover all the records of it
+	ErrMSnapshotValidity = "The validities in the snapshot mismatch for the metric "
+	//ErrMSnapshotName is the error message given when the name of a metric
+	//in the snapshot mismatch to the key it is stored with
+	ErrMSnapshotName = "The name of the metric in the snapshot mismatch to its key "
+	//ErrMMetricNotFound is the error message given when a metric doesn't
+	//exist in the dataset
+	ErrMMetricNotFound = "Couldn't find the metric in the dataset "
+	//ErrMDuplicateMetric is the error message given when a metric is given
+	//more than once
+	ErrMDuplicateMetric = "The metric is given more than once "
+	//ErrMAggregateFunc is the error message given by aggregate when the
+	//aggregation function is not supported
+	ErrMAggregateFunc = "Unsupported aggregation function "
+	//ErrMAggregateType is the error message given by aggregate when a
+	//non numeric metric is aggregated by a numeric function
+	ErrMAggregateType = "Only " + Float + " and " + Int + " metrics can be " +
+		"aggregated by "
+	//ErrMAggregateName is the error message given by aggregate when the name
+	//of an aggregated metric is already taken in the result
+	ErrMAggregateName = "The name is already taken in the aggregated dataset "
+	//ErrMGranularity is the error message given by aggregate when the
+	//granularity of the time dimensions is not supported
+	ErrMGranularity = "Unsupported granularity "
 )
 
 //Error will be used to return errors in the insights package functions
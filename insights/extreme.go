@@ -0,0 +1,356 @@
+package insights
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/cuttle-ai/brain/visualizations"
+	"github.com/gonum/stat"
+)
+
+/*
+	This file contains the utilities and structs required for extreme value
+	insights
+*/
+
+const (
+	//minExtremeRecords is the minimum no. of records in the history of a
+	//metric required for finding whether its latest value is extreme
+	minExtremeRecords = 6
+	//minExtremeLookback is the minimum no. of previous records the latest
+	//value should be beyond to be reported when it isn't an all time extreme
+	minExtremeLookback = 6
+	//minExtremeCategories is the minimum no. of categories required for
+	//finding the extreme category
+	minExtremeCategories = 4
+	//extremeZ is the minimum no. of standard deviations a category should be
+	//away from its peers to be extreme
+	extremeZ = 3
+	//extremeRelative is the minimum difference from the mean of its peers
+	//relative to the mean a category should have to be extreme when the
+	//values of the peers are all the same
+	extremeRelative = 0.5
+	//maxExtremeBars is the maximum no. of categories shown in the bar chart
+	//apart from the extreme ones
+	maxExtremeBars = 20
+)
+
+const (
+	//ExtremeHigh denotes that the value is higher than the others
+	ExtremeHigh = "high"
+	//ExtremeLow denotes that the value is lower than the others
+	ExtremeLow = "low"
+)
+
+//ExtremeValue is a value of a metric that is beyond the other values
+type ExtremeValue struct {
+	Kind string //Kind is either ExtremeHigh or ExtremeLow
+	//At is the time of the latest record or the category having the value.
+	//Time has the same data type as that of time metric.
+	At    interface{}
+	Value float64 //Value is the extreme value
+	//Lookback is the no. of previous records the latest value is beyond.
+	//For the categories it is the no. of peers of the category.
+	Lookback int
+	//AllTime is true if the latest value is beyond all the previous records
+	AllTime bool
+}
+
+//Extreme is the extreme value insight.
+//It finds whether the latest value of a metric recorded over time is a
+//record high or low. For a metric across categories, it finds the
+//categories whose values are extreme compared to their peers.
+type Extreme struct {
+	//visual has the visualization to be used for showing the extreme values.
+	//Line chart is used for the metrics recorded over time and bar chart for
+	//the categories. The extreme values are annotated.
+	visual visualizations.Visual
+	//relevant stores the information whether the insight is relevant or not.
+	//This property is updated after running methods like FSFA and Generate
+	relevant bool
+	dt       Dataset //dt is the dataset to be used for the insight
+	//ms is the list of metrics. First one is the float metric and the second
+	//one is either the time metric or the category metric.
+	ms       []Metric
+	extremes []ExtremeValue //extremes has the extreme values found
+}
+
+//New returns a new instance of the Extreme with
+//initializations done for the given dataset
+func (e *Extreme) New(d Dataset, ms []Metric) Insight {
+	return &Extreme{dt: d, ms: ms}
+}
+
+//Visual returns the visualization to be used for visualizing the extremes
+func (e *Extreme) Visual() visualizations.Visual {
+	return e.visual
+}
+
+//Type returns the type string for the extreme value type of insight
+func (e *Extreme) Type() string {
+	return EXTREME
+}
+
+//Relevant returns whether the insight is relevant or not for the given dataset.
+func (e *Extreme) Relevant() bool {
+	return e.relevant
+}
+
+//Extremes returns the extreme values found
+func (e *Extreme) Extremes() []ExtremeValue {
+	return e.extremes
+}
+
+//FSFA does the fast statistical feasibilty analysis over the dataset
+//with the given metrics whether the extreme values can be found.
+//It requires a float metric with either a time metric or a string category
+//metric.
+func (e *Extreme) FSFA() {
+	if len(e.ms) != 2 || e.ms[0].DataType != Float ||
+		(!isTime(e.ms[1]) && e.ms[1].DataType != String) {
+		e.relevant = false
+		return
+	}
+	e.relevant = e.dt.Length > 0
+}
+
+//Generate generates the extreme value insight for the datatset associated
+//with it for the provided metrics.
+//This method can only be run after running the FSFA.
+//Else the insight won't be generated
+func (e *Extreme) Generate() {
+	//Checking whether the existing relevance of the insight
+	if !e.relevant {
+		return
+	}
+	if isTime(e.ms[1]) {
+		e.latest()
+		return
+	}
+	e.categories()
+}
+
+//latest finds whether the latest value of the metric recorded over time is
+//beyond its previous values
+func (e *Extreme) latest() {
+	/*
+		We will order the metric in time.
+		Then we count the previous records the latest value is beyond
+		without a break.
+		If it is beyond all the records or atleast minExtremeLookback records,
+		it is reported.
+	*/
+	ax, ok := newTimeAxis(e.dt, e.ms[1])
+	if !ok {
+		e.relevant = false
+		return
+	}
+	y, labels := ax.series(e.dt, e.ms[0])
+	if len(y) < minExtremeRecords {
+		e.relevant = false
+		return
+	}
+	last := len(y) - 1
+	e.extremes = []ExtremeValue{}
+	for _, kind := range []string{ExtremeHigh, ExtremeLow} {
+		k := 0
+		for i := last - 1; i >= 0; i-- {
+			if (kind == ExtremeHigh && y[i] >= y[last]) ||
+				(kind == ExtremeLow && y[i] <= y[last]) {
+				break
+			}
+			k++
+		}
+		if k == last || k >= minExtremeLookback {
+			e.extremes = append(e.extremes, ExtremeValue{kind, labels[last],
+				y[last], k, k == last})
+		}
+	}
+	if len(e.extremes) == 0 {
+		e.relevant = false
+		return
+	}
+	e.relevant = true
+	e.visual = e.line(y, labels)
+}
+
+//line returns the line chart of the metric with the latest value annotated
+func (e *Extreme) line(y []float64, labels []interface{}) visualizations.LineChart {
+	m, tm := e.ms[0], e.ms[1]
+	name := displayName(m)
+	x := e.extremes[0]
+	desc := name + " of " + formatFloat(x.Value) + " at " + axisLabel(x.At) +
+		" is the " + e.describe(x)
+	visual := visualizations.LineChart{
+		T: name + " is at its " + e.describe(x),
+		D: desc,
+		M: []visualizations.Metric{
+			{Name: tm.Name, DisplayName: tm.DisplayName, DataType: tm.DataType,
+				Dimension: 0},
+			{Name: m.Name, DisplayName: m.DisplayName, DataType: Float,
+				Dimension: 1},
+		},
+		A: []visualizations.Annotation{
+			{Label: e.describe(x), X: x.At, Value: x.Value},
+		},
+	}
+	data := make([]map[string]interface{}, len(y))
+	for i, v := range y {
+		data[i] = map[string]interface{}{tm.Name: labels[i], m.Name: v}
+	}
+	visual.Dt = data
+	return visual
+}
+
+//categories finds the categories whose sum of the metric is extreme compared
+//to the other categories
+func (e *Extreme) categories() {
+	/*
+		We will sum the metric for each category.
+		Then for the highest and the lowest categories, we find how many
+		standard deviations they are away from the rest of the categories.
+		If it is atleast extremeZ, the category is reported.
+	*/
+	m := e.ms[0]
+	cats, ok := keys(e.dt, e.ms[1])
+	if !ok || m.Index >= len(e.dt.DataF) {
+		e.relevant = false
+		return
+	}
+	sums := map[string]float64{}
+	for i, v := range e.dt.DataF[m.Index] {
+		if math.IsNaN(v) || len(cats[i]) == 0 {
+			continue
+		}
+		sums[cats[i]] += v
+	}
+	if len(sums) < minExtremeCategories {
+		e.relevant = false
+		return
+	}
+	order := make([]string, 0, len(sums))
+	for c := range sums {
+		order = append(order, c)
+	}
+	sort.Slice(order, func(i, j int) bool {
+		if sums[order[i]] == sums[order[j]] {
+			return order[i] < order[j]
+		}
+		return sums[order[i]] > sums[order[j]]
+	})
+
+	//finding the extreme categories
+	e.extremes = []ExtremeValue{}
+	for _, kind := range []string{ExtremeHigh, ExtremeLow} {
+		c, rest := order[0], order[1:]
+		if kind == ExtremeLow {
+			c, rest = order[len(order)-1], order[:len(order)-1]
+		}
+		others := make([]float64, len(rest))
+		for i, r := range rest {
+			others[i] = sums[r]
+		}
+		mean, sd := stat.MeanStdDev(others, nil)
+		diff := sums[c] - mean
+		if kind == ExtremeLow {
+			diff = -diff
+		}
+		if diff <= 0 || (sd > 0 && diff/sd < extremeZ) ||
+			(sd == 0 && diff < extremeRelative*math.Abs(mean)) {
+			continue
+		}
+		e.extremes = append(e.extremes, ExtremeValue{kind, c, sums[c],
+			len(rest), false})
+	}
+	if len(e.extremes) == 0 {
+		e.relevant = false
+		return
+	}
+	e.relevant = true
+	e.visual = e.bar(order, sums)
+}
+
+//bar returns the bar chart of the categories with the extreme ones annotated
+func (e *Extreme) bar(order []string, sums map[string]float64) visualizations.BarChart {
+	m, cm := e.ms[0], e.ms[1]
+	name, cname := displayName(m), displayName(cm)
+	desc := ""
+	extreme := map[string]bool{}
+	annotations := []visualizations.Annotation{}
+	for _, x := range e.extremes {
+		c := x.At.(string)
+		extreme[c] = true
+		if len(desc) > 0 {
+			desc += ". "
+		}
+		desc += c + " has the " + e.describe(x) + " " + name + " of " +
+			formatFloat(x.Value)
+		annotations = append(annotations, visualizations.Annotation{
+			Label: e.describe(x), X: c, Value: x.Value})
+	}
+	visual := visualizations.BarChart{
+		T: name + " by " + cname,
+		D: desc,
+		M: []visualizations.Metric{
+			{Name: cm.Name, DisplayName: cm.DisplayName, DataType: String,
+				Dimension: 0},
+			{Name: m.Name, DisplayName: m.DisplayName, DataType: Float,
+				Dimension: 1},
+		},
+		A: annotations,
+	}
+	data := []map[string]interface{}{}
+	for i, c := range order {
+		if i >= maxExtremeBars && !extreme[c] {
+			continue
+		}
+		data = append(data, map[string]interface{}{cm.Name: c, m.Name: sums[c]})
+	}
+	visual.Dt = data
+	return visual
+}
+
+//Propose suggests the possible insights from the domain knowledge.
+//Float metrics of datasets having a time metric are proposed for finding
+//the record highs and lows. Float metrics are also proposed with each string
+//metric for finding the extreme categories. Metrics having ids like the user
+//or the transaction aren't used as categories.
+func (e *Extreme) Propose(d Dataset) []ProposedInsight {
+	result := []ProposedInsight{}
+	tm, hasTime := timeMetric(d)
+	ms := sortedMetrics(d)
+	for _, m := range ms {
+		if m.DataType != Float || (hasTime && m.Name == tm.Name) {
+			continue
+		}
+		if hasTime {
+			metrics := []Metric{m, tm}
+			result = append(result, ProposedInsight{e.New(d, metrics), metrics})
+		}
+		for _, c := range ms {
+			if c.DataType != String || (hasTime && c.Name == tm.Name) ||
+				(c.Semantic != "" && c.Semantic != SemanticSegment &&
+					c.Semantic != SemanticItem) {
+				continue
+			}
+			metrics := []Metric{m, c}
+			result = append(result, ProposedInsight{e.New(d, metrics), metrics})
+		}
+	}
+	return result
+}
+
+//describe returns the description of the extreme value like all time high,
+//highest in 12 records or highest for the categories
+func (e *Extreme) describe(x ExtremeValue) string {
+	most := map[string]string{ExtremeHigh: "highest", ExtremeLow: "lowest"}[x.Kind]
+	switch {
+	case !isTime(e.ms[1]):
+		return most
+	case x.AllTime:
+		return "all time " + x.Kind
+	default:
+		return most + " in " + strconv.Itoa(x.Lookback+1) + " records"
+	}
+}
@@ -0,0 +1,169 @@
+package insights
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cuttle-ai/brain/visualizations"
+)
+
+/*
+	This file contains the tests for the extreme value insight
+*/
+
+//weeklySignups returns a dataset with the signups of the weeks
+func weeklySignups(signups []float64) Dataset {
+	weeks := make([]float64, len(signups))
+	for i := range weeks {
+		weeks[i] = float64(i + 1)
+	}
+	d := NewDataset()
+	d.AddMetric(Metric{Name: "week", DataType: Float, Semantic: SemanticTime},
+		weeks)
+	d.AddMetric(Metric{Name: "signups", DisplayName: "Signups",
+		DataType: Float}, signups)
+	return d
+}
+
+//regionalRevenue returns a dataset with the revenue of the regions
+func regionalRevenue(revenue []float64) Dataset {
+	regions := []string{"north", "south", "east", "west", "central", "coast"}
+	d := NewDataset()
+	d.AddMetric(Metric{Name: "region", DataType: String},
+		regions[:len(revenue)])
+	d.AddMetric(Metric{Name: "revenue", DataType: Float}, revenue)
+	return d
+}
+
+func TestExtreme_New(t *testing.T) {
+	d := weeklySignups([]float64{1, 2})
+	ei := (&Extreme{}).New(d, []Metric{d.Metrics["signups"], d.Metrics["week"]})
+	e, ok := ei.(*Extreme)
+	if !ok {
+		t.Fatal("Expected an extreme. Got", reflect.TypeOf(ei))
+	}
+	if len(e.ms) != 2 {
+		t.Fatal("Expected 2 metrics. Got", len(e.ms))
+	}
+}
+
+func TestExtreme_Type(t *testing.T) {
+	e := &Extreme{}
+	if e.Type() != EXTREME {
+		t.Fatal("Expected insight type is", EXTREME, "Got", e.Type())
+	}
+}
+
+func TestExtreme_FSFA(t *testing.T) {
+	d := weeklySignups([]float64{1, 2})
+	e := &Extreme{dt: d, ms: []Metric{d.Metrics["signups"],
+		d.Metrics["signups"]}}
+	e.FSFA()
+	if e.Relevant() {
+		t.Fatal("Expected extreme to be irrelevant without time or category.",
+			"Got it as relevant")
+	}
+	e = &Extreme{dt: d, ms: []Metric{d.Metrics["signups"], d.Metrics["week"]}}
+	e.FSFA()
+	if !e.Relevant() {
+		t.Fatal("Expected extreme to be relevant with normal conditions.",
+			"Got it as irrelevant")
+	}
+}
+
+func TestExtreme_Generate(t *testing.T) {
+	t.Run("Testing generate with all time high", func(t *testing.T) {
+		d := weeklySignups([]float64{10, 12, 11, 13, 12, 14, 20})
+		e := (&Extreme{}).Propose(d)[0].I.(*Extreme)
+		e.FSFA()
+		e.Generate()
+		if !e.Relevant() {
+			t.Fatal("Expected extreme to be relevant. Got irrelevant")
+		}
+		expected := []ExtremeValue{{ExtremeHigh, float64(7), 20, 6, true}}
+		if !reflect.DeepEqual(e.Extremes(), expected) {
+			t.Fatal("Expected", expected, "Got", e.Extremes())
+		}
+		l, ok := e.Visual().(visualizations.LineChart)
+		if !ok {
+			t.Fatal("Expected a line chart. Got", reflect.TypeOf(e.Visual()))
+		}
+		if len(l.Annotations()) != 1 || l.Annotations()[0].Label != "all time high" {
+			t.Fatal("Expected the all time high annotated. Got", l.Annotations())
+		}
+	})
+
+	t.Run("Testing generate with n period low", func(t *testing.T) {
+		d := weeklySignups([]float64{1, 10, 12, 11, 13, 12, 14, 9, 8, 2})
+		e := (&Extreme{}).Propose(d)[0].I.(*Extreme)
+		e.FSFA()
+		e.Generate()
+		x := e.Extremes()
+		if !e.Relevant() || len(x) != 1 || x[0].Kind != ExtremeLow ||
+			x[0].Lookback != 8 || x[0].AllTime {
+			t.Fatal("Expected the lowest in 9 records. Got", x)
+		}
+	})
+
+	t.Run("Testing generate with extreme category", func(t *testing.T) {
+		d := regionalRevenue([]float64{100, 110, 95, 105, 500, 100})
+		e := (&Extreme{}).Propose(d)[0].I.(*Extreme)
+		e.FSFA()
+		e.Generate()
+		if !e.Relevant() {
+			t.Fatal("Expected extreme to be relevant. Got irrelevant")
+		}
+		expected := []ExtremeValue{{ExtremeHigh, "central", 500, 5, false}}
+		if !reflect.DeepEqual(e.Extremes(), expected) {
+			t.Fatal("Expected", expected, "Got", e.Extremes())
+		}
+		b, ok := e.Visual().(visualizations.BarChart)
+		if !ok {
+			t.Fatal("Expected a bar chart. Got", reflect.TypeOf(e.Visual()))
+		}
+		if len(b.Data()) != 6 || len(b.Annotations()) != 1 {
+			t.Fatal("Expected 6 bars with an annotation. Got", len(b.Data()),
+				b.Annotations())
+		}
+	})
+
+	t.Run("Testing generate without extremes", func(t *testing.T) {
+		d := regionalRevenue([]float64{100, 110, 95, 105})
+		e := (&Extreme{}).Propose(d)[0].I.(*Extreme)
+		e.FSFA()
+		e.Generate()
+		if e.Relevant() {
+			t.Fatal("Expected extreme to be irrelevant. Got", e.Extremes())
+		}
+	})
+
+	t.Run("Testing generate with peers of the same value", func(t *testing.T) {
+		//slightly higher than the peers is not extreme
+		d := regionalRevenue([]float64{100, 100, 101, 100, 100})
+		e := (&Extreme{}).Propose(d)[0].I.(*Extreme)
+		e.FSFA()
+		e.Generate()
+		if e.Relevant() {
+			t.Fatal("Expected extreme to be irrelevant. Got", e.Extremes())
+		}
+		d = regionalRevenue([]float64{100, 100, 400, 100, 100})
+		e = (&Extreme{}).Propose(d)[0].I.(*Extreme)
+		e.FSFA()
+		e.Generate()
+		expected := []ExtremeValue{{ExtremeHigh, "east", 400, 4, false}}
+		if !reflect.DeepEqual(e.Extremes(), expected) {
+			t.Fatal("Expected", expected, "Got", e.Extremes())
+		}
+	})
+}
+
+func TestExtreme_Propose(t *testing.T) {
+	d := weeklySignups([]float64{1, 2})
+	if pro := (&Extreme{}).Propose(d); len(pro) != 1 {
+		t.Fatal("Expected 1 proposal. Got", len(pro))
+	}
+	d = regionalRevenue([]float64{1, 2})
+	if pro := (&Extreme{}).Propose(d); len(pro) != 1 || pro[0].M[1].Name != "region" {
+		t.Fatal("Expected 1 proposal with region. Got", pro)
+	}
+}
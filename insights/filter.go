@@ -0,0 +1,137 @@
+package insights
+
+/*
+	This file contains the utilities for taking the subsets of the datasets
+	like the records of a segment or a few of the metrics
+*/
+
+//Predicate tells whether the ith record of the dataset is to be kept
+type Predicate func(d Dataset, i int) bool
+
+//Between returns the predicate keeping the records whose value of the float
+//or int metric is within low and high, both inclusive. Records with missing
+//values are not kept. If the metric doesn't exist or is not numeric, none
+//of the records are kept.
+func Between(metric string, low, high float64) Predicate {
+	return func(d Dataset, i int) bool {
+		m, ok := d.Metrics[metric]
+		if !ok || !d.Valid(metric, i) {
+			return false
+		}
+		var v float64
+		switch m.DataType {
+		case Float:
+			v = d.DataF[m.Index][i]
+		case Int:
+			v = float64(d.DataI[m.Index][i])
+		default:
+			return false
+		}
+		return v >= low && v <= high
+	}
+}
+
+//In returns the predicate keeping the records whose value of the string
+//metric is one of the given values. Records with missing values are not
+//kept. If the metric doesn't exist or is not a string metric, none of the
+//records are kept.
+func In(metric string, values ...string) Predicate {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return func(d Dataset, i int) bool {
+		m, ok := d.Metrics[metric]
+		if !ok || m.DataType != String || !d.Valid(metric, i) {
+			return false
+		}
+		return set[d.DataS[m.Index][i]]
+	}
+}
+
+//Not returns the predicate keeping the records not kept by the given one
+func Not(p Predicate) Predicate {
+	return func(d Dataset, i int) bool {
+		return !p(d, i)
+	}
+}
+
+//Filter returns a new dataset having the records satisfying all the
+//predicates. Records are in the same order as in the dataset. The metrics
+//are same as that of the dataset with their indices and the length updated
+//for the new dataset. It returns an error if the data of a metric couldn't
+//be added to the new dataset.
+func (d Dataset) Filter(preds ...Predicate) (Dataset, error) {
+	rows := []int{}
+	for i := 0; i < int(d.Length); i++ {
+		keep := true
+		for _, p := range preds {
+			if !p(d, i) {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			rows = append(rows, i)
+		}
+	}
+	return subset(d, sortedMetrics(d), rows)
+}
+
+//Select returns a new dataset having only the given metrics of the dataset.
+//It returns an error if a metric doesn't exist in the dataset, is given more
+//than once or its data couldn't be added to the new dataset.
+func (d Dataset) Select(names ...string) (Dataset, error) {
+	ms := make([]Metric, len(names))
+	seen := map[string]bool{}
+	for i, name := range names {
+		m, ok := d.Metrics[name]
+		if !ok {
+			return NewDataset(), &Error{ErrMMetricNotFound + name, ErrCGeneric}
+		}
+		if seen[name] {
+			return NewDataset(), &Error{ErrMDuplicateMetric + name, ErrCGeneric}
+		}
+		seen[name] = true
+		ms[i] = m
+	}
+	rows := make([]int, d.Length)
+	for i := range rows {
+		rows[i] = i
+	}
+	return subset(d, ms, rows)
+}
+
+//Slice returns a new dataset having the records from the index from up to
+//the index to, excluding the latter. Indices beyond the records of the
+//dataset are limited to them. It returns an error if the data of a metric
+//couldn't be added to the new dataset.
+func (d Dataset) Slice(from, to int) (Dataset, error) {
+	if from < 0 {
+		from = 0
+	}
+	if to > int(d.Length) {
+		to = int(d.Length)
+	}
+	rows := []int{}
+	for i := from; i < to; i++ {
+		rows = append(rows, i)
+	}
+	return subset(d, sortedMetrics(d), rows)
+}
+
+//subset returns a new dataset having the given metrics of the dataset in
+//the given records. The data and the validities are copied so that the new
+//dataset doesn't share them with the dataset. It returns an error if a
+//metric couldn't be added to the new dataset like the metrics of
+//unsupported data types.
+func subset(d Dataset, ms []Metric, rows []int) (Dataset, error) {
+	res := NewDataset()
+	for _, m := range ms {
+		data := take(d, m, rows)
+		if err := res.AddNullableMetric(m, data, takeValid(d, m.Name, rows)); err != nil {
+			return NewDataset(), err
+		}
+	}
+	return res, nil
+}
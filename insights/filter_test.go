@@ -0,0 +1,119 @@
+package insights
+
+import (
+	"reflect"
+	"testing"
+)
+
+/*
+	This file contains the tests for the subsets of the datasets
+*/
+
+type filterTC struct {
+	ID          string
+	Description string
+	Predicates  []Predicate
+	Expected    []string
+}
+
+var filterTCs = []filterTC{
+	{"1", "No predicates", nil, []string{"a", "b", "a", "c", "b", "d"}},
+	{"2", "Float range", []Predicate{Between("revenue", 20, 50)},
+		[]string{"b", "a", "b"}},
+	{"3", "Int range", []Predicate{Between("units", 5, 10)},
+		[]string{"b", "d"}},
+	{"4", "String membership", []Predicate{In("region", "south")},
+		[]string{"b", "b"}},
+	{"5", "Range and membership", []Predicate{In("region", "north"),
+		Between("revenue", 0, 100)}, []string{"a", "a"}},
+	{"6", "Negated membership", []Predicate{Not(In("region", "north"))},
+		[]string{"b", "b", "d"}},
+	{"7", "Unknown metric", []Predicate{Between("profit", 0, 100)},
+		[]string{}},
+	{"8", "Range of a string metric", []Predicate{Between("region", 0, 100)},
+		[]string{}},
+}
+
+func TestDataset_Filter(t *testing.T) {
+	d := salesDataset()
+	for _, v := range filterTCs {
+		t.Run(v.ID, func(t *testing.T) {
+			f, err := d.Filter(v.Predicates...)
+			if err != nil {
+				t.Fatal("Error while filtering the records", err)
+			}
+			if f.Length != int64(len(v.Expected)) || len(f.Metrics) != len(d.Metrics) {
+				t.Fatal("Expected", len(v.Expected), "records with all the metrics.",
+					"Got", f.Length, len(f.Metrics))
+			}
+			if c := f.DataS[f.Metrics["customer"].Index]; !reflect.DeepEqual(c, v.Expected) {
+				t.Fatal("Expected the customers", v.Expected, "Got", c)
+			}
+		})
+	}
+
+	//missing values should stay missing in the filtered dataset
+	f, _ := d.Filter(In("customer", "c", "d"))
+	if f.Valid("revenue", 0) || f.Valid("region", 1) || !f.Valid("region", 0) {
+		t.Fatal("Expected the missing revenue and region to be kept")
+	}
+	if f.Metrics["revenue"].Semantic != SemanticMonetary {
+		t.Fatal("Expected the metrics to be kept as such. Got", f.Metrics["revenue"])
+	}
+}
+
+func TestDataset_Select(t *testing.T) {
+	d := salesDataset()
+	s, err := d.Select("units", "revenue")
+	if err != nil {
+		t.Fatal("Error while selecting the metrics", err)
+	}
+	if s.Length != d.Length || len(s.Metrics) != 2 || len(s.DataF) != 1 ||
+		len(s.DataI) != 1 || len(s.DataS) != 0 {
+		t.Fatal("Expected only the units and revenue. Got", s.Metrics)
+	}
+	if s.Metrics["revenue"].Index != 0 || s.NullCount("revenue") != 1 {
+		t.Fatal("Expected the revenue at 0 with 1 missing value. Got",
+			s.Metrics["revenue"], s.NullCount("revenue"))
+	}
+	//the new dataset shouldn't share the data
+	s.DataI[0][0] = 100
+	if d.DataI[d.Metrics["units"].Index][0] != 1 {
+		t.Fatal("Expected the dataset to be unchanged")
+	}
+	if _, err := d.Select("profit"); err == nil {
+		t.Fatal("Expected an error for an unknown metric. Got nil")
+	}
+	if _, err := d.Select("units", "units"); err == nil {
+		t.Fatal("Expected an error for a metric selected twice. Got nil")
+	}
+	//metrics of unsupported data types can't be selected
+	d.Metrics["units"] = Metric{Name: "units", DataType: "decimal"}
+	if _, err := d.Select("units"); err == nil {
+		t.Fatal("Expected an error for the unsupported metric. Got nil")
+	}
+}
+
+func TestDataset_Slice(t *testing.T) {
+	d := salesDataset()
+	tcs := []struct {
+		From, To int
+		Expected []int64
+	}{
+		{1, 3, []int64{2, 3}},
+		{-2, 2, []int64{1, 2}},
+		{4, 10, []int64{5, 6}},
+		{3, 3, []int64{}},
+		{5, 2, []int64{}},
+	}
+	for _, v := range tcs {
+		s, err := d.Slice(v.From, v.To)
+		if err != nil {
+			t.Fatal("Error while slicing the records", err)
+		}
+		if u := s.DataI[s.Metrics["units"].Index]; s.Length != int64(len(v.Expected)) ||
+			!reflect.DeepEqual(u, v.Expected) {
+			t.Fatal("Expected the units", v.Expected, "for", v.From, v.To, "Got", u)
+		}
+	}
+}
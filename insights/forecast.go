@@ -0,0 +1,381 @@
+package insights
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/cuttle-ai/brain/visualizations"
+)
+
+/*
+	This file contains the utilities and structs required for forecast
+	insights
+*/
+
+const (
+	//minForecastRecords is the minimum no. of records in the history of a
+	//metric required for forecasting it
+	minForecastRecords = 8
+	//forecastHorizon is the default no. of periods the metrics are projected
+	//ahead
+	forecastHorizon = 6
+	//predictionZ is the z score used for the 95% prediction intervals
+	predictionZ = 1.959963984540054
+)
+
+//ForecastPoint is the projection of a metric for a period
+type ForecastPoint struct {
+	//Period is the no. of periods ahead of the last record. It starts from 1.
+	Period int
+	//Time is the time of the period. It has the same data type as that of
+	//time metric of the dataset.
+	Time  interface{}
+	Value float64 //Value is the projected value
+	//Lower and Upper are the bounds of the 95% prediction interval
+	Lower float64
+	Upper float64
+}
+
+//Forecast is the forecast insight.
+//It projects a metric recorded over time ahead with prediction intervals.
+//Metrics are projected by the Holt's linear trend model. Seasonality is not
+//modelled, so the projections of the seasonal metrics follow only their
+//level and trend.
+type Forecast struct {
+	//Horizon is the no. of periods the metrics are projected ahead. It is
+	//forecastHorizon if not set. It is limited to half of the no. of records
+	//in the history of the metric. Insights returned by New and Propose have
+	//the horizon of the instance.
+	Horizon int
+	//visual has the visualization to be used for showing the forecast.
+	//Line chart with the history, projection and the prediction intervals
+	//is used.
+	visual visualizations.Visual
+	//relevant stores the information whether the insight is relevant or not.
+	//This property is updated after running methods like FSFA and Generate
+	relevant bool
+	dt       Dataset //dt is the dataset to be used for the forecast
+	//ms is the list of metrics. First one is the metric to be forecasted and
+	//the second one has the time of the records.
+	ms     []Metric
+	points []ForecastPoint //points has the projections
+	//crossed is the threshold the metric is projected to cross first
+	crossed Limit
+	//crossing is the period at which the metric is projected to cross the
+	//threshold. It is 0 if it isn't projected to cross any threshold.
+	crossing int
+}
+
+//New returns a new instance of the Forecast with
+//initializations done for the given dataset
+func (f *Forecast) New(d Dataset, ms []Metric) Insight {
+	return &Forecast{dt: d, ms: ms, Horizon: f.Horizon}
+}
+
+//Visual returns the visualization to be used for visualizing the forecast
+func (f *Forecast) Visual() visualizations.Visual {
+	return f.visual
+}
+
+//Type returns the type string for the forecast type of insight
+func (f *Forecast) Type() string {
+	return FORECAST
+}
+
+//Relevant returns whether the insight is relevant or not for the given dataset.
+func (f *Forecast) Relevant() bool {
+	return f.relevant
+}
+
+//Points returns the projections of the metric
+func (f *Forecast) Points() []ForecastPoint {
+	return f.points
+}
+
+//Crossing returns the threshold of the metric it is projected to cross
+//first and the period at which it is crossed. A threshold is crossed when
+//the projection stops or starts satisfying it like a target being reached
+//or a limit being breached. The period is 0 if the metric doesn't have
+//thresholds or it is not projected to cross any of them within the forecast
+//horizon.
+func (f *Forecast) Crossing() (Limit, int) {
+	return f.crossed, f.crossing
+}
+
+//FSFA does the fast statistical feasibilty analysis over the dataset
+//with the given metrics whether the metric can be forecasted.
+//Forecast requires a float metric and a time metric with atleast
+//minForecastRecords no. of records.
+func (f *Forecast) FSFA() {
+	/*
+		Will check whether the length of the metrics array is 2.
+		Then it will check whether the first metric is float and the second
+		one is the time metric.
+		Then we check whether there are sufficient records
+	*/
+	//Checking the length of the metrics
+	if len(f.ms) != 2 {
+		f.relevant = false
+		return
+	}
+
+	//checking the metrics
+	if f.ms[0].DataType != Float || !isTime(f.ms[1]) {
+		f.relevant = false
+		return
+	}
+
+	//checking the no. of records
+	if f.dt.Length < minForecastRecords {
+		f.relevant = false
+		return
+	}
+
+	//Everything is fine
+	f.relevant = true
+}
+
+//Generate generates the forecast insight for the datatset associated with
+//it for the provided variables.
+//This method can only be run after running the FSFA.
+//Else the insight won't be generated
+func (f *Forecast) Generate() {
+	/*
+		If the insight is not relevant we won't event bother
+		to go forward.
+		We will order the metric in time and fit the Holt's linear trend model.
+		Then we project the metric the horizon no. of periods ahead.
+		If the metric has thresholds, we will find the threshold the
+		projection crosses first.
+		Then we create the visual.
+	*/
+	//Checking whether the existing relevance of the insight
+	if !f.relevant {
+		return
+	}
+
+	//ordering the metric in time
+	ax, ok := newTimeAxis(f.dt, f.ms[1])
+	if !ok {
+		f.relevant = false
+		return
+	}
+	y, labels := ax.series(f.dt, f.ms[0])
+	if len(y) < minForecastRecords {
+		f.relevant = false
+		return
+	}
+
+	//fitting the model and projecting the metric
+	model, ok := fitHolt(y)
+	if !ok {
+		f.relevant = false
+		return
+	}
+	steps := f.Horizon
+	if steps <= 0 {
+		steps = forecastHorizon
+	}
+	if steps > len(y)/2 {
+		steps = len(y) / 2
+	}
+	point, lower, upper := model.forecast(steps)
+	times := ax.future(steps)
+	f.points = make([]ForecastPoint, steps)
+	for h := range f.points {
+		f.points[h] = ForecastPoint{h + 1, times[h], point[h], lower[h], upper[h]}
+	}
+
+	//finding the threshold crossed first
+	f.crossed, f.crossing = Limit{}, 0
+	for _, t := range f.ms[0].Thresholds {
+		c := crossing(y[len(y)-1], point, t)
+		if c > 0 && (f.crossing == 0 || c < f.crossing) {
+			f.crossed, f.crossing = t, c
+		}
+	}
+
+	f.relevant = true
+	f.visual = f.line(y, labels)
+}
+
+//line returns the line chart having the history and the projection of the
+//metric
+func (f *Forecast) line(y []float64, labels []interface{}) visualizations.LineChart {
+	/*
+		We will create the line chart with the time on the x axis.
+		History and the projection are plotted as lines and the prediction
+		intervals as a band.
+		Last record of the history is repeated in the projection so that the
+		lines are connected.
+	*/
+	m, tm := f.ms[0], f.ms[1]
+	name := displayName(m)
+	last := f.points[len(f.points)-1]
+	desc := "is projected to be " + formatFloat(last.Value) + " in " +
+		strconv.Itoa(last.Period) + " periods"
+	if f.crossing != 0 {
+		desc += ". It is projected to cross the threshold " +
+			f.crossed.String() + " in " + strconv.Itoa(f.crossing) + " periods"
+	}
+	fname, lname, uname := m.Name+"_forecast", m.Name+"_lower", m.Name+"_upper"
+	visual := visualizations.LineChart{
+		T: "Forecast of " + name,
+		D: name + " " + desc,
+		M: []visualizations.Metric{
+			{Name: tm.Name, DisplayName: tm.DisplayName, DataType: tm.DataType,
+				Dimension: 0},
+			{Name: m.Name, DisplayName: m.DisplayName, DataType: Float,
+				Dimension: 1},
+			{Name: fname, DisplayName: "Forecast of " + name, DataType: Float,
+				Dimension: 1},
+			{Name: lname, DisplayName: "Lower bound", DataType: Float,
+				Dimension: 2},
+			{Name: uname, DisplayName: "Upper bound", DataType: Float,
+				Dimension: 3},
+		},
+	}
+	for _, t := range m.Thresholds {
+		visual.R = append(visual.R, visualizations.ReferenceLine{
+			Label: "Threshold " + t.String(), Value: t.Value})
+	}
+
+	//adding the history and the projection
+	data := make([]map[string]interface{}, 0, len(y)+len(f.points))
+	for i, v := range y {
+		data = append(data, map[string]interface{}{tm.Name: labels[i], m.Name: v})
+	}
+	data[len(data)-1][fname] = y[len(y)-1]
+	data[len(data)-1][lname] = y[len(y)-1]
+	data[len(data)-1][uname] = y[len(y)-1]
+	for _, p := range f.points {
+		data = append(data, map[string]interface{}{
+			tm.Name: p.Time,
+			fname:   p.Value,
+			lname:   p.Lower,
+			uname:   p.Upper,
+		})
+	}
+	visual.Dt = data
+	return visual
+}
+
+//Propose suggests the possible insights from the domain knowledge.
+//Float metrics of datasets having a time metric are proposed for forecast.
+func (f *Forecast) Propose(d Dataset) []ProposedInsight {
+	/*
+		We will check whether the dataset has a time metric.
+		Then we iterate through the metrics in the data set and select the
+		variables that have float data type.
+		Each one of them is proposed with the time metric.
+	*/
+	//variable for storing the result
+	result := []ProposedInsight{}
+
+	//checking for the time metric
+	tm, ok := timeMetric(d)
+	if !ok {
+		return result
+	}
+
+	//selecting the float metrics
+	for _, m := range sortedMetrics(d) {
+		if m.DataType != Float || m.Name == tm.Name {
+			continue
+		}
+		metrics := []Metric{m, tm}
+		result = append(result, ProposedInsight{f.New(d, metrics), metrics})
+	}
+	return result
+}
+
+//holt is the Holt's linear trend model. It is the exponential smoothing
+//of the level and the trend of a series.
+type holt struct {
+	alpha float64 //alpha is the smoothing parameter of the level
+	beta  float64 //beta is the smoothing parameter of the trend
+	level float64 //level is the level at the end of the series
+	trend float64 //trend is the trend at the end of the series
+	//sigma is the standard deviation of the one step ahead errors
+	sigma float64
+}
+
+//fitHolt fits the Holt's linear trend model to the series. The smoothing
+//parameters are chosen from a grid minimizing the sum of squared one step
+//ahead errors. The series should have atleast 3 values else false is returned.
+func fitHolt(y []float64) (holt, bool) {
+	if len(y) < 3 {
+		return holt{}, false
+	}
+	best := holt{}
+	bestSSE := math.Inf(1)
+	for a := 1; a < 10; a++ {
+		for b := 1; b < 10; b++ {
+			h := holt{alpha: float64(a) / 10, beta: float64(b) / 10}
+			sse := h.smooth(y)
+			if sse < bestSSE {
+				best, bestSSE = h, sse
+			}
+		}
+	}
+	best.smooth(y)
+	best.sigma = math.Sqrt(bestSSE / float64(len(y)-2))
+	if math.IsNaN(best.sigma) || math.IsInf(best.sigma, 0) {
+		return holt{}, false
+	}
+	return best, true
+}
+
+//smooth runs the smoothing over the series and updates the level and trend
+//of the model. It returns the sum of squared one step ahead errors.
+func (h *holt) smooth(y []float64) float64 {
+	h.level, h.trend = y[0], y[1]-y[0]
+	sse := 0.0
+	for t := 1; t < len(y); t++ {
+		pred := h.level + h.trend
+		if t > 1 {
+			sse += (y[t] - pred) * (y[t] - pred)
+		}
+		prev := h.level
+		h.level = h.alpha*y[t] + (1-h.alpha)*pred
+		h.trend = h.beta*(h.level-prev) + (1-h.beta)*h.trend
+	}
+	return sse
+}
+
+//forecast projects the series the given no. of steps ahead. It returns the
+//point forecasts with the lower and upper bounds of 95% prediction intervals.
+func (h holt) forecast(steps int) ([]float64, []float64, []float64) {
+	/*
+		Point forecast at step k is level + k * trend.
+		The variance of the forecast error at step k is
+		sigma^2 * (1 + (k-1) * (alpha^2 + alpha*b*k + b^2*k*(2k-1)/6))
+		where b = alpha * beta.
+	*/
+	point := make([]float64, steps)
+	lower := make([]float64, steps)
+	upper := make([]float64, steps)
+	b := h.alpha * h.beta
+	for i := range point {
+		k := float64(i + 1)
+		point[i] = h.level + k*h.trend
+		v := 1 + (k-1)*(h.alpha*h.alpha+h.alpha*b*k+b*b*k*(2*k-1)/6)
+		w := predictionZ * h.sigma * math.Sqrt(v)
+		lower[i] = point[i] - w
+		upper[i] = point[i] + w
+	}
+	return point, lower, upper
+}
+
+//crossing returns the period at which the projection crosses the threshold
+//starting from the last value, i.e. the first projected value not
+//satisfying the threshold the same way as the last value. It returns 0 if
+//there is no crossing.
+func crossing(last float64, projection []float64, t Limit) int {
+	for i, v := range projection {
+		if t.Met(v) != t.Met(last) {
+			return i + 1
+		}
+	}
+	return 0
+}
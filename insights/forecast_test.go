@@ -0,0 +1,211 @@
+package insights
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/cuttle-ai/brain/visualizations"
+)
+
+/*
+	This file contains the tests for the forecast insight
+*/
+
+//monthlySales returns a dataset with the sales growing linearly over
+//12 months. The records are not in the order of time.
+func monthlySales(ts ...Limit) Dataset {
+	months := []string{}
+	sales := []float64{}
+	for i := 11; i >= 0; i-- {
+		months = append(months, "2019-"+[]string{"01", "02", "03", "04", "05",
+			"06", "07", "08", "09", "10", "11", "12"}[i])
+		sales = append(sales, 10+2*float64(i))
+	}
+	d := NewDataset()
+	d.AddMetric(Metric{Name: "month", DataType: String, Semantic: SemanticTime},
+		months)
+	d.AddMetric(Metric{Name: "sales", DisplayName: "Sales", DataType: Float,
+		Thresholds: ts}, sales)
+	return d
+}
+
+func TestForecast_New(t *testing.T) {
+	d := monthlySales()
+	fi := (&Forecast{}).New(d, []Metric{d.Metrics["sales"]})
+	f, ok := fi.(*Forecast)
+	if !ok {
+		t.Fatal("Expected a forecast. Got", reflect.TypeOf(fi))
+	}
+	if f.dt.Length != 12 || len(f.ms) != 1 {
+		t.Fatal("Expected dataset of length 12 with 1 metric. Got", f.dt.Length,
+			len(f.ms))
+	}
+	if f = (&Forecast{Horizon: 3}).New(d, f.ms).(*Forecast); f.Horizon != 3 {
+		t.Fatal("Expected the horizon 3 to be kept. Got", f.Horizon)
+	}
+}
+
+func TestForecast_Type(t *testing.T) {
+	f := &Forecast{}
+	if f.Type() != FORECAST {
+		t.Fatal("Expected insight type is", FORECAST, "Got", f.Type())
+	}
+}
+
+func TestForecast_FSFA(t *testing.T) {
+	d := monthlySales()
+	t.Run("Testing FSFA without time metric", func(t *testing.T) {
+		f := &Forecast{dt: d, ms: []Metric{d.Metrics["sales"],
+			d.Metrics["sales"]}}
+		f.FSFA()
+		if f.Relevant() {
+			t.Fatal("Expected forecast to be irrelevant without time metric.",
+				"Got it as relevant")
+		}
+	})
+
+	t.Run("Testing FSFA with insufficient records", func(t *testing.T) {
+		small := NewDataset()
+		small.AddMetric(Metric{Name: "year", DataType: Float,
+			Semantic: SemanticTime}, []float64{1, 2})
+		small.AddMetric(Metric{Name: "sales", DataType: Float}, []float64{1, 2})
+		f := &Forecast{dt: small, ms: []Metric{small.Metrics["sales"],
+			small.Metrics["year"]}}
+		f.FSFA()
+		if f.Relevant() {
+			t.Fatal("Expected forecast to be irrelevant with 2 records.",
+				"Got it as relevant")
+		}
+	})
+
+	t.Run("Testing FSFA in normal conditions", func(t *testing.T) {
+		f := &Forecast{dt: d, ms: []Metric{d.Metrics["sales"],
+			d.Metrics["month"]}}
+		f.FSFA()
+		if !f.Relevant() {
+			t.Fatal("Expected forecast to be relevant with normal conditions.",
+				"Got it as irrelevant")
+		}
+	})
+}
+
+type fGenerateTC struct {
+	ID          string
+	Description string
+	Thresholds  []Limit
+	Crossing    int
+}
+
+var fGenerateTCs = []fGenerateTC{
+	{"1", "Without thresholds", nil, 0},
+	{"2", "Projection reaching the target", []Limit{{AtLeast, 40}}, 4},
+	{"3", "Projection not reaching the target", []Limit{{AtLeast, 50}}, 0},
+	{"4", "Projection breaching the limits", []Limit{{AtMost, 50},
+		{Below, 38}}, 3},
+}
+
+func TestForecast_Generate(t *testing.T) {
+	t.Run("Testing generate when forecast is irrelevant", func(t *testing.T) {
+		f := &Forecast{}
+		f.Generate()
+		if f.Relevant() {
+			t.Fatal("Expected generation to be irrelvant when the insight",
+				"is irrelvant. Got it relevant")
+		}
+	})
+
+	for _, v := range fGenerateTCs {
+		t.Run(v.ID, func(t *testing.T) {
+			d := monthlySales(v.Thresholds...)
+			f := &Forecast{dt: d, ms: []Metric{d.Metrics["sales"],
+				d.Metrics["month"]}}
+			f.FSFA()
+			f.Generate()
+			if !f.Relevant() {
+				t.Fatal("Expected forecast to be relevant. Got irrelevant")
+			}
+			if len(f.Points()) != forecastHorizon {
+				t.Fatal("Expected", forecastHorizon, "projections. Got",
+					len(f.Points()))
+			}
+			p := f.Points()[0]
+			if math.Abs(p.Value-34) > 1e-9 || p.Time != "2020-01" ||
+				p.Lower > p.Value || p.Upper < p.Value {
+				t.Fatal("Expected the projection of 2020-01 to be 34. Got", p)
+			}
+			if _, c := f.Crossing(); c != v.Crossing {
+				t.Fatal("Expected threshold crossing at", v.Crossing, "Got", c)
+			}
+			l, ok := f.Visual().(visualizations.LineChart)
+			if !ok {
+				t.Fatal("Expected a line chart. Got", reflect.TypeOf(f.Visual()))
+			}
+			if len(l.Data()) != 12+forecastHorizon {
+				t.Fatal("Expected", 12+forecastHorizon, "records. Got",
+					len(l.Data()))
+			}
+			if len(l.ReferenceLines()) != len(v.Thresholds) {
+				t.Fatal("Expected the thresholds as reference lines. Got",
+					l.ReferenceLines())
+			}
+		})
+	}
+
+	//horizon beyond half of the history is limited to it
+	for _, v := range [][2]int{{3, 3}, {20, 6}} {
+		t.Run("Testing generate with horizon", func(t *testing.T) {
+			d := monthlySales()
+			f := &Forecast{Horizon: v[0], dt: d, ms: []Metric{d.Metrics["sales"],
+				d.Metrics["month"]}}
+			f.FSFA()
+			f.Generate()
+			if len(f.Points()) != v[1] {
+				t.Fatal("Expected", v[1], "projections for the horizon", v[0],
+					"Got", len(f.Points()))
+			}
+		})
+	}
+}
+
+func TestForecast_Propose(t *testing.T) {
+	t.Run("Testing propose without time metric", func(t *testing.T) {
+		d := NewDataset()
+		d.AddMetric(Metric{Name: "sales", DataType: Float}, []float64{1, 2})
+		if pro := (&Forecast{}).Propose(d); len(pro) != 0 {
+			t.Fatal("Expected no proposals. Got", len(pro))
+		}
+	})
+
+	t.Run("Testing propose with time metric", func(t *testing.T) {
+		d := monthlySales()
+		d.AddMetric(Metric{Name: "region", DataType: String},
+			make([]string, 12))
+		pro := (&Forecast{}).Propose(d)
+		if len(pro) != 1 {
+			t.Fatal("Expected 1 proposal. Got", len(pro))
+		}
+		if pro[0].M[0].Name != "sales" || pro[0].M[1].Name != "month" {
+			t.Fatal("Expected sales and month in the proposal. Got", pro[0].M)
+		}
+	})
+}
+
+func TestFitHolt(t *testing.T) {
+	if _, ok := fitHolt([]float64{1, 2}); ok {
+		t.Fatal("Expected the fit to fail with 2 values")
+	}
+	h, ok := fitHolt([]float64{3, 5, 4, 6, 5, 7, 6, 8})
+	if !ok {
+		t.Fatal("Expected the fit to succeed")
+	}
+	p, l, u := h.forecast(3)
+	for i := range p {
+		if !(l[i] < p[i] && p[i] < u[i]) {
+			t.Fatal("Expected the projection within the bounds. Got", l, p, u)
+		}
+		if i > 0 && u[i]-l[i] < u[i-1]-l[i-1] {
+			t.Fatal("Expected the intervals to widen. Got", l, u)
+		}
+	}
+}
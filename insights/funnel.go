@@ -0,0 +1,432 @@
+package insights
+
+import (
+	"math"
+	"sort"
+
+	"github.com/cuttle-ai/brain/visualizations"
+)
+
+/*
+	This file contains the utilities and structs required for funnel
+	conversion insights
+*/
+
+const (
+	//maxFunnelSegments is the maximum no. of segments for which the funnels
+	//are compared. Metrics having more segments aren't compared.
+	maxFunnelSegments = 20
+	//allSegments is the segment label of the funnel of all the records
+	allSegments = "All"
+)
+
+//FunnelStage is a stage in the funnel
+type FunnelStage struct {
+	Stage string //Stage is the name of the stage
+	//Count is the no. of records reaching the stage. If the stages are from
+	//an event log having the users, it is the no. of distinct users.
+	Count float64
+	//Conversion is the ratio of the count of the previous stage reaching
+	//this stage. It is 1 for the first stage.
+	Conversion float64
+}
+
+//FunnelSegment is the funnel of the records in a segment
+type FunnelSegment struct {
+	Segment string        //Segment is the name of the segment
+	Stages  []FunnelStage //Stages has the stages of the funnel of the segment
+	//Conversion is the ratio of the count of the first stage reaching the
+	//last stage
+	Conversion float64
+}
+
+//Funnel is the funnel conversion insight.
+//It finds the conversion between the stages of a process, the biggest drop
+//off and compares the funnels across segments.
+//Stages are either float metrics having the no. of records reaching the stage
+//or a string metric of an event log having the name of the stage reached.
+//Stage metrics are ordered as they are in the dataset while the stages of an
+//event log are ordered by the no. of records reaching them.
+type Funnel struct {
+	//visual has the visualization to be used for showing the funnel
+	visual visualizations.Visual
+	//relevant stores the information whether the insight is relevant or not.
+	//This property is updated after running methods like FSFA and Generate
+	relevant bool
+	dt       Dataset //dt is the dataset having the stages
+	//ms is the list of metrics having the stages, the users and the segments
+	//identified by their semantics
+	ms       []Metric
+	stages   []FunnelStage   //stages has the stages of the funnel
+	dropOff  int             //dropOff is the index of the stage with biggest drop off
+	segments []FunnelSegment //segments has the funnels of the segments
+}
+
+//New returns a new instance of the Funnel with
+//initializations done for the given dataset
+func (f *Funnel) New(d Dataset, ms []Metric) Insight {
+	return &Funnel{dt: d, ms: ms}
+}
+
+//Visual returns the visualization to be used for visualizing the funnel
+func (f *Funnel) Visual() visualizations.Visual {
+	return f.visual
+}
+
+//Type returns the type string for the funnel type of insight
+func (f *Funnel) Type() string {
+	return FUNNEL
+}
+
+//Relevant returns whether the insight is relevant or not for the given dataset.
+func (f *Funnel) Relevant() bool {
+	return f.relevant
+}
+
+//Stages returns the stages of the funnel in their order
+func (f *Funnel) Stages() []FunnelStage {
+	return f.stages
+}
+
+//DropOff returns the index of the stage having the biggest drop off from
+//its previous stage
+func (f *Funnel) DropOff() int {
+	return f.dropOff
+}
+
+//Segments returns the funnels of the segments sorted by their conversion
+func (f *Funnel) Segments() []FunnelSegment {
+	return f.segments
+}
+
+//funnelRoles has the metrics of a funnel identified by their roles
+type funnelRoles struct {
+	stages  []Metric //stages are the float metrics having the stage counts
+	log     *Metric  //log is the string metric having the stage names
+	user    *Metric  //user is the metric having the user of the event log
+	segment *Metric  //segment is the metric having the segments
+}
+
+//label returns the name of the stage shown in the funnel. Float stage
+//metrics are counted by their names so that the metrics having the same
+//display name are kept apart. They are shown by their display names.
+func (r funnelRoles) label(stage string) string {
+	if len(r.stages) < 2 {
+		return stage
+	}
+	for _, m := range r.stages {
+		if m.Name == stage {
+			return displayName(m)
+		}
+	}
+	return stage
+}
+
+//roles identifies the metrics of the funnel by their semantics
+func (f *Funnel) roles() funnelRoles {
+	r := funnelRoles{}
+	for i, m := range f.ms {
+		switch {
+		case m.Semantic == SemanticStage && m.DataType == Float:
+			r.stages = append(r.stages, m)
+		case m.Semantic == SemanticStage && m.DataType == String:
+			r.log = &f.ms[i]
+		case m.Semantic == SemanticUser:
+			r.user = &f.ms[i]
+		case m.Semantic == SemanticSegment:
+			r.segment = &f.ms[i]
+		}
+	}
+	return r
+}
+
+//FSFA does the fast statistical feasibilty analysis over the dataset
+//with the given metrics whether the funnel can be analysed.
+//It requires either atleast two float stage metrics or a string stage metric.
+func (f *Funnel) FSFA() {
+	r := f.roles()
+	if len(r.stages) < 2 && r.log == nil {
+		f.relevant = false
+		return
+	}
+	f.relevant = f.dt.Length > 0
+}
+
+//Generate generates the funnel insight for the datatset associated with
+//it for the provided metrics.
+//This method can only be run after running the FSFA.
+//Else the insight won't be generated
+func (f *Funnel) Generate() {
+	/*
+		If the insight is not relevant we won't event bother
+		to go forward.
+		We will find the count of each stage for all the records and order
+		the stages by the counts.
+		Then we find the conversions and the biggest drop off.
+		Then we find the funnels of the segments in the same order of stages.
+	*/
+	//Checking whether the existing relevance of the insight
+	if !f.relevant {
+		return
+	}
+	r := f.roles()
+
+	//finding the stages
+	counts, ok := f.counts(r, nil, "")
+	if !ok || len(counts) < 2 {
+		f.relevant = false
+		return
+	}
+	order := make([]string, 0, len(counts))
+	if len(r.stages) >= 2 {
+		//stage columns are ordered as they are in the dataset
+		sort.Slice(r.stages, func(i, j int) bool {
+			return r.stages[i].Index < r.stages[j].Index
+		})
+		for _, m := range r.stages {
+			order = append(order, m.Name)
+		}
+	} else {
+		//stages in the event log are ordered by the no. of records reaching them
+		for s := range counts {
+			order = append(order, s)
+		}
+		sort.Slice(order, func(i, j int) bool {
+			if counts[order[i]] == counts[order[j]] {
+				return order[i] < order[j]
+			}
+			return counts[order[i]] > counts[order[j]]
+		})
+	}
+	f.stages = funnelStages(order, counts, r.label)
+	if f.stages[0].Count == 0 {
+		f.relevant = false
+		return
+	}
+	f.dropOff = 1
+	for i := 2; i < len(f.stages); i++ {
+		if f.stages[i].Conversion < f.stages[f.dropOff].Conversion {
+			f.dropOff = i
+		}
+	}
+
+	//finding the funnels of the segments
+	f.segments = []FunnelSegment{}
+	if r.segment != nil {
+		f.segments = f.segmentFunnels(r, order)
+	}
+
+	f.relevant = true
+	f.visual = f.funnel()
+}
+
+//counts returns the count of each stage for the records in the segment.
+//Float stage metrics are counted by their names and the stages of the event
+//log by their values. If the segment keys are nil, all the records are
+//considered.
+func (f *Funnel) counts(r funnelRoles, segs []string, seg string) (map[string]float64, bool) {
+	/*
+		For the float stage metrics we sum the values of the records.
+		For the event log we count the records with each stage name. If there
+		is a user metric we count the distinct users instead.
+	*/
+	counts := map[string]float64{}
+	in := func(i int) bool {
+		return segs == nil || segs[i] == seg
+	}
+
+	//float stage metrics
+	if len(r.stages) >= 2 {
+		for _, m := range r.stages {
+			if m.Index >= len(f.dt.DataF) {
+				return nil, false
+			}
+			counts[m.Name] = 0
+			for i, v := range f.dt.DataF[m.Index] {
+				if in(i) && !math.IsNaN(v) {
+					counts[m.Name] += v
+				}
+			}
+		}
+		return counts, true
+	}
+
+	//event log
+	stages, ok := keys(f.dt, *r.log)
+	if !ok {
+		return nil, false
+	}
+	var users []string
+	if r.user != nil {
+		if users, ok = keys(f.dt, *r.user); !ok {
+			return nil, false
+		}
+	}
+	seen := map[string]map[string]bool{}
+	for i, s := range stages {
+		if len(s) == 0 {
+			continue
+		}
+		if _, ok := counts[s]; !ok {
+			counts[s] = 0
+			seen[s] = map[string]bool{}
+		}
+		if !in(i) {
+			continue
+		}
+		if users == nil {
+			counts[s]++
+			continue
+		}
+		if !seen[s][users[i]] {
+			seen[s][users[i]] = true
+			counts[s]++
+		}
+	}
+	return counts, true
+}
+
+//segmentFunnels returns the funnels of the segments sorted by their
+//conversion. Segments without any records in the first stage are skipped.
+//Records with missing or empty segment are left out of the segments.
+func (f *Funnel) segmentFunnels(r funnelRoles, order []string) []FunnelSegment {
+	result := []FunnelSegment{}
+	segs, ok := keys(f.dt, *r.segment)
+	if !ok {
+		return result
+	}
+	distinct := map[string]bool{}
+	for i, s := range segs {
+		//records with missing segment are not a segment of their own
+		if !f.dt.Valid(r.segment.Name, i) || len(s) == 0 {
+			continue
+		}
+		distinct[s] = true
+	}
+	if len(distinct) < 2 || len(distinct) > maxFunnelSegments {
+		return result
+	}
+	for s := range distinct {
+		counts, ok := f.counts(r, segs, s)
+		if !ok || counts[order[0]] == 0 {
+			continue
+		}
+		stages := funnelStages(order, counts, r.label)
+		result = append(result, FunnelSegment{s, stages,
+			stages[len(stages)-1].Count / stages[0].Count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Conversion == result[j].Conversion {
+			return result[i].Segment < result[j].Segment
+		}
+		return result[i].Conversion > result[j].Conversion
+	})
+	return result
+}
+
+//funnel returns the funnel visualization of the stages and the segments
+func (f *Funnel) funnel() visualizations.Funnel {
+	first, last := f.stages[0], f.stages[len(f.stages)-1]
+	drop := f.stages[f.dropOff]
+	desc := "Biggest drop off is from " + f.stages[f.dropOff-1].Stage + " to " +
+		drop.Stage + " where only " + formatFloat(drop.Conversion*100) +
+		"% convert. Overall conversion is " +
+		formatFloat(last.Count/first.Count*100) + "%"
+	if len(f.segments) > 1 {
+		best, worst := f.segments[0], f.segments[len(f.segments)-1]
+		desc += ". " + best.Segment + " converts the best with " +
+			formatFloat(best.Conversion*100) + "% and " + worst.Segment +
+			" the worst with " + formatFloat(worst.Conversion*100) + "%"
+	}
+	visual := visualizations.Funnel{
+		T: "Conversion from " + first.Stage + " to " + last.Stage,
+		D: desc,
+		M: []visualizations.Metric{
+			{Name: "stage", DisplayName: "Stage", DataType: String,
+				Dimension: 0},
+			{Name: "count", DisplayName: "Count", DataType: Float,
+				Dimension: 1},
+		},
+	}
+	funnels := []FunnelSegment{{allSegments, f.stages, 0}}
+	if len(f.segments) > 0 {
+		visual.M = append(visual.M, visualizations.Metric{Name: "segment",
+			DisplayName: "Segment", DataType: String, Dimension: 2})
+		funnels = append(funnels, f.segments...)
+	}
+	data := []map[string]interface{}{}
+	for _, fs := range funnels {
+		for _, s := range fs.Stages {
+			rec := map[string]interface{}{"stage": s.Stage, "count": s.Count}
+			if len(f.segments) > 0 {
+				rec["segment"] = fs.Segment
+			}
+			data = append(data, rec)
+		}
+	}
+	visual.Dt = data
+	return visual
+}
+
+//Propose suggests the possible insights from the domain knowledge.
+//Datasets having atleast two float stage metrics or a string stage metric
+//are proposed for the funnel. Funnel is proposed for each segment metric.
+func (f *Funnel) Propose(d Dataset) []ProposedInsight {
+	/*
+		We will select the stage metrics and the user metric.
+		Then we will propose the funnel with each segment metric.
+		If there are no segment metrics, we will propose only the funnel.
+	*/
+	result := []ProposedInsight{}
+	base := []Metric{}
+	floats := 0
+	var log *Metric
+	segs := []Metric{}
+	for _, m := range sortedMetrics(d) {
+		switch {
+		case m.Semantic == SemanticStage && m.DataType == Float:
+			base = append(base, m)
+			floats++
+		case m.Semantic == SemanticStage && m.DataType == String && log == nil:
+			mc := m
+			log = &mc
+		case m.Semantic == SemanticSegment:
+			segs = append(segs, m)
+		}
+	}
+	if floats < 2 {
+		if log == nil {
+			return result
+		}
+		base = []Metric{*log}
+		if u, ok := metricWithSemantic(d, SemanticUser); ok {
+			base = append(base, u)
+		}
+	}
+	if len(segs) == 0 {
+		return append(result, ProposedInsight{f.New(d, base), base})
+	}
+	for _, s := range segs {
+		ms := append(append([]Metric{}, base...), s)
+		result = append(result, ProposedInsight{f.New(d, ms), ms})
+	}
+	return result
+}
+
+//funnelStages returns the stages in the order with their conversions. The
+//stages are named by the label function.
+func funnelStages(order []string, counts map[string]float64, label func(string) string) []FunnelStage {
+	stages := make([]FunnelStage, len(order))
+	for i, s := range order {
+		stages[i] = FunnelStage{Stage: label(s), Count: counts[s], Conversion: 1}
+		if i == 0 {
+			continue
+		}
+		if prev := stages[i-1].Count; prev > 0 {
+			stages[i].Conversion = counts[s] / prev
+		} else {
+			stages[i].Conversion = 0
+		}
+	}
+	return stages
+}
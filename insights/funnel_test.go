@@ -0,0 +1,225 @@
+package insights
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cuttle-ai/brain/visualizations"
+)
+
+/*
+	This file contains the tests for the funnel conversion insight
+*/
+
+//funnelMetrics are the metrics of the event log datasets
+var funnelMetrics = []Metric{
+	{Name: "stage", DataType: String, Semantic: SemanticStage},
+	{Name: "user", DataType: String, Semantic: SemanticUser},
+	{Name: "channel", DataType: String, Semantic: SemanticSegment},
+}
+
+//eventLog returns the event log of 10 users visiting, 6 of them signing up
+//and 2 of them purchasing. Users from web convert better than mobile.
+func eventLog() Dataset {
+	stages, users, channels := []string{}, []string{}, []string{}
+	add := func(stage, user, channel string) {
+		stages = append(stages, stage)
+		users = append(users, user)
+		channels = append(channels, channel)
+	}
+	for u := 0; u < 10; u++ {
+		id := string(rune('a' + u))
+		channel := "mobile"
+		if u < 5 {
+			channel = "web"
+		}
+		//visiting twice shouldn't be counted twice
+		add("visit", id, channel)
+		add("visit", id, channel)
+		if u < 4 || u == 5 || u == 6 {
+			add("signup", id, channel)
+		}
+		if u < 2 {
+			add("purchase", id, channel)
+		}
+	}
+	d := NewDataset()
+	d.AddMetric(funnelMetrics[0], stages)
+	d.AddMetric(funnelMetrics[1], users)
+	d.AddMetric(funnelMetrics[2], channels)
+	return d
+}
+
+func TestFunnel_New(t *testing.T) {
+	d := eventLog()
+	fi := (&Funnel{}).New(d, funnelMetrics)
+	f, ok := fi.(*Funnel)
+	if !ok {
+		t.Fatal("Expected a funnel. Got", reflect.TypeOf(fi))
+	}
+	if len(f.ms) != 3 {
+		t.Fatal("Expected 3 metrics. Got", len(f.ms))
+	}
+}
+
+func TestFunnel_Type(t *testing.T) {
+	f := &Funnel{}
+	if f.Type() != FUNNEL {
+		t.Fatal("Expected insight type is", FUNNEL, "Got", f.Type())
+	}
+}
+
+func TestFunnel_FSFA(t *testing.T) {
+	d := eventLog()
+	f := &Funnel{dt: d, ms: funnelMetrics[1:]}
+	f.FSFA()
+	if f.Relevant() {
+		t.Fatal("Expected funnel to be irrelevant without stage metric.",
+			"Got it as relevant")
+	}
+	f = &Funnel{dt: d, ms: funnelMetrics}
+	f.FSFA()
+	if !f.Relevant() {
+		t.Fatal("Expected funnel to be relevant with normal conditions.",
+			"Got it as irrelevant")
+	}
+}
+
+func TestFunnel_Generate(t *testing.T) {
+	t.Run("Testing generate with event log", func(t *testing.T) {
+		d := eventLog()
+		f := (&Funnel{}).Propose(d)[0].I.(*Funnel)
+		f.FSFA()
+		f.Generate()
+		if !f.Relevant() {
+			t.Fatal("Expected funnel to be relevant. Got irrelevant")
+		}
+		expected := []FunnelStage{
+			{"visit", 10, 1},
+			{"signup", 6, 0.6},
+			{"purchase", 2, 2.0 / 6},
+		}
+		if !reflect.DeepEqual(f.Stages(), expected) {
+			t.Fatal("Expected stages", expected, "Got", f.Stages())
+		}
+		if f.DropOff() != 2 {
+			t.Fatal("Expected biggest drop off at purchase. Got", f.DropOff())
+		}
+		segs := f.Segments()
+		if len(segs) != 2 || segs[0].Segment != "web" ||
+			segs[0].Conversion != 0.4 || segs[1].Segment != "mobile" ||
+			segs[1].Conversion != 0 {
+			t.Fatal("Expected web converting 40% and mobile 0%. Got", segs)
+		}
+		fv, ok := f.Visual().(visualizations.Funnel)
+		if !ok {
+			t.Fatal("Expected a funnel visual. Got", reflect.TypeOf(f.Visual()))
+		}
+		if len(fv.Data()) != 3*3 {
+			t.Fatal("Expected 9 records in the funnel. Got", len(fv.Data()))
+		}
+	})
+
+	t.Run("Testing generate with missing segments", func(t *testing.T) {
+		d := eventLog()
+		channels := d.DataS[d.Metrics["channel"].Index]
+		valid := make([]bool, len(channels))
+		for i := range valid {
+			valid[i] = i%5 != 0
+			if i%7 == 0 {
+				channels[i] = ""
+			}
+		}
+		e := NewDataset()
+		e.AddMetric(funnelMetrics[0], d.DataS[d.Metrics["stage"].Index])
+		e.AddMetric(funnelMetrics[1], d.DataS[d.Metrics["user"].Index])
+		e.AddNullableMetric(funnelMetrics[2], channels, valid)
+		f := (&Funnel{}).Propose(e)[0].I.(*Funnel)
+		f.FSFA()
+		f.Generate()
+		segs := f.Segments()
+		if len(segs) != 2 || segs[0].Segment != "web" || segs[1].Segment != "mobile" {
+			t.Fatal("Expected only the web and mobile segments. Got", segs)
+		}
+	})
+
+	t.Run("Testing generate with stage counts", func(t *testing.T) {
+		ms := []Metric{
+			{Name: "leads", DataType: Float, Semantic: SemanticStage},
+			{Name: "deals", DataType: Float, Semantic: SemanticStage},
+			{Name: "trials", DataType: Float, Semantic: SemanticStage},
+		}
+		d := NewDataset()
+		d.AddMetric(ms[0], []float64{100, 60})
+		d.AddMetric(ms[2], []float64{50, 30})
+		d.AddMetric(ms[1], []float64{10, 5})
+		f := (&Funnel{}).Propose(d)[0].I.(*Funnel)
+		f.FSFA()
+		f.Generate()
+		if !f.Relevant() {
+			t.Fatal("Expected funnel to be relevant. Got irrelevant")
+		}
+		stages := f.Stages()
+		if len(stages) != 3 || stages[0].Stage != "leads" ||
+			stages[1].Stage != "trials" || stages[2].Stage != "deals" {
+			t.Fatal("Expected stages leads, trials and deals. Got", stages)
+		}
+		if f.DropOff() != 2 || stages[2].Conversion != 0.1875 {
+			t.Fatal("Expected biggest drop off at deals with 18.75%. Got",
+				f.DropOff(), stages[2].Conversion)
+		}
+		if len(f.Segments()) != 0 {
+			t.Fatal("Expected no segments. Got", f.Segments())
+		}
+	})
+
+	t.Run("Testing generate with stages of the same display name", func(t *testing.T) {
+		ms := []Metric{
+			{Name: "visits", DisplayName: "Users", DataType: Float,
+				Semantic: SemanticStage},
+			{Name: "signups", DisplayName: "Users", DataType: Float,
+				Semantic: SemanticStage},
+		}
+		d := NewDataset()
+		d.AddMetric(ms[0], []float64{100, 60})
+		d.AddMetric(ms[1], []float64{40, 20})
+		f := &Funnel{dt: d, ms: []Metric{d.Metrics["visits"],
+			d.Metrics["signups"]}}
+		f.FSFA()
+		f.Generate()
+		if !f.Relevant() {
+			t.Fatal("Expected funnel to be relevant. Got irrelevant")
+		}
+		stages := f.Stages()
+		if len(stages) != 2 || stages[0].Count != 160 || stages[1].Count != 60 ||
+			stages[1].Stage != "Users" {
+			t.Fatal("Expected stages of 160 and 60 users. Got", stages)
+		}
+	})
+
+	t.Run("Testing generate with a single stage", func(t *testing.T) {
+		d := NewDataset()
+		d.AddMetric(funnelMetrics[0], []string{"visit", "visit"})
+		f := &Funnel{dt: d, ms: funnelMetrics[:1]}
+		f.FSFA()
+		f.Generate()
+		if f.Relevant() {
+			t.Fatal("Expected funnel to be irrelevant with a single stage.",
+				"Got relevant")
+		}
+	})
+}
+
+func TestFunnel_Propose(t *testing.T) {
+	d := eventLog()
+	pro := (&Funnel{}).Propose(d)
+	if len(pro) != 1 || len(pro[0].M) != 3 {
+		t.Fatal("Expected 1 proposal with 3 metrics. Got", pro)
+	}
+	d = NewDataset()
+	d.AddMetric(Metric{Name: "stage", DataType: Float,
+		Semantic: SemanticStage}, []float64{1})
+	if pro := (&Funnel{}).Propose(d); len(pro) != 0 {
+		t.Fatal("Expected no proposals. Got", len(pro))
+	}
+}
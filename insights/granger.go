@@ -0,0 +1,282 @@
+package insights
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/cuttle-ai/brain/visualizations"
+	"github.com/gonum/stat/distuv"
+)
+
+/*
+	This file contains the utilities and structs required for granger
+	causality insights
+*/
+
+const (
+	//minGrangerRecords is the minimum no. of records in the history of the
+	//metrics required for the granger causality test
+	minGrangerRecords = 30
+	//maxGrangerLag is the maximum lag order tried for the test
+	maxGrangerLag = 8
+	//grangerAlpha is the significance level of the test
+	grangerAlpha = 0.05
+)
+
+//Granger is the granger causality insight.
+//It tests whether the past values of a metric improve the prediction of
+//another metric over its own past values. Unlike correlation, it gives an
+//evidence of the direction of the relationship.
+type Granger struct {
+	//visual has the visualization to be used for showing the metrics.
+	//Line chart of the metrics over time is used.
+	visual visualizations.Visual
+	//relevant stores the information whether the insight is relevant or not.
+	//This property is updated after running methods like FSFA and Generate
+	relevant bool
+	dt       Dataset //dt is the dataset to be used for the insight
+	//ms is the list of metrics. They are the cause, the effect and the time
+	//metric in the order.
+	ms     []Metric
+	f      float64 //f is the F statistic of the test
+	pValue float64 //pValue is the p-value of the test
+	lag    int     //lag is the lag order chosen by the AIC
+	//differenced is true if the metrics were differenced as they were non
+	//stationary
+	differenced bool
+}
+
+//New returns a new instance of the Granger with
+//initializations done for the given dataset
+func (g *Granger) New(d Dataset, ms []Metric) Insight {
+	return &Granger{dt: d, ms: ms}
+}
+
+//Visual returns the visualization to be used for visualizing the metrics
+func (g *Granger) Visual() visualizations.Visual {
+	return g.visual
+}
+
+//Type returns the type string for the granger causality type of insight
+func (g *Granger) Type() string {
+	return GRANGER
+}
+
+//Relevant returns whether the insight is relevant or not for the given dataset.
+func (g *Granger) Relevant() bool {
+	return g.relevant
+}
+
+//FStatistic returns the F statistic of the test
+func (g *Granger) FStatistic() float64 {
+	return g.f
+}
+
+//PValue returns the p-value of the test
+func (g *Granger) PValue() float64 {
+	return g.pValue
+}
+
+//Lag returns the no. of past periods used in the test. It is chosen by the
+//akaike information criterion.
+func (g *Granger) Lag() int {
+	return g.lag
+}
+
+//Differenced returns whether the changes of the metrics were tested instead
+//of the values as the metrics were non stationary
+func (g *Granger) Differenced() bool {
+	return g.differenced
+}
+
+//FSFA does the fast statistical feasibilty analysis over the dataset
+//with the given metrics whether the granger causality can be tested.
+//It requires two float metrics and a time metric with atleast
+//minGrangerRecords no. of records.
+func (g *Granger) FSFA() {
+	if len(g.ms) != 3 || g.ms[0].DataType != Float ||
+		g.ms[1].DataType != Float || !isTime(g.ms[2]) {
+		g.relevant = false
+		return
+	}
+	g.relevant = g.dt.Length >= minGrangerRecords
+}
+
+//Generate generates the granger causality insight for the datatset
+//associated with it for the provided metrics. The insight is relevant only
+//if the first metric granger causes the second one.
+//This method can only be run after running the FSFA.
+//Else the insight won't be generated
+func (g *Granger) Generate() {
+	/*
+		If the insight is not relevant we won't event bother
+		to go forward.
+		We will order the metrics in time. If either of them has a unit root
+		we will test their changes instead.
+		Then we choose the lag order minimizing the AIC of the regression of
+		the effect on the past values of both the metrics.
+		Then we do the F test comparing it with the regression of the effect
+		on its own past values.
+	*/
+	//Checking whether the existing relevance of the insight
+	if !g.relevant {
+		return
+	}
+
+	//ordering the metrics in time
+	ax, ok := newTimeAxis(g.dt, g.ms[2])
+	if !ok {
+		g.relevant = false
+		return
+	}
+	x, y, labels := ax.pairs(g.dt, g.ms[0], g.ms[1])
+	if len(y) < minGrangerRecords {
+		g.relevant = false
+		return
+	}
+	cx, cy := x, y
+	g.differenced = nonStationary(x) || nonStationary(y)
+	if g.differenced {
+		cx, cy = differences(x), differences(y)
+	}
+
+	//choosing the lag and testing
+	maxLag := maxGrangerLag
+	if maxLag > len(cy)/10 {
+		maxLag = len(cy) / 10
+	}
+	bestAIC := math.Inf(1)
+	g.lag = 0
+	for p := 1; p <= maxLag; p++ {
+		u, ok := ols(laggedRegressors(cx, cy, p, maxLag, true), cy[maxLag:])
+		if !ok {
+			continue
+		}
+		n := float64(len(cy) - maxLag)
+		aic := n*math.Log(u.rss/n) + 2*float64(2*p+1)
+		if aic < bestAIC {
+			bestAIC, g.lag = aic, p
+		}
+	}
+	if g.lag == 0 {
+		g.relevant = false
+		return
+	}
+	if g.f, g.pValue, ok = grangerTest(cx, cy, g.lag); !ok ||
+		g.pValue >= grangerAlpha {
+		g.relevant = false
+		return
+	}
+
+	g.relevant = true
+	g.visual = g.line(x, y, labels)
+}
+
+//line returns the line chart of the metrics over time
+func (g *Granger) line(x, y []float64, labels []interface{}) visualizations.LineChart {
+	cause, effect, tm := g.ms[0], g.ms[1], g.ms[2]
+	cname, ename := displayName(cause), displayName(effect)
+	desc := "Past " + strconv.Itoa(g.lag) + " periods of " + cname +
+		" improve the prediction of " + ename + " (F statistic " +
+		formatFloat(g.f) + ", p-value " + strconv.FormatFloat(g.pValue, 'g', 4, 64) +
+		")"
+	if g.differenced {
+		desc += ". Changes of the metrics were tested as they are non stationary"
+	}
+	visual := visualizations.LineChart{
+		T: cname + " leads " + ename,
+		D: desc,
+		M: []visualizations.Metric{
+			{Name: tm.Name, DisplayName: tm.DisplayName, DataType: tm.DataType,
+				Dimension: 0},
+			{Name: cause.Name, DisplayName: cause.DisplayName, DataType: Float,
+				Dimension: 1},
+			{Name: effect.Name, DisplayName: effect.DisplayName, DataType: Float,
+				Dimension: 1},
+		},
+	}
+	data := make([]map[string]interface{}, len(y))
+	for i := range y {
+		data[i] = map[string]interface{}{tm.Name: labels[i], cause.Name: x[i],
+			effect.Name: y[i]}
+	}
+	visual.Dt = data
+	return visual
+}
+
+//Propose suggests the possible insights from the domain knowledge.
+//Each pair of float metrics of datasets having a time metric is proposed
+//in both the directions.
+func (g *Granger) Propose(d Dataset) []ProposedInsight {
+	result := []ProposedInsight{}
+	tm, ok := timeMetric(d)
+	if !ok {
+		return result
+	}
+	for _, pair := range floatPairs(d, tm.Name) {
+		for _, metrics := range [][]Metric{
+			{pair[0], pair[1], tm},
+			{pair[1], pair[0], tm},
+		} {
+			result = append(result, ProposedInsight{g.New(d, metrics), metrics})
+		}
+	}
+	return result
+}
+
+//grangerTest does the F test of whether the lagged values of x improve the
+//regression of y on its own lagged values. It returns the F statistic and
+//the p-value.
+func grangerTest(x, y []float64, p int) (float64, float64, bool) {
+	/*
+		F = ((rssR - rssU) / p) / (rssU / (n - 2p - 1))
+		where rssR is of the regression of y on its own lags and rssU is of
+		the regression on the lags of both.
+	*/
+	r, ok1 := ols(laggedRegressors(x, y, p, p, false), y[p:])
+	u, ok2 := ols(laggedRegressors(x, y, p, p, true), y[p:])
+	n := len(y) - p
+	d2 := float64(n - 2*p - 1)
+	if !ok1 || !ok2 || d2 <= 0 {
+		return 0, 0, false
+	}
+	if u.rss == 0 {
+		if r.rss == 0 {
+			return 0, 1, true
+		}
+		return math.Inf(1), 0, true
+	}
+	f := ((r.rss - u.rss) / float64(p)) / (u.rss / d2)
+	if f < 0 {
+		f = 0
+	}
+	return f, distuv.F{D1: float64(p), D2: d2}.Survival(f), true
+}
+
+//laggedRegressors returns the regressors for predicting y[t] for t starting
+//from start. They are a constant, p lags of y and p lags of x if asked.
+func laggedRegressors(x, y []float64, p, start int, withX bool) [][]float64 {
+	rows := make([][]float64, 0, len(y)-start)
+	for t := start; t < len(y); t++ {
+		row := []float64{1}
+		for j := 1; j <= p; j++ {
+			row = append(row, y[t-j])
+		}
+		if withX {
+			for j := 1; j <= p; j++ {
+				row = append(row, x[t-j])
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+//differences returns the changes between the consecutive values
+func differences(y []float64) []float64 {
+	d := make([]float64, len(y)-1)
+	for i := range d {
+		d[i] = y[i+1] - y[i]
+	}
+	return d
+}
@@ -0,0 +1,165 @@
+package insights
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/cuttle-ai/brain/visualizations"
+)
+
+/*
+	This file contains the tests for the granger causality insight
+*/
+
+//grangerDataset returns a dataset of 200 days with the ads spend and the
+//sales. Sales follow the ads spend of 2 days back if lead is true. Else they
+//are independent.
+func grangerDataset(lead bool) Dataset {
+	r := rand.New(rand.NewSource(3))
+	n := 200
+	days := make([]float64, n)
+	ads := make([]float64, n)
+	sales := make([]float64, n)
+	for i := range days {
+		days[i] = float64(i)
+		ads[i] = 10 + r.NormFloat64()
+		sales[i] = 50 + r.NormFloat64()
+		if lead && i > 1 {
+			sales[i] += 2 * ads[i-2]
+		}
+	}
+	d := NewDataset()
+	d.AddMetric(Metric{Name: "day", DataType: Float, Semantic: SemanticTime},
+		days)
+	d.AddMetric(Metric{Name: "ads", DisplayName: "Ads spend", DataType: Float},
+		ads)
+	d.AddMetric(Metric{Name: "sales", DisplayName: "Sales", DataType: Float},
+		sales)
+	return d
+}
+
+//grangerMetrics returns the metrics of the dataset for testing whether the
+//ads spend granger causes the sales
+func grangerMetrics(d Dataset) []Metric {
+	return []Metric{d.Metrics["ads"], d.Metrics["sales"], d.Metrics["day"]}
+}
+
+func TestGranger_New(t *testing.T) {
+	d := grangerDataset(true)
+	gi := (&Granger{}).New(d, grangerMetrics(d))
+	g, ok := gi.(*Granger)
+	if !ok {
+		t.Fatal("Expected a granger. Got", reflect.TypeOf(gi))
+	}
+	if len(g.ms) != 3 {
+		t.Fatal("Expected 3 metrics. Got", len(g.ms))
+	}
+}
+
+func TestGranger_Type(t *testing.T) {
+	g := &Granger{}
+	if g.Type() != GRANGER {
+		t.Fatal("Expected insight type is", GRANGER, "Got", g.Type())
+	}
+}
+
+func TestGranger_FSFA(t *testing.T) {
+	d := dailySeries([]float64{1, 2})
+	g := &Granger{dt: d, ms: []Metric{d.Metrics["value"], d.Metrics["value"],
+		d.Metrics["day"]}}
+	g.FSFA()
+	if g.Relevant() {
+		t.Fatal("Expected granger to be irrelevant with 2 records.",
+			"Got it as relevant")
+	}
+	d = grangerDataset(true)
+	g = &Granger{dt: d, ms: grangerMetrics(d)}
+	g.FSFA()
+	if !g.Relevant() {
+		t.Fatal("Expected granger to be relevant with normal conditions.",
+			"Got it as irrelevant")
+	}
+}
+
+func TestGranger_Generate(t *testing.T) {
+	t.Run("Testing generate with leading metric", func(t *testing.T) {
+		d := grangerDataset(true)
+		g := &Granger{dt: d, ms: grangerMetrics(d)}
+		g.FSFA()
+		g.Generate()
+		if !g.Relevant() {
+			t.Fatal("Expected granger to be relevant. Got irrelevant",
+				g.FStatistic(), g.PValue())
+		}
+		if g.Lag() < 2 {
+			t.Fatal("Expected a lag of atleast 2. Got", g.Lag())
+		}
+		if g.PValue() >= grangerAlpha || g.FStatistic() <= 0 {
+			t.Fatal("Expected a significant F statistic. Got", g.FStatistic(),
+				g.PValue())
+		}
+		if g.Differenced() {
+			t.Fatal("Expected the stationary metrics not to be differenced")
+		}
+		l, ok := g.Visual().(visualizations.LineChart)
+		if !ok {
+			t.Fatal("Expected a line chart. Got", reflect.TypeOf(g.Visual()))
+		}
+		if len(l.Metrics()) != 3 || len(l.Data()) != 200 {
+			t.Fatal("Expected 3 metrics and 200 records. Got",
+				len(l.Metrics()), len(l.Data()))
+		}
+	})
+
+	t.Run("Testing generate with reverse direction", func(t *testing.T) {
+		d := grangerDataset(true)
+		g := &Granger{dt: d, ms: []Metric{d.Metrics["sales"], d.Metrics["ads"],
+			d.Metrics["day"]}}
+		g.FSFA()
+		g.Generate()
+		if g.Relevant() {
+			t.Fatal("Expected sales not to granger cause ads spend. Got",
+				g.FStatistic(), g.PValue())
+		}
+	})
+
+	t.Run("Testing generate with independent metrics", func(t *testing.T) {
+		d := grangerDataset(false)
+		g := &Granger{dt: d, ms: grangerMetrics(d)}
+		g.FSFA()
+		g.Generate()
+		if g.Relevant() {
+			t.Fatal("Expected independent metrics to be irrelevant. Got",
+				g.FStatistic(), g.PValue())
+		}
+	})
+}
+
+func TestGranger_Propose(t *testing.T) {
+	d := grangerDataset(true)
+	pro := (&Granger{}).Propose(d)
+	if len(pro) != 2 {
+		t.Fatal("Expected proposals in both the directions. Got", len(pro))
+	}
+	d = dailySeries([]float64{1, 2})
+	if pro := (&Granger{}).Propose(d); len(pro) != 0 {
+		t.Fatal("Expected no proposals with a single float metric. Got",
+			len(pro))
+	}
+}
+
+func TestGrangerTest(t *testing.T) {
+	x := []float64{1, 3, 2, 5, 4, 6, 2, 7, 3, 8, 1, 4}
+	y := make([]float64, len(x))
+	for i := 1; i < len(x); i++ {
+		y[i] = x[i-1]
+	}
+	f, p, ok := grangerTest(x, y, 1)
+	if !ok || p != 0 {
+		t.Fatal("Expected an exact fit to have p-value 0. Got", f, p, ok)
+	}
+	if _, _, ok := grangerTest(x[:4], y[:4], 2); ok {
+		t.Fatal("Expected the test to fail with too few records")
+	}
+}
@@ -0,0 +1,374 @@
+package insights
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+	This file contains the utilities for grouping the records of the datasets
+	and aggregating the metrics in the groups
+*/
+
+const (
+	//AggSum is the aggregation function giving the sum of the values
+	AggSum = "sum"
+	//AggMean is the aggregation function giving the mean of the values
+	AggMean = "mean"
+	//AggCount is the aggregation function giving the no. of values that are
+	//not missing. It gives the no. of records if the metric is not given.
+	AggCount = "count"
+	//AggMin is the aggregation function giving the least of the values
+	AggMin = "min"
+	//AggMax is the aggregation function giving the greatest of the values
+	AggMax = "max"
+	//AggMedian is the aggregation function giving the median of the values
+	AggMedian = "median"
+	//AggDistinct is the aggregation function giving the no. of distinct
+	//values that are not missing
+	AggDistinct = "distinct"
+)
+
+const (
+	//GranularityDay is the granularity grouping the times by day
+	GranularityDay = granularityDay
+	//GranularityWeek is the granularity grouping the times by week starting
+	//on monday
+	GranularityWeek = granularityWeek
+	//GranularityMonth is the granularity grouping the times by month
+	GranularityMonth = granularityMonth
+	//GranularityQuarter is the granularity grouping the times by quarter
+	GranularityQuarter = granularityQuarter
+	//GranularityYear is the granularity grouping the times by year
+	GranularityYear = granularityYear
+)
+
+//granularities are the granularities by which the time dimensions can be
+//grouped
+var granularities = map[string]bool{
+	GranularityDay:     true,
+	GranularityWeek:    true,
+	GranularityMonth:   true,
+	GranularityQuarter: true,
+	GranularityYear:    true,
+}
+
+//aggregateNames are the prefixes of the display names of the aggregated
+//metrics for the aggregation functions
+var aggregateNames = map[string]string{
+	AggSum:      "Total",
+	AggMean:     "Average",
+	AggCount:    "Count of",
+	AggMin:      "Min",
+	AggMax:      "Max",
+	AggMedian:   "Median",
+	AggDistinct: "Distinct",
+}
+
+//Aggregation is the aggregation of a metric in the groups of a dataset
+type Aggregation struct {
+	//Func is the aggregation function like AggSum
+	Func string
+	//Metric is the name of the metric aggregated. It is optional for AggCount.
+	Metric string
+	//Name is the name of the aggregated metric. It is the name of the metric
+	//followed by the function like revenue_sum if not set. It is count for
+	//the count of the records.
+	Name string
+}
+
+//Grouping is a dataset grouped by the dimensions. Metrics are aggregated in
+//the groups by the Aggregate method.
+type Grouping struct {
+	//d is the dataset grouped
+	d Dataset
+	//dimensions are the names of the metrics by which the records are grouped
+	dimensions []string
+	//granularity is the granularity of the time dimensions
+	granularity string
+}
+
+//GroupBy groups the records of the dataset by the values of the dimensions.
+//Records having the same values for all the dimensions are in the same
+//group. Missing values of a dimension form a group of their own.
+func (d Dataset) GroupBy(dimensions ...string) Grouping {
+	return Grouping{d: d, dimensions: dimensions}
+}
+
+//Per sets the granularity like GranularityMonth by which the time
+//dimensions are grouped. Times stored as strings or of the Time data type
+//are grouped by the periods of the granularity. Time dimensions are grouped
+//by their values if it is not set. Aggregate returns an error for the
+//granularities other than the Granularity constants.
+func (g Grouping) Per(granularity string) Grouping {
+	g.granularity = granularity
+	return g
+}
+
+//Aggregate aggregates the metrics in the groups and returns a dataset having
+//a record per group. The dataset has the dimensions followed by the
+//aggregated metrics. Groups are in the order of their first record. Time
+//dimensions grouped by a granularity have the start of the periods as their
+//values. Times that couldn't be parsed are grouped as missing. Aggregated
+//metrics are Float metrics and missing values are left out while
+//aggregating them. Aggregates of the groups without values are NaN except
+//the sums, counts and distinct counts which are 0. Aggregated metrics keep
+//the semantic of their metric other than the time. Counts have the count
+//semantic. It returns an error if the granularity is not one of the
+//Granularity constants, a dimension or a metric doesn't exist, the function
+//is not supported, a non numeric metric is aggregated by a function other
+//than AggCount and AggDistinct or the name of an aggregated metric is
+//already taken by a dimension or another aggregation.
+func (g Grouping) Aggregate(aggs ...Aggregation) (Dataset, error) {
+	/*
+		We will find the key of each record from its dimensions.
+		Then we collect the records of each key as a group.
+		Then we add the dimensions of the groups to the result.
+		Then we aggregate each metric in the groups.
+	*/
+	d := g.d
+	res := NewDataset()
+	if g.granularity != "" && !granularities[g.granularity] {
+		return res, &Error{ErrMGranularity + g.granularity, ErrCGeneric}
+	}
+
+	//finding the keys of the records
+	n := int(d.Length)
+	dims := make([]Metric, len(g.dimensions))
+	rowKeys := make([][]string, n)
+	periods := make([][]int, len(dims))
+	for j, name := range g.dimensions {
+		m, ok := d.Metrics[name]
+		if !ok {
+			return res, &Error{ErrMMetricNotFound + name, ErrCGeneric}
+		}
+		dims[j] = m
+		ks, ok := keys(d, m)
+		if !ok {
+			return res, &Error{ErrMMetricsDatasizeIncorrect, ErrCMetricSizeMismatch}
+		}
+		bucket := g.granularity != "" && isTime(m) && m.DataType != Float
+		if bucket {
+			periods[j] = make([]int, n)
+		}
+		for i := 0; i < n; i++ {
+			k := "\x01"
+			if d.Valid(name, i) {
+				k = ks[i]
+			}
+			if !bucket {
+				rowKeys[i] = append(rowKeys[i], k)
+				continue
+			}
+			//times that couldn't be parsed are grouped as missing
+			if t, _, ok := parseTime(k); ok {
+				periods[j][i] = periodIndex(t, g.granularity)
+				k = strconv.Itoa(periods[j][i])
+			} else {
+				k = "\x01"
+			}
+			rowKeys[i] = append(rowKeys[i], k)
+		}
+	}
+
+	//collecting the groups
+	groups := [][]int{}
+	index := map[string]int{}
+	for i := 0; i < n; i++ {
+		k := strings.Join(rowKeys[i], "\x00")
+		gi, ok := index[k]
+		if !ok {
+			gi = len(groups)
+			index[k] = gi
+			groups = append(groups, []int{})
+		}
+		groups[gi] = append(groups[gi], i)
+	}
+	first := make([]int, len(groups))
+	for i, rows := range groups {
+		first[i] = rows[0]
+	}
+
+	//adding the dimensions
+	for j, m := range dims {
+		var data interface{}
+		dm := Metric{Name: m.Name, DisplayName: m.DisplayName,
+			DataType: m.DataType, Semantic: m.Semantic}
+		switch {
+		case periods[j] != nil && m.DataType == Time:
+			ts := make([]time.Time, len(groups))
+			for i, r := range first {
+				ts[i] = periodStart(periods[j][r], g.granularity)
+			}
+			data = ts
+		case periods[j] != nil:
+			ss := make([]string, len(groups))
+			for i, r := range first {
+				ss[i] = periodLabel(periods[j][r], g.granularity)
+			}
+			data = ss
+		default:
+			data = take(d, m, first)
+		}
+		valid := takeValid(d, m.Name, first)
+		for i, r := range first {
+			valid[i] = valid[i] && rowKeys[r][j] != "\x01"
+		}
+		if err := res.AddNullableMetric(dm, data, valid); err != nil {
+			return res, err
+		}
+	}
+
+	//aggregating the metrics
+	for _, a := range aggs {
+		m, vals, err := aggregate(d, a, groups)
+		if err != nil {
+			return res, err
+		}
+		if _, ok := res.Metrics[m.Name]; ok {
+			return res, &Error{ErrMAggregateName + m.Name, ErrCSchemaConflict}
+		}
+		if err := res.AddMetric(m, vals); err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+//aggregate returns the aggregated metric and its values in the groups
+func aggregate(d Dataset, a Aggregation, groups [][]int) (Metric, []float64, error) {
+	/*
+		We will find the metric aggregated and name the result.
+		Then based on the function we aggregate the values in each group.
+	*/
+	vals := make([]float64, len(groups))
+	prefix, ok := aggregateNames[a.Func]
+	if !ok {
+		return Metric{}, nil, &Error{ErrMAggregateFunc + a.Func, ErrCGeneric}
+	}
+
+	//counting the records
+	if a.Func == AggCount && len(a.Metric) == 0 {
+		name := a.Name
+		if len(name) == 0 {
+			name = AggCount
+		}
+		for i, rows := range groups {
+			vals[i] = float64(len(rows))
+		}
+		return Metric{Name: name, DisplayName: "Count", DataType: Float,
+			Semantic: SemanticCount}, vals, nil
+	}
+
+	//naming the metric
+	m, ok := d.Metrics[a.Metric]
+	if !ok {
+		return Metric{}, nil, &Error{ErrMMetricNotFound + a.Metric, ErrCGeneric}
+	}
+	res := Metric{Name: a.Name, DisplayName: prefix + " " + displayName(m),
+		DataType: Float, Semantic: m.Semantic}
+	if len(res.Name) == 0 {
+		res.Name = m.Name + "_" + a.Func
+	}
+	if isTime(m) {
+		res.Semantic = ""
+	}
+
+	//counting the values
+	if a.Func == AggCount || a.Func == AggDistinct {
+		res.Semantic = SemanticCount
+		ks, ok := keys(d, m)
+		if !ok {
+			return Metric{}, nil, &Error{ErrMMetricsDatasizeIncorrect,
+				ErrCMetricSizeMismatch}
+		}
+		for i, rows := range groups {
+			seen := map[string]bool{}
+			for _, r := range rows {
+				if !d.Valid(m.Name, r) {
+					continue
+				}
+				if a.Func == AggCount {
+					vals[i]++
+				} else if !seen[ks[r]] {
+					seen[ks[r]] = true
+					vals[i]++
+				}
+			}
+		}
+		return res, vals, nil
+	}
+
+	//aggregating the numbers
+	nums, ok := numbers(d, m)
+	if !ok {
+		return Metric{}, nil, &Error{ErrMAggregateType + a.Func + ". Got " +
+			m.DataType, ErrCDataTypeMismatch}
+	}
+	for i, rows := range groups {
+		vs := []float64{}
+		for _, r := range rows {
+			if !math.IsNaN(nums[r]) {
+				vs = append(vs, nums[r])
+			}
+		}
+		vals[i] = aggregateValues(a.Func, vs)
+	}
+	return res, vals, nil
+}
+
+//aggregateValues returns the aggregate of the values by the numeric
+//aggregation function
+func aggregateValues(f string, vals []float64) float64 {
+	if f == AggSum {
+		s := 0.0
+		for _, v := range vals {
+			s += v
+		}
+		return s
+	}
+	if len(vals) == 0 {
+		return math.NaN()
+	}
+	switch f {
+	case AggMean:
+		return aggregateValues(AggSum, vals) / float64(len(vals))
+	case AggMedian:
+		return median(vals)
+	}
+	r := vals[0]
+	for _, v := range vals[1:] {
+		if (f == AggMin && v < r) || (f == AggMax && v > r) {
+			r = v
+		}
+	}
+	return r
+}
+
+//numbers returns the values of the float or int metric as floats. Missing
+//values are NaN. If the metric is not numeric or doesn't have data in the
+//dataset, false is returned.
+func numbers(d Dataset, m Metric) ([]float64, bool) {
+	switch m.DataType {
+	case Float:
+		if m.Index >= len(d.DataF) || int64(len(d.DataF[m.Index])) != d.Length {
+			return nil, false
+		}
+		return d.DataF[m.Index], true
+	case Int:
+		if m.Index >= len(d.DataI) || int64(len(d.DataI[m.Index])) != d.Length {
+			return nil, false
+		}
+		fs := make([]float64, d.Length)
+		for i, v := range d.DataI[m.Index] {
+			fs[i] = float64(v)
+			if !d.Valid(m.Name, i) {
+				fs[i] = math.NaN()
+			}
+		}
+		return fs, true
+	}
+	return nil, false
+}
@@ -0,0 +1,170 @@
+package insights
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+/*
+	This file contains the tests for the group by and the aggregations
+*/
+
+//salesDataset returns a dataset of 6 sales with the region, order date,
+//customer, revenue and units. Region of the last sale and the revenue of
+//the fourth one are missing.
+func salesDataset() Dataset {
+	day := func(m time.Month, d int) time.Time {
+		return time.Date(2019, m, d, 10, 0, 0, 0, time.UTC)
+	}
+	d := NewDataset()
+	d.AddNullableMetric(Metric{Name: "region", DisplayName: "Region",
+		DataType: String}, []string{"north", "south", "north", "north", "south", ""},
+		[]bool{true, true, true, true, true, false})
+	d.AddMetric(Metric{Name: "order_date", DataType: Time},
+		[]time.Time{day(1, 3), day(1, 9), day(1, 20), day(2, 2), day(2, 5), day(2, 7)})
+	d.AddMetric(Metric{Name: "customer", DataType: String},
+		[]string{"a", "b", "a", "c", "b", "d"})
+	d.AddNullableMetric(Metric{Name: "revenue", DisplayName: "Revenue",
+		DataType: Float, Semantic: SemanticMonetary},
+		[]float64{10, 20, 30, 0, 50, 60}, []bool{true, true, true, false, true, true})
+	d.AddMetric(Metric{Name: "units", DataType: Int}, []int64{1, 2, 3, 4, 5, 6})
+	return d
+}
+
+func TestDataset_GroupBy(t *testing.T) {
+	d := salesDataset()
+	res, err := d.GroupBy("region").Aggregate(
+		Aggregation{Func: AggSum, Metric: "revenue"},
+		Aggregation{Func: AggCount},
+		Aggregation{Func: AggCount, Metric: "revenue"},
+		Aggregation{Func: AggDistinct, Metric: "customer", Name: "customers"},
+		Aggregation{Func: AggMean, Metric: "units"},
+		Aggregation{Func: AggMedian, Metric: "revenue"},
+		Aggregation{Func: AggMin, Metric: "units"},
+		Aggregation{Func: AggMax, Metric: "revenue"})
+	if err != nil {
+		t.Fatal("Error while aggregating", err)
+	}
+	if res.Length != 3 || len(res.Metrics) != 9 {
+		t.Fatal("Expected 3 groups with 9 metrics. Got", res.Length, len(res.Metrics))
+	}
+	regions := res.DataS[res.Metrics["region"].Index]
+	if regions[0] != "north" || regions[1] != "south" || res.Valid("region", 2) {
+		t.Fatal("Expected north, south and the missing region. Got", regions)
+	}
+	expected := map[string][]float64{
+		"revenue_sum":    {40, 70, 60},
+		"count":          {3, 2, 1},
+		"revenue_count":  {2, 2, 1},
+		"customers":      {2, 1, 1},
+		"units_mean":     {8.0 / 3, 3.5, 6},
+		"revenue_median": {20, 35, 60},
+		"units_min":      {1, 2, 6},
+		"revenue_max":    {30, 50, 60},
+	}
+	for name, vals := range expected {
+		m, ok := res.Metrics[name]
+		if !ok {
+			t.Fatal("Expected the metric", name, "Got", res.Metrics)
+		}
+		for i, v := range vals {
+			if math.Abs(res.DataF[m.Index][i]-v) > 1e-9 {
+				t.Fatal("Expected", name, vals, "Got", res.DataF[m.Index])
+			}
+		}
+	}
+	if m := res.Metrics["revenue_sum"]; m.Semantic != SemanticMonetary ||
+		m.DisplayName != "Total Revenue" {
+		t.Fatal("Expected the monetary total revenue. Got", m)
+	}
+	if res.Metrics["customers"].Semantic != SemanticCount {
+		t.Fatal("Expected the distinct count to be a count. Got",
+			res.Metrics["customers"])
+	}
+}
+
+func TestGrouping_Per(t *testing.T) {
+	d := salesDataset()
+	res, err := d.GroupBy("region", "order_date").Per(GranularityMonth).
+		Aggregate(Aggregation{Func: AggSum, Metric: "revenue"})
+	if err != nil {
+		t.Fatal("Error while aggregating", err)
+	}
+	//north jan, south jan, north feb, south feb, missing feb
+	if res.Length != 5 {
+		t.Fatal("Expected 5 groups. Got", res.Length)
+	}
+	months := res.DataT[res.Metrics["order_date"].Index]
+	if !months[2].Equal(time.Date(2019, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatal("Expected the start of february. Got", months[2])
+	}
+	sums := res.DataF[res.Metrics["revenue_sum"].Index]
+	if sums[0] != 40 || sums[2] != 0 {
+		t.Fatal("Expected the revenue of 40 for north in january and 0 in",
+			"february. Got", sums)
+	}
+	if !res.Valid("revenue_sum", 2) {
+		t.Fatal("Expected the sum without values to be 0 and not missing")
+	}
+}
+
+func TestGrouping_Per_Unparsed(t *testing.T) {
+	//times that couldn't be parsed are grouped with the missing ones
+	d := NewDataset()
+	d.AddMetric(Metric{Name: "month", DataType: String, Semantic: SemanticTime},
+		[]string{"2019-01-05", "soon", "2019-01-20", ""})
+	res, err := d.GroupBy("month").Per(GranularityMonth).
+		Aggregate(Aggregation{Func: AggCount})
+	if err != nil {
+		t.Fatal("Error while aggregating", err)
+	}
+	if res.Length != 2 || !res.Valid("month", 0) || res.Valid("month", 1) {
+		t.Fatal("Expected january and the missing month. Got",
+			res.DataS[res.Metrics["month"].Index], res.Length)
+	}
+	if counts := res.DataF[res.Metrics["count"].Index]; counts[0] != 2 ||
+		counts[1] != 2 {
+		t.Fatal("Expected 2 records in each group. Got", counts)
+	}
+}
+
+func TestGrouping_Aggregate(t *testing.T) {
+	d := salesDataset()
+	tcs := []struct {
+		Description string
+		Dimensions  []string
+		Aggregation Aggregation
+		Expected    int
+	}{
+		{"Unknown dimension", []string{"city"}, Aggregation{Func: AggCount},
+			ErrCGeneric},
+		{"Unknown metric", []string{"region"}, Aggregation{Func: AggSum,
+			Metric: "profit"}, ErrCGeneric},
+		{"Unknown function", []string{"region"}, Aggregation{Func: "mode",
+			Metric: "revenue"}, ErrCGeneric},
+		{"Sum of strings", []string{"region"}, Aggregation{Func: AggSum,
+			Metric: "customer"}, ErrCDataTypeMismatch},
+		{"Name of a dimension", []string{"region"}, Aggregation{Func: AggCount,
+			Name: "region"}, ErrCSchemaConflict},
+	}
+	for _, v := range tcs {
+		t.Run(v.Description, func(t *testing.T) {
+			_, err := d.GroupBy(v.Dimensions...).Aggregate(v.Aggregation)
+			if e, ok := err.(*Error); !ok || e.Code != v.Expected {
+				t.Fatal("Expected the error code", v.Expected, "Got", err)
+			}
+		})
+	}
+
+	if _, err := d.GroupBy("order_date").Per("hour").Aggregate(
+		Aggregation{Func: AggCount}); err == nil {
+		t.Fatal("Expected an error for the unsupported granularity. Got nil")
+	}
+
+	//without dimensions all the records are in a single group
+	res, err := d.GroupBy().Aggregate(Aggregation{Func: AggMean, Metric: "revenue"})
+	if err != nil || res.Length != 1 || res.DataF[0][0] != 34 {
+		t.Fatal("Expected the mean revenue of 34. Got", res.DataF, err)
+	}
+}
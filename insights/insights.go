@@ -1,6 +1,11 @@
 package insights
 
-import "github.com/cuttle-ai/brain/visualizations"
+import (
+	"math"
+	"strconv"
+
+	"github.com/cuttle-ai/brain/visualizations"
+)
 
 /*
 	This file contains the utilities for generating insights for a dataset
@@ -9,6 +14,37 @@ import "github.com/cuttle-ai/brain/visualizations"
 const (
 	//CORRELATION is the type string of the correlation type of insight
 	CORRELATION = "CORRELATION"
+	//CLUSTERING is the type string of the clustering type of insight
+	CLUSTERING = "CLUSTERING"
+	//FORECAST is the type string of the forecast type of insight
+	FORECAST = "FORECAST"
+	//BENFORD is the type string of the benford's law conformity type of
+	//insight
+	BENFORD = "BENFORD"
+	//DATAQUALITY is the type string of the data quality type of insight
+	DATAQUALITY = "DATAQUALITY"
+	//COHORT is the type string of the cohort retention type of insight
+	COHORT = "COHORT"
+	//FUNNEL is the type string of the funnel conversion type of insight
+	FUNNEL = "FUNNEL"
+	//BASKET is the type string of the market basket type of insight
+	BASKET = "BASKET"
+	//VOLATILITY is the type string of the volatility type of insight
+	VOLATILITY = "VOLATILITY"
+	//RANK is the type string of the rank change type of insight
+	RANK = "RANK"
+	//THRESHOLD is the type string of the threshold type of insight
+	THRESHOLD = "THRESHOLD"
+	//EXTREME is the type string of the extreme value type of insight
+	EXTREME = "EXTREME"
+	//STATIONARITY is the type string of the stationarity type of insight
+	STATIONARITY = "STATIONARITY"
+	//GRANGER is the type string of the granger causality type of insight
+	GRANGER = "GRANGER"
+	//DEPENDENCE is the type string of the non linear dependence type of insight
+	DEPENDENCE = "DEPENDENCE"
+	//RATIO is the type string of the ratio type of insight
+	RATIO = "RATIO"
 )
 
 //Insight is the interface that has to be implemented by the any type of insight
@@ -84,10 +120,15 @@ func GenerateInsights(d Dataset) []Insight {
 }
 
 //Propose will propose the possible insights for a given data set.
-//It uses the domain knowledge for proposing the same.
+//It uses the domain knowledge for proposing the same. The meaningful ratios
+//of the metrics are derived so that the ratio change, forecast and extreme
+//value insights are proposed for them too. The other insights don't see the
+//ratios since their missing values and quotients would be reported as that
+//of the data.
 //This function is WIP and should be used for production purposes.
 func Propose(d Dataset) []ProposedInsight {
 	/*
+		We will derive the ratios of the metrics.
 		We will get the list of the possible insight types.
 		We will simply iterate through the insight types and make decisions
 		based on the same.
@@ -95,12 +136,20 @@ func Propose(d Dataset) []ProposedInsight {
 	//variable to store the comboined insights
 	result := []ProposedInsight{}
 
+	//deriving the ratios
+	rd := d.WithRatios()
+
 	//getting the insight type lists
 	ins := Insights()
 
 	//now iterating through each to produce the proposals
 	for i := range ins {
-		result = append(result, ins[i].Propose(d)...)
+		switch ins[i].(type) {
+		case *Ratio, *Forecast, *Extreme:
+			result = append(result, ins[i].Propose(rd)...)
+		default:
+			result = append(result, ins[i].Propose(d)...)
+		}
 	}
 
 	return result
@@ -110,5 +159,26 @@ func Propose(d Dataset) []ProposedInsight {
 func Insights() []Insight {
 	return []Insight{
 		&Correlation{},
+		&Clustering{},
+		&Forecast{},
+		&Benford{},
+		&DataQuality{},
+		&Cohort{},
+		&Funnel{},
+		&Basket{},
+		&Volatility{},
+		&Rank{},
+		&Threshold{},
+		&Extreme{},
+		&Stationarity{},
+		&Granger{},
+		&Dependence{},
+		&Ratio{},
 	}
 }
+
+//formatFloat formats the float rounded to two decimals for the descriptions
+//of the insights
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64)
+}
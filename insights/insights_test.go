@@ -12,8 +12,8 @@ import (
 
 func TestInsights(t *testing.T) {
 	ins := Insights()
-	if len(ins) != 1 {
-		t.Fatal("Expected to support 1 insight. But got", len(ins))
+	if len(ins) != 16 {
+		t.Fatal("Expected to support 16 insights. But got", len(ins))
 	}
 }
 
@@ -57,6 +57,71 @@ var proposeTCs = []proposeTC{
 				},
 			},
 		},
+		{
+			&Clustering{},
+			[]Metric{
+				{
+					Name:        "Age",
+					DataType:    Float,
+					DisplayName: "Age",
+				},
+				{
+					Name:        "Height",
+					DataType:    Float,
+					DisplayName: "Height",
+				},
+			},
+		},
+		{
+			&Benford{},
+			[]Metric{
+				{
+					Name:        "Age",
+					DataType:    Float,
+					DisplayName: "Age",
+				},
+			},
+		},
+		{
+			&Benford{},
+			[]Metric{
+				{
+					Name:        "Height",
+					DataType:    Float,
+					DisplayName: "Height",
+				},
+			},
+		},
+		{
+			&DataQuality{},
+			[]Metric{
+				{
+					Name:        "Age",
+					DataType:    Float,
+					DisplayName: "Age",
+				},
+				{
+					Name:        "Height",
+					DataType:    Float,
+					DisplayName: "Height",
+				},
+			},
+		},
+		{
+			&Dependence{},
+			[]Metric{
+				{
+					Name:        "Age",
+					DataType:    Float,
+					DisplayName: "Age",
+				},
+				{
+					Name:        "Height",
+					DataType:    Float,
+					DisplayName: "Height",
+				},
+			},
+		},
 	}},
 }
 
@@ -0,0 +1,260 @@
+package insights
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+/*
+	This file contains the utilities for loading the datasets from JSON records
+*/
+
+//ReadJSON reads the JSON records into a dataset. The input can be an array
+//of objects or a stream of objects like newline delimited JSON. The records
+//are converted as done by FromRecords. It returns an error if the JSON
+//couldn't be read, a record is not an object or the records conflict in
+//their schema.
+func ReadJSON(r io.Reader) (Dataset, error) {
+	/*
+		We will decode the values in the input one after the other.
+		Arrays are taken as the lists of records and objects as the records.
+		Then we convert the records into the dataset.
+	*/
+	records := []map[string]interface{}{}
+	dec := json.NewDecoder(r)
+	for {
+		var v interface{}
+		err := dec.Decode(&v)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return NewDataset(), &Error{ErrMReadJSON + err.Error(), ErrCReadFailed}
+		}
+		vals, ok := v.([]interface{})
+		if !ok {
+			vals = []interface{}{v}
+		}
+		for _, val := range vals {
+			rec, ok := val.(map[string]interface{})
+			if !ok {
+				return NewDataset(), &Error{ErrMJSONRecord +
+					strconv.Itoa(len(records)+1), ErrCReadFailed}
+			}
+			records = append(records, rec)
+		}
+	}
+	return FromRecords(records)
+}
+
+//FromRecords converts the records into a dataset. Records are of the same
+//shape as the data of the visualizations where the values are mapped to the
+//names of the metrics. Nested objects are flattened into the metrics named
+//with dots like user.age. Arrays are stored as their JSON text. Keys not
+//present in a record or having null are the missing values of the record.
+//Data type of each metric is Float for the numbers, Bool for the bools and
+//Time for the times. Strings are Time if all of them can be parsed as time.
+//Else they are String. Metrics without any values are String. It returns an
+//error if a metric has values of different types, has both the values and
+//the nested fields or a key having dots names the same metric as a nested
+//field in a record.
+func FromRecords(records []map[string]interface{}) (Dataset, error) {
+	/*
+		We will flatten the records and collect the names of the metrics.
+		Then we check whether a name is used for both a value and an object.
+		Then for each metric, we infer the data type from its values.
+		Then we convert the values and add the metric to the dataset.
+	*/
+	d := NewDataset()
+
+	//flattening the records
+	flat := make([]map[string]interface{}, len(records))
+	seen := map[string]bool{}
+	for i, rec := range records {
+		flat[i] = map[string]interface{}{}
+		if err := flatten("", rec, flat[i]); err != nil {
+			return d, err
+		}
+		for k := range flat[i] {
+			seen[k] = true
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for k := range seen {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	//checking for the names used as objects too
+	for _, name := range names {
+		for i, c := range name {
+			if c == '.' && seen[name[:i]] {
+				return d, &Error{ErrMSchemaConflict + name[:i] +
+					". It has both values and nested fields", ErrCSchemaConflict}
+			}
+		}
+	}
+
+	//adding the metrics
+	for _, name := range names {
+		vals := make([]interface{}, len(flat))
+		valid := make([]bool, len(flat))
+		for i, rec := range flat {
+			vals[i] = rec[name]
+			valid[i] = vals[i] != nil
+		}
+		dt, err := recordsType(name, vals)
+		if err != nil {
+			return d, err
+		}
+		if err := d.AddNullableMetric(Metric{Name: name, DataType: dt},
+			recordsColumn(vals, dt), valid); err != nil {
+			return d, err
+		}
+	}
+	return d, nil
+}
+
+//flatten adds the values of the object to the flat record. Fields of the
+//nested objects are named with the prefix of their parents. It returns an
+//error if two fields get the same name like the key a.b and the field b of
+//the nested object a.
+func flatten(prefix string, obj map[string]interface{}, flat map[string]interface{}) error {
+	for k, v := range obj {
+		name := prefix + k
+		if val, ok := v.(map[string]interface{}); ok {
+			if err := flatten(name+".", val, flat); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, ok := flat[name]; ok {
+			return &Error{ErrMSchemaConflict + name +
+				". It is both a key and a nested field", ErrCSchemaConflict}
+		}
+		if val, ok := v.([]interface{}); ok {
+			b, _ := json.Marshal(val)
+			flat[name] = string(b)
+			continue
+		}
+		flat[name] = v
+	}
+	return nil
+}
+
+//recordKind returns the data type of a value in the records. Strings are
+//returned as String. It returns false if the value is not of a supported
+//type.
+func recordKind(v interface{}) (string, bool) {
+	switch v.(type) {
+	case float64, float32, int, int32, int64, uint, uint32, uint64, json.Number:
+		return Float, true
+	case bool:
+		return Bool, true
+	case time.Time:
+		return Time, true
+	case string:
+		return String, true
+	}
+	return "", false
+}
+
+//recordsType returns the data type of the metric having the values. It
+//returns a schema conflict error if the values are of different types.
+func recordsType(name string, vals []interface{}) (string, error) {
+	dt := ""
+	for _, v := range vals {
+		if v == nil {
+			continue
+		}
+		k, ok := recordKind(v)
+		if !ok {
+			return "", &Error{ErrMDAddMetricUnsupportedType + name,
+				ErrCUnsupportedDataType}
+		}
+		if len(dt) > 0 && k != dt {
+			return "", &Error{ErrMSchemaConflict + name + ". It has both " +
+				dt + " and " + k + " values", ErrCSchemaConflict}
+		}
+		dt = k
+	}
+	if dt != String {
+		if len(dt) == 0 {
+			return String, nil
+		}
+		return dt, nil
+	}
+	for _, v := range vals {
+		if v == nil {
+			continue
+		}
+		if _, _, ok := parseTime(v.(string)); !ok {
+			return String, nil
+		}
+	}
+	return Time, nil
+}
+
+//recordsColumn returns the data array of the values for the data type.
+//Missing values are left as the zero values.
+func recordsColumn(vals []interface{}, dt string) interface{} {
+	switch dt {
+	case Float:
+		fs := make([]float64, len(vals))
+		for i, v := range vals {
+			fs[i] = toFloat(v)
+		}
+		return fs
+	case Bool:
+		bs := make([]bool, len(vals))
+		for i, v := range vals {
+			bs[i], _ = v.(bool)
+		}
+		return bs
+	case Time:
+		ts := make([]time.Time, len(vals))
+		for i, v := range vals {
+			switch t := v.(type) {
+			case time.Time:
+				ts[i] = t
+			case string:
+				ts[i], _, _ = parseTime(t)
+			}
+		}
+		return ts
+	}
+	ss := make([]string, len(vals))
+	for i, v := range vals {
+		ss[i], _ = v.(string)
+	}
+	return ss
+}
+
+//toFloat returns the number as a float. Values that are not numbers are 0.
+func toFloat(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case float32:
+		return float64(n)
+	case int:
+		return float64(n)
+	case int32:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case uint:
+		return float64(n)
+	case uint32:
+		return float64(n)
+	case uint64:
+		return float64(n)
+	case json.Number:
+		f, _ := n.Float64()
+		return f
+	}
+	return 0
+}
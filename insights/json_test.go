@@ -0,0 +1,108 @@
+package insights
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+/*
+	This file contains the tests for the JSON loader
+*/
+
+func TestReadJSON(t *testing.T) {
+	in := `[{"city": "Kochi", "sales": 12.5, "paid": true,
+	"user": {"age": 31, "joined": "2019-01-02"}, "tags": ["a", "b"]},
+	{"city": "Pune", "sales": null, "paid": false, "user": {"age": 40}}]`
+	d, err := ReadJSON(strings.NewReader(in))
+	if err != nil {
+		t.Fatal("Error while reading the JSON", err)
+	}
+	if d.Length != 2 || len(d.Metrics) != 6 {
+		t.Fatal("Expected 2 records of 6 metrics. Got", d.Length, len(d.Metrics))
+	}
+	types := map[string]string{"city": String, "sales": Float, "paid": Bool,
+		"user.age": Float, "user.joined": Time, "tags": String}
+	for name, dt := range types {
+		if m, ok := d.Metrics[name]; !ok || m.DataType != dt {
+			t.Fatal("Expected", name, "of type", dt, "Got", m, ok)
+		}
+	}
+	if s := d.DataF[d.Metrics["sales"].Index]; s[0] != 12.5 || !math.IsNaN(s[1]) {
+		t.Fatal("Expected the sales [12.5 NaN]. Got", s)
+	}
+	if tags := d.DataS[d.Metrics["tags"].Index]; tags[0] != `["a","b"]` {
+		t.Fatal("Expected the tags as JSON text. Got", tags)
+	}
+	if d.NullCount("user.joined") != 1 || d.NullCount("tags") != 1 {
+		t.Fatal("Expected the absent keys to be missing. Got",
+			d.NullCount("user.joined"), d.NullCount("tags"))
+	}
+}
+
+type readJSONTC struct {
+	ID          string
+	Description string
+	Input       string
+	Length      int64
+	Expected    int
+}
+
+var readJSONTCs = []readJSONTC{
+	{"1", "Newline delimited records", "{\"a\": 1}\n{\"a\": 2}\n{\"a\": 3}\n",
+		3, -1},
+	{"2", "Empty input", "", 0, -1},
+	{"3", "Malformed JSON", `[{"a": 1}`, 0, ErrCReadFailed},
+	{"4", "Record not an object", `[1, 2]`, 0, ErrCReadFailed},
+	{"5", "Number and string in a metric", `[{"a": 1}, {"a": "x"}]`, 0,
+		ErrCSchemaConflict},
+	{"6", "Value and object in a metric", `[{"a": 1}, {"a": {"b": 2}}]`, 0,
+		ErrCSchemaConflict},
+	{"7", "Key with dots and nested field of the same name",
+		`[{"a.b": 1, "a": {"b": 2}}]`, 0, ErrCSchemaConflict},
+}
+
+func TestReadJSON_Records(t *testing.T) {
+	for _, v := range readJSONTCs {
+		t.Run(v.ID, func(t *testing.T) {
+			d, err := ReadJSON(strings.NewReader(v.Input))
+			if v.Expected >= 0 {
+				e, ok := err.(*Error)
+				if !ok || e.Code != v.Expected {
+					t.Fatal("Expected error code", v.Expected, "Got", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("Error while reading the JSON", err)
+			}
+			if d.Length != v.Length {
+				t.Fatal("Expected", v.Length, "records. Got", d.Length)
+			}
+		})
+	}
+}
+
+func TestFromRecords(t *testing.T) {
+	//data of a visualization should convert back to a dataset
+	d := dependenceDataset(true)
+	de := &Dependence{dt: d, ms: []Metric{d.Metrics["temp"], d.Metrics["usage"]}}
+	de.FSFA()
+	de.Generate()
+	data := de.Visual().Data()
+	rd, err := FromRecords(data)
+	if err != nil {
+		t.Fatal("Error while converting the records", err)
+	}
+	if rd.Length != int64(len(data)) || len(rd.Metrics) != 3 {
+		t.Fatal("Expected", len(data), "records of 3 metrics. Got", rd.Length,
+			len(rd.Metrics))
+	}
+	if rd.NullCount("usage") != len(data)-int(d.Length) {
+		t.Fatal("Expected the points of the curve to miss the usage. Got",
+			rd.NullCount("usage"))
+	}
+	if _, err := FromRecords([]map[string]interface{}{{"a": []int{1}}}); err == nil {
+		t.Fatal("Expected an error for unsupported values. Got nil")
+	}
+}
@@ -0,0 +1,342 @@
+//go:build parquet
+// +build parquet
+
+package insights
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/schema"
+	"github.com/xitongsys/parquet-go/source"
+)
+
+/*
+	This file contains the utilities for loading the datasets from parquet
+	files. It is built only with the parquet build tag so that the insights
+	don't depend on parquet unless required.
+*/
+
+//ParquetOptions are the options for reading a parquet file
+type ParquetOptions struct {
+	//Columns are the names of the columns to be read. Columns of the nested
+	//fields are named with dots like user.age. All the columns are read if
+	//it is empty.
+	Columns []string
+	//Parallel is the no. of goroutines used for reading the file. It is 1
+	//if not set.
+	Parallel int64
+}
+
+//ParquetReader reads the row groups of a parquet file as datasets so that
+//the large files can be processed without loading them fully
+type ParquetReader struct {
+	//pr is the underlying column reader
+	pr *reader.ParquetReader
+	//cols are the columns to be read
+	cols []parquetColumn
+	//group is the index of the next row group to be read
+	group int
+}
+
+//parquetColumn is a leaf column of the parquet schema read as a metric
+type parquetColumn struct {
+	//path is the path of the column in the parquet schema
+	path string
+	//metric is the metric of the column
+	metric Metric
+	//el is the schema element of the column
+	el *parquet.SchemaElement
+}
+
+//NewParquetReader returns a reader for the parquet file. Only the columns
+//given in the options are read. Data types of the metrics are mapped from
+//the logical types of the columns. Dates and timestamps are Time, decimals
+//and the other numbers are Float, int64 without a logical type is Int,
+//booleans are Bool and the rest are String. It returns an error if the
+//file couldn't be read or a given column doesn't exist or is repeated.
+func NewParquetReader(pf source.ParquetFile, opts ParquetOptions) (*ParquetReader, error) {
+	/*
+		We will open the column reader of the file.
+		Then we find the leaf columns in the schema along with their names.
+		Then we keep the columns asked in the options.
+	*/
+	np := opts.Parallel
+	if np <= 0 {
+		np = 1
+	}
+	pr, err := reader.NewParquetColumnReader(pf, np)
+	if err != nil {
+		return nil, &Error{ErrMReadParquet + err.Error(), ErrCReadFailed}
+	}
+	all := parquetColumns(pr.SchemaHandler)
+	if len(opts.Columns) == 0 {
+		cols := []parquetColumn{}
+		for _, c := range all {
+			if !parquetRepeated(c.el) {
+				cols = append(cols, c)
+			}
+		}
+		return &ParquetReader{pr: pr, cols: cols}, nil
+	}
+	byName := map[string]parquetColumn{}
+	for _, c := range all {
+		byName[c.metric.Name] = c
+	}
+	cols := make([]parquetColumn, len(opts.Columns))
+	for i, name := range opts.Columns {
+		c, ok := byName[name]
+		if !ok || parquetRepeated(c.el) {
+			pr.ReadStop()
+			return nil, &Error{ErrMParquetColumn + name, ErrCReadFailed}
+		}
+		cols[i] = c
+	}
+	return &ParquetReader{pr: pr, cols: cols}, nil
+}
+
+//NumRowGroups returns the no. of row groups in the file
+func (p *ParquetReader) NumRowGroups() int {
+	return len(p.pr.Footer.RowGroups)
+}
+
+//Next reads the next row group as a dataset. It returns io.EOF if all the
+//row groups are read.
+func (p *ParquetReader) Next() (Dataset, error) {
+	if p.group >= p.NumRowGroups() {
+		return NewDataset(), io.EOF
+	}
+	n := p.pr.Footer.RowGroups[p.group].NumRows
+	p.group++
+	return p.read(n)
+}
+
+//Close stops reading the file. The file has to be closed by the caller.
+func (p *ParquetReader) Close() {
+	p.pr.ReadStop()
+}
+
+//ReadParquet reads all the rows of the parquet file into a dataset. The
+//columns are read as done by NewParquetReader.
+func ReadParquet(pf source.ParquetFile, opts ParquetOptions) (Dataset, error) {
+	p, err := NewParquetReader(pf, opts)
+	if err != nil {
+		return NewDataset(), err
+	}
+	defer p.Close()
+	return p.read(p.pr.GetNumRows())
+}
+
+//read reads the next n rows of the columns into a dataset
+func (p *ParquetReader) read(n int64) (Dataset, error) {
+	d := NewDataset()
+	for _, c := range p.cols {
+		vals, _, _, err := p.pr.ReadColumnByPath(c.path, n)
+		if err != nil {
+			return d, &Error{ErrMReadParquet + err.Error(), ErrCReadFailed}
+		}
+		if err := d.AddNullableMetric(c.metric, parquetData(c, vals),
+			parquetValid(vals)); err != nil {
+			return d, err
+		}
+	}
+	return d, nil
+}
+
+//parquetColumns returns the leaf columns in the schema. Names of the metrics
+//are the names of the elements in the file from the root joined by dots.
+//Paths are taken from the index map of the schema.
+func parquetColumns(sh *schema.SchemaHandler) []parquetColumn {
+	/*
+		Elements are stored in depth first order with the no. of children
+		of the groups. We will walk them keeping the stack of the groups
+		and the no. of their children left to be visited.
+	*/
+	cols := []parquetColumn{}
+	names := []string{}
+	left := []int32{}
+	for i, el := range sh.SchemaElements {
+		//leaving the groups whose children are visited
+		for len(left) > 0 && left[len(left)-1] == 0 {
+			names, left = names[:len(names)-1], left[:len(left)-1]
+		}
+		if len(left) > 0 {
+			left[len(left)-1]--
+		}
+		if el.IsSetNumChildren() && el.GetNumChildren() > 0 {
+			//root is not a part of the names
+			if i > 0 {
+				names = append(names, sh.GetExName(i))
+			} else {
+				names = append(names, "")
+			}
+			left = append(left, el.GetNumChildren())
+			continue
+		}
+		name := strings.TrimPrefix(strings.Join(append(names, sh.GetExName(i)), "."), ".")
+		cols = append(cols, parquetColumn{path: sh.IndexMap[int32(i)],
+			metric: Metric{Name: name, DataType: parquetType(el)}, el: el})
+	}
+	return cols
+}
+
+//parquetRepeated returns whether the column is repeated. Repeated columns
+//don't have a value per row and are not read.
+func parquetRepeated(el *parquet.SchemaElement) bool {
+	return el.IsSetRepetitionType() &&
+		el.GetRepetitionType() == parquet.FieldRepetitionType_REPEATED
+}
+
+//parquetType returns the data type of the metric for the schema element
+func parquetType(el *parquet.SchemaElement) string {
+	lt := el.GetLogicalType()
+	switch {
+	case lt != nil && (lt.IsSetDATE() || lt.IsSetTIMESTAMP()):
+		return Time
+	case lt != nil && lt.IsSetDECIMAL():
+		return Float
+	case lt != nil && lt.IsSetSTRING():
+		return String
+	}
+	if el.IsSetConvertedType() {
+		switch el.GetConvertedType() {
+		case parquet.ConvertedType_DATE, parquet.ConvertedType_TIMESTAMP_MILLIS,
+			parquet.ConvertedType_TIMESTAMP_MICROS:
+			return Time
+		case parquet.ConvertedType_DECIMAL:
+			return Float
+		case parquet.ConvertedType_UTF8, parquet.ConvertedType_ENUM,
+			parquet.ConvertedType_JSON:
+			return String
+		}
+	}
+	switch el.GetType() {
+	case parquet.Type_BOOLEAN:
+		return Bool
+	case parquet.Type_INT32, parquet.Type_FLOAT, parquet.Type_DOUBLE:
+		return Float
+	case parquet.Type_INT64:
+		if el.IsSetConvertedType() || lt != nil {
+			return Float
+		}
+		return Int
+	case parquet.Type_INT96:
+		return Time
+	}
+	return String
+}
+
+//parquetValid returns the validities of the values where nils are invalid
+func parquetValid(vals []interface{}) []bool {
+	valid := make([]bool, len(vals))
+	for i, v := range vals {
+		valid[i] = v != nil
+	}
+	return valid
+}
+
+//parquetData returns the data array of the column having the values
+func parquetData(c parquetColumn, vals []interface{}) interface{} {
+	n := len(vals)
+	switch c.metric.DataType {
+	case Float:
+		fs := make([]float64, n)
+		for i, v := range vals {
+			fs[i] = parquetFloat(c.el, v)
+		}
+		return fs
+	case Int:
+		is := make([]int64, n)
+		for i, v := range vals {
+			is[i], _ = v.(int64)
+		}
+		return is
+	case Bool:
+		bs := make([]bool, n)
+		for i, v := range vals {
+			bs[i], _ = v.(bool)
+		}
+		return bs
+	case Time:
+		ts := make([]time.Time, n)
+		for i, v := range vals {
+			if v != nil {
+				ts[i] = parquetTime(c.el, v)
+			}
+		}
+		return ts
+	}
+	ss := make([]string, n)
+	for i, v := range vals {
+		ss[i], _ = v.(string)
+	}
+	return ss
+}
+
+//parquetFloat returns the number as a float. Decimals are scaled by their
+//scale. Decimals stored as bytes are read as big endian integers.
+func parquetFloat(el *parquet.SchemaElement, v interface{}) float64 {
+	var f float64
+	switch n := v.(type) {
+	case int32:
+		f = float64(n)
+	case int64:
+		f = float64(n)
+	case float32:
+		f = float64(n)
+	case float64:
+		f = n
+	case string:
+		//two's complement big endian integer of the decimal
+		i := new(big.Int).SetBytes([]byte(n))
+		if len(n) > 0 && n[0]&0x80 != 0 {
+			i.Sub(i, new(big.Int).Lsh(big.NewInt(1), uint(8*len(n))))
+		}
+		f, _ = new(big.Float).SetInt(i).Float64()
+	default:
+		return 0
+	}
+	if el.IsSetScale() && el.GetScale() > 0 {
+		f /= math.Pow10(int(el.GetScale()))
+	}
+	return f
+}
+
+//parquetTime returns the time of the date or the timestamp in the column
+func parquetTime(el *parquet.SchemaElement, v interface{}) time.Time {
+	switch t := v.(type) {
+	case int32:
+		//dates are the no. of days since the epoch
+		return time.Unix(int64(t)*86400, 0).UTC()
+	case int64:
+		unit := time.Millisecond
+		lt := el.GetLogicalType()
+		switch {
+		case lt != nil && lt.IsSetTIMESTAMP() && lt.TIMESTAMP.Unit.IsSetMICROS():
+			unit = time.Microsecond
+		case lt != nil && lt.IsSetTIMESTAMP() && lt.TIMESTAMP.Unit.IsSetNANOS():
+			unit = time.Nanosecond
+		case el.IsSetConvertedType() &&
+			el.GetConvertedType() == parquet.ConvertedType_TIMESTAMP_MICROS:
+			unit = time.Microsecond
+		}
+		return time.Unix(0, t*int64(unit)).UTC()
+	case string:
+		//int96 timestamps are the nanoseconds of the day followed by the
+		//julian day
+		if len(t) != 12 {
+			return time.Time{}
+		}
+		nanos := int64(binary.LittleEndian.Uint64([]byte(t[:8])))
+		day := int64(binary.LittleEndian.Uint32([]byte(t[8:])))
+		//julian day of the epoch is 2440588
+		return time.Unix((day-2440588)*86400, nanos).UTC()
+	}
+	return time.Time{}
+}
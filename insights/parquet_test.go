@@ -0,0 +1,133 @@
+//go:build parquet
+// +build parquet
+
+package insights
+
+import (
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+/*
+	This file contains the tests for the parquet reader
+*/
+
+//parquetSale is a row of the sales parquet file
+type parquetSale struct {
+	City   *string `parquet:"name=city, type=UTF8, repetitiontype=OPTIONAL"`
+	Amount float64 `parquet:"name=amount, type=DOUBLE"`
+	Units  int64   `parquet:"name=units, type=INT64"`
+	Day    int32   `parquet:"name=day, type=DATE"`
+	Paid   bool    `parquet:"name=paid, type=BOOLEAN"`
+}
+
+//writeSales writes the sales parquet file with two row groups of 2 and 1
+//rows. City of the last row is missing.
+func writeSales(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "brain")
+	if err != nil {
+		t.Fatal("Error while creating the directory", err)
+	}
+	name := filepath.Join(dir, "sales.parquet")
+	fw, err := local.NewLocalFileWriter(name)
+	if err != nil {
+		t.Fatal("Error while creating the file", err)
+	}
+	defer fw.Close()
+	pw, err := writer.NewParquetWriter(fw, new(parquetSale), 1)
+	if err != nil {
+		t.Fatal("Error while creating the writer", err)
+	}
+	kochi, pune := "Kochi", "Pune"
+	sales := []parquetSale{{&kochi, 1.5, 3, 17897, true},
+		{&pune, 2.5, 4, 17898, false}, {nil, 3.5, 5, 17899, true}}
+	for i, s := range sales {
+		if err := pw.Write(s); err != nil {
+			t.Fatal("Error while writing the row", err)
+		}
+		if i == 1 {
+			pw.Flush(true)
+		}
+	}
+	if err := pw.WriteStop(); err != nil {
+		t.Fatal("Error while writing the file", err)
+	}
+	return name
+}
+
+func TestReadParquet(t *testing.T) {
+	name := writeSales(t)
+	defer os.RemoveAll(filepath.Dir(name))
+	fr, err := local.NewLocalFileReader(name)
+	if err != nil {
+		t.Fatal("Error while opening the file", err)
+	}
+	defer fr.Close()
+	d, err := ReadParquet(fr, ParquetOptions{})
+	if err != nil {
+		t.Fatal("Error while reading the file", err)
+	}
+	if d.Length != 3 || len(d.Metrics) != 5 {
+		t.Fatal("Expected 3 records of 5 metrics. Got", d.Length, len(d.Metrics))
+	}
+	types := map[string]string{"city": String, "amount": Float, "units": Int,
+		"day": Time, "paid": Bool}
+	for name, dt := range types {
+		if m, ok := d.Metrics[name]; !ok || m.DataType != dt {
+			t.Fatal("Expected", name, "of type", dt, "Got", m, ok)
+		}
+	}
+	if day := d.DataT[d.Metrics["day"].Index][0]; day.Format("2006-01-02") != "2019-01-01" {
+		t.Fatal("Expected the day 2019-01-01. Got", day)
+	}
+	if d.NullCount("city") != 1 {
+		t.Fatal("Expected 1 missing city. Got", d.NullCount("city"))
+	}
+}
+
+func TestParquetReader_Next(t *testing.T) {
+	name := writeSales(t)
+	defer os.RemoveAll(filepath.Dir(name))
+	fr, _ := local.NewLocalFileReader(name)
+	defer fr.Close()
+	if _, err := NewParquetReader(fr, ParquetOptions{Columns: []string{"price"}}); err == nil {
+		t.Fatal("Expected an error for an unknown column. Got nil")
+	}
+	p, err := NewParquetReader(fr, ParquetOptions{Columns: []string{"amount"}})
+	if err != nil {
+		t.Fatal("Error while opening the reader", err)
+	}
+	defer p.Close()
+	if p.NumRowGroups() != 2 {
+		t.Fatal("Expected 2 row groups. Got", p.NumRowGroups())
+	}
+	lengths := []int64{}
+	total := 0.0
+	for {
+		d, err := p.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal("Error while reading the row group", err)
+		}
+		if len(d.Metrics) != 1 {
+			t.Fatal("Expected only the amount. Got", d.Metrics)
+		}
+		lengths = append(lengths, d.Length)
+		for _, v := range d.DataF[0] {
+			total += v
+		}
+	}
+	if len(lengths) != 2 || lengths[0] != 2 || math.Abs(total-7.5) > 1e-9 {
+		t.Fatal("Expected the row groups of 2 and 1 rows with total 7.5. Got",
+			lengths, total)
+	}
+}
@@ -0,0 +1,415 @@
+package insights
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cuttle-ai/brain/visualizations"
+)
+
+/*
+	This file contains the utilities and structs required for data quality
+	insights
+*/
+
+const (
+	//maxMissingRatio is the ratio of missing values in a metric above which
+	//it is reported
+	maxMissingRatio = 0.2
+	//nearConstantRatio is the ratio of the most frequent value of a metric
+	//above which the metric is said to be near constant
+	nearConstantRatio = 0.95
+	//minDuplicateRatio is the ratio of duplicate records in the dataset
+	//above which the duplicates are reported
+	minDuplicateRatio = 0.01
+	//minFormatRatio is the minimum ratio of the values of a string metric in a
+	//format for the format to be considered while checking for mixed formats
+	minFormatRatio = 0.05
+	//minQualityRecords is the minimum no. of values in a metric required for
+	//checking whether it is near constant or has mixed formats
+	minQualityRecords = 10
+)
+
+const (
+	//IssueMissing is the data quality issue of a metric having high ratio of
+	//missing values
+	IssueMissing = "missing values"
+	//IssueConstant is the data quality issue of a metric being near constant
+	IssueConstant = "near constant"
+	//IssueDuplicate is the data quality issue of the dataset having duplicate
+	//records
+	IssueDuplicate = "duplicate records"
+	//IssueMixedFormat is the data quality issue of a string metric having
+	//values in different formats
+	IssueMixedFormat = "mixed formats"
+	//IssueSentinel is the data quality issue of a metric having sentinel
+	//values like -999 used in place of missing values
+	IssueSentinel = "sentinel values"
+)
+
+//sentinelFloats are the float values often used in place of missing values
+var sentinelFloats = []float64{-999, -9999, -99999, 9999, 99999, 999999}
+
+//sentinelStrings are the string values often used in place of missing values
+var sentinelStrings = map[string]bool{
+	"n/a": true, "na": true, "#n/a": true, "null": true, "nil": true,
+	"none": true, "-": true, "?": true, "unknown": true,
+}
+
+//QualityIssue is a data quality issue found in the dataset
+type QualityIssue struct {
+	//Metric is the name of the metric having the issue. It is empty for
+	//issues of the dataset like duplicate records.
+	Metric string
+	Issue  string  //Issue is the type of the issue like IssueMissing
+	Ratio  float64 //Ratio is the ratio of the records affected by the issue
+	Detail string  //Detail describes the issue
+}
+
+//DataQuality is the data quality insight.
+//It reports the issues in the data like missing values, near constant
+//metrics, duplicate records, mixed formats and sentinel values.
+type DataQuality struct {
+	//visual has the visualization to be used for showing the issues.
+	//Table of the issues is used.
+	visual visualizations.Visual
+	//relevant stores the information whether the insight is relevant or not.
+	//This property is updated after running methods like FSFA and Generate
+	relevant bool
+	dt       Dataset        //dt is the dataset to be checked
+	ms       []Metric       //ms is the list of metrics to be checked
+	issues   []QualityIssue //issues has the issues found in the dataset
+}
+
+//New returns a new instance of the DataQuality with
+//initializations done for the given dataset
+func (q *DataQuality) New(d Dataset, ms []Metric) Insight {
+	return &DataQuality{dt: d, ms: ms}
+}
+
+//Visual returns the visualization to be used for visualizing the issues
+func (q *DataQuality) Visual() visualizations.Visual {
+	return q.visual
+}
+
+//Type returns the type string for the data quality type of insight
+func (q *DataQuality) Type() string {
+	return DATAQUALITY
+}
+
+//Relevant returns whether the insight is relevant or not for the given dataset.
+func (q *DataQuality) Relevant() bool {
+	return q.relevant
+}
+
+//Issues returns the data quality issues found in the dataset
+func (q *DataQuality) Issues() []QualityIssue {
+	return q.issues
+}
+
+//FSFA does the fast statistical feasibilty analysis over the dataset
+//with the given metrics whether the quality can be checked.
+//It requires atleast one metric and one record.
+func (q *DataQuality) FSFA() {
+	q.relevant = len(q.ms) > 0 && q.dt.Length > 0
+}
+
+//Generate generates the data quality insight for the datatset associated with
+//it for the provided metrics. The insight is relevant if any issue is found.
+//This method can only be run after running the FSFA.
+//Else the insight won't be generated
+func (q *DataQuality) Generate() {
+	/*
+		If the insight is not relevant we won't event bother
+		to go forward.
+		We will check each metric for the issues based on its data type.
+		Then we check the dataset for the duplicate records.
+		If there are any issues we will create the visual.
+	*/
+	//Checking whether the existing relevance of the insight
+	if !q.relevant {
+		return
+	}
+
+	//checking the metrics
+	q.issues = []QualityIssue{}
+	for _, m := range q.ms {
+		switch m.DataType {
+		case Float:
+			if m.Index < len(q.dt.DataF) {
+				q.issues = append(q.issues, floatIssues(m, q.dt.DataF[m.Index])...)
+			}
+		case String:
+			if m.Index < len(q.dt.DataS) {
+				q.issues = append(q.issues, stringIssues(m, q.dt.DataS[m.Index])...)
+			}
+		default:
+			//only the missing values are checked for the other data types
+			missing := q.dt.NullCount(m.Name)
+			if ratio := float64(missing) / float64(q.dt.Length); ratio > maxMissingRatio {
+				q.issues = append(q.issues, QualityIssue{m.Name, IssueMissing,
+					ratio, strconv.Itoa(missing) + " values are missing"})
+			}
+		}
+	}
+
+	//checking the duplicate records
+	if dups := q.duplicates(); dups > 0 {
+		ratio := float64(dups) / float64(q.dt.Length)
+		if ratio >= minDuplicateRatio {
+			q.issues = append(q.issues, QualityIssue{Issue: IssueDuplicate,
+				Ratio: ratio, Detail: strconv.Itoa(dups) +
+					" records are duplicates of other records"})
+		}
+	}
+
+	if len(q.issues) == 0 {
+		q.relevant = false
+		return
+	}
+	q.relevant = true
+	q.visual = q.table()
+}
+
+//duplicates returns the no. of records which are duplicates of a record
+//occurring before them in the dataset
+func (q *DataQuality) duplicates() int {
+	seen := map[string]bool{}
+	dups := 0
+	cols := [][]string{}
+	for _, m := range q.ms {
+		if ks, ok := keys(q.dt, m); ok {
+			cols = append(cols, ks)
+		}
+	}
+	for i := 0; i < int(q.dt.Length); i++ {
+		parts := make([]string, 0, len(cols))
+		for _, ks := range cols {
+			parts = append(parts, ks[i])
+		}
+		key := strings.Join(parts, "\x00")
+		if seen[key] {
+			dups++
+		}
+		seen[key] = true
+	}
+	return dups
+}
+
+//table returns the table of the issues
+func (q *DataQuality) table() visualizations.Table {
+	visual := visualizations.Table{
+		T: strconv.Itoa(len(q.issues)) + " data quality issues found",
+		D: "Issues in the data can affect the other insights",
+		M: []visualizations.Metric{
+			{Name: "metric", DisplayName: "Metric", DataType: String,
+				Dimension: 0},
+			{Name: "issue", DisplayName: "Issue", DataType: String,
+				Dimension: 1},
+			{Name: "ratio", DisplayName: "Affected records", DataType: Float,
+				Dimension: 2, PostplacementUnit: "%"},
+			{Name: "detail", DisplayName: "Detail", DataType: String,
+				Dimension: 3},
+		},
+	}
+	data := make([]map[string]interface{}, len(q.issues))
+	for i, is := range q.issues {
+		name := is.Metric
+		if m, ok := q.dt.Metrics[is.Metric]; ok {
+			name = displayName(m)
+		}
+		data[i] = map[string]interface{}{
+			"metric": name,
+			"issue":  is.Issue,
+			"ratio":  math.Round(is.Ratio*10000) / 100,
+			"detail": is.Detail,
+		}
+	}
+	visual.Dt = data
+	return visual
+}
+
+//Propose suggests the possible insights from the domain knowledge.
+//All the metrics in the dataset are proposed to be checked for the quality.
+func (q *DataQuality) Propose(d Dataset) []ProposedInsight {
+	ms := sortedMetrics(d)
+	if len(ms) == 0 {
+		return []ProposedInsight{}
+	}
+	return []ProposedInsight{{q.New(d, ms), ms}}
+}
+
+//floatIssues returns the data quality issues of a float metric
+func floatIssues(m Metric, data []float64) []QualityIssue {
+	/*
+		NaN values are the missing values.
+		Then we find the frequencies of the values for checking whether the
+		metric is near constant.
+		Sentinel values are reported if they are outside the range of the
+		other values of the metric.
+	*/
+	issues := []QualityIssue{}
+	if len(data) == 0 {
+		return issues
+	}
+	n := float64(len(data))
+
+	//finding the missing values and the frequencies
+	missing := 0
+	freq := map[float64]int{}
+	for _, v := range data {
+		if math.IsNaN(v) {
+			missing++
+			continue
+		}
+		freq[v]++
+	}
+	if ratio := float64(missing) / n; ratio > maxMissingRatio {
+		issues = append(issues, QualityIssue{m.Name, IssueMissing, ratio,
+			strconv.Itoa(missing) + " values are missing"})
+	}
+
+	//checking whether the metric is near constant
+	top, topV := 0, 0.0
+	for v, c := range freq {
+		if c > top || (c == top && v < topV) {
+			top, topV = c, v
+		}
+	}
+	present := len(data) - missing
+	if present >= minQualityRecords {
+		if ratio := float64(top) / float64(present); ratio >= nearConstantRatio {
+			issues = append(issues, QualityIssue{m.Name, IssueConstant, ratio,
+				"most of the values are " + formatFloat(topV)})
+		}
+	}
+
+	//checking the sentinel values
+	sentinels := map[float64]bool{}
+	for _, s := range sentinelFloats {
+		sentinels[s] = true
+	}
+	min, max := math.Inf(1), math.Inf(-1)
+	for v := range freq {
+		if sentinels[v] {
+			continue
+		}
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+	}
+	if math.IsInf(min, 1) {
+		//all the values are sentinels or missing
+		return issues
+	}
+	for _, s := range sentinelFloats {
+		c := freq[s]
+		if c == 0 || (s < 0 && min < 0) || (s > 0 && s < 10*math.Abs(max)) {
+			continue
+		}
+		issues = append(issues, QualityIssue{m.Name, IssueSentinel,
+			float64(c) / n, strconv.Itoa(c) + " values are " + formatFloat(s)})
+	}
+	return issues
+}
+
+//stringIssues returns the data quality issues of a string metric
+func stringIssues(m Metric, data []string) []QualityIssue {
+	/*
+		Empty strings are the missing values and the sentinelStrings are the
+		sentinel values.
+		Then we find the frequencies of the values for checking whether the
+		metric is near constant.
+		Then we find the formats of the values for checking whether the
+		metric has mixed formats.
+	*/
+	issues := []QualityIssue{}
+	if len(data) == 0 {
+		return issues
+	}
+	n := float64(len(data))
+
+	//finding the missing values, sentinels, frequencies and formats
+	missing, sentinel := 0, 0
+	freq := map[string]int{}
+	formats := map[string]int{}
+	for _, v := range data {
+		t := strings.TrimSpace(v)
+		if len(t) == 0 {
+			missing++
+			continue
+		}
+		if sentinelStrings[strings.ToLower(t)] {
+			sentinel++
+			continue
+		}
+		freq[v]++
+		formats[stringFormat(t)]++
+	}
+	if ratio := float64(missing) / n; ratio > maxMissingRatio {
+		issues = append(issues, QualityIssue{m.Name, IssueMissing, ratio,
+			strconv.Itoa(missing) + " values are missing"})
+	}
+	if sentinel > 0 {
+		issues = append(issues, QualityIssue{m.Name, IssueSentinel,
+			float64(sentinel) / n, strconv.Itoa(sentinel) +
+				" values are placeholders like N/A"})
+	}
+	present := len(data) - missing - sentinel
+	if present < minQualityRecords {
+		return issues
+	}
+
+	//checking whether the metric is near constant
+	top, topV := 0, ""
+	for v, c := range freq {
+		if c > top || (c == top && v < topV) {
+			top, topV = c, v
+		}
+	}
+	if ratio := float64(top) / float64(present); ratio >= nearConstantRatio {
+		issues = append(issues, QualityIssue{m.Name, IssueConstant, ratio,
+			"most of the values are " + topV})
+	}
+
+	//checking for the mixed formats
+	type format struct {
+		name  string
+		count int
+	}
+	fs := []format{}
+	for f, c := range formats {
+		if float64(c)/float64(present) >= minFormatRatio {
+			fs = append(fs, format{f, c})
+		}
+	}
+	if len(fs) < 2 {
+		return issues
+	}
+	sort.Slice(fs, func(i, j int) bool {
+		if fs[i].count == fs[j].count {
+			return fs[i].name < fs[j].name
+		}
+		return fs[i].count > fs[j].count
+	})
+	details := make([]string, len(fs))
+	for i, f := range fs {
+		details[i] = strconv.Itoa(f.count) + " values are " + f.name
+	}
+	issues = append(issues, QualityIssue{m.Name, IssueMixedFormat,
+		float64(present-fs[0].count) / n, strings.Join(details, ", ")})
+	return issues
+}
+
+//stringFormat returns the format of a string value. It is one of number,
+//date in a layout and text.
+func stringFormat(s string) string {
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return "numbers"
+	}
+	if _, l, ok := parseTime(s); ok {
+		return "dates like " + l
+	}
+	return "text"
+}
@@ -0,0 +1,201 @@
+package insights
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/cuttle-ai/brain/visualizations"
+)
+
+/*
+	This file contains the tests for the data quality insight
+*/
+
+func TestDataQuality_New(t *testing.T) {
+	d := NewDataset()
+	m := Metric{Name: "age", DataType: Float}
+	d.AddMetric(m, []float64{10, 20, 30})
+	qi := (&DataQuality{}).New(d, []Metric{m})
+	q, ok := qi.(*DataQuality)
+	if !ok {
+		t.Fatal("Expected a data quality. Got", reflect.TypeOf(qi))
+	}
+	if q.dt.Length != 3 || len(q.ms) != 1 {
+		t.Fatal("Expected dataset of length 3 with 1 metric. Got", q.dt.Length,
+			len(q.ms))
+	}
+}
+
+func TestDataQuality_Type(t *testing.T) {
+	q := &DataQuality{}
+	if q.Type() != DATAQUALITY {
+		t.Fatal("Expected insight type is", DATAQUALITY, "Got", q.Type())
+	}
+}
+
+func TestDataQuality_FSFA(t *testing.T) {
+	q := &DataQuality{}
+	q.FSFA()
+	if q.Relevant() {
+		t.Fatal("Expected data quality to be irrelevant without metrics.",
+			"Got it as relevant")
+	}
+	d := NewDataset()
+	d.AddMetric(Metric{Name: "age", DataType: Float}, []float64{1})
+	q = &DataQuality{dt: d, ms: []Metric{d.Metrics["age"]}}
+	q.FSFA()
+	if !q.Relevant() {
+		t.Fatal("Expected data quality to be relevant with normal conditions.",
+			"Got it as irrelevant")
+	}
+}
+
+//repeat returns the string repeated n times
+func repeat(s string, n int) []string {
+	r := make([]string, n)
+	for i := range r {
+		r[i] = s
+	}
+	return r
+}
+
+type qGenerateTC struct {
+	ID          string
+	Description string
+	Metrics     []Metric
+	Datas       []interface{}
+	Expected    []QualityIssue
+}
+
+var qGenerateTCs = []qGenerateTC{
+	{"1", "Clean data", []Metric{
+		{Name: "age", DataType: Float},
+		{Name: "name", DataType: String},
+	}, []interface{}{
+		[]float64{10, 20, 30, 40},
+		[]string{"a", "b", "c", "d"},
+	}, []QualityIssue{}},
+	{"2", "Missing values", []Metric{
+		{Name: "age", DataType: Float},
+		{Name: "name", DataType: String},
+	}, []interface{}{
+		[]float64{10, math.NaN(), 30, 40},
+		[]string{"a", "", "", "d"},
+	}, []QualityIssue{
+		{"age", IssueMissing, 0.25, "1 values are missing"},
+		{"name", IssueMissing, 0.5, "2 values are missing"},
+	}},
+	{"3", "Near constant metrics", []Metric{
+		{Name: "age", DataType: Float},
+		{Name: "country", DataType: String},
+		{Name: "id", DataType: Float},
+	}, []interface{}{
+		[]float64{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 2},
+		repeat("IN", 20),
+		[]float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18,
+			19, 20},
+	}, []QualityIssue{
+		{"age", IssueConstant, 0.95, "most of the values are 1"},
+		{"country", IssueConstant, 1, "most of the values are IN"},
+	}},
+	{"4", "Duplicate records", []Metric{
+		{Name: "age", DataType: Float},
+		{Name: "name", DataType: String},
+	}, []interface{}{
+		[]float64{10, 20, 10, 40},
+		[]string{"a", "b", "a", "d"},
+	}, []QualityIssue{
+		{"", IssueDuplicate, 0.25, "1 records are duplicates of other records"},
+	}},
+	{"5", "Sentinel values", []Metric{
+		{Name: "age", DataType: Float},
+		{Name: "temperature", DataType: Float},
+		{Name: "name", DataType: String},
+	}, []interface{}{
+		[]float64{10, -999, 30, 40},
+		[]float64{-10, -999, 30, 40},
+		[]string{"a", "N/A", "c", "d"},
+	}, []QualityIssue{
+		{"age", IssueSentinel, 0.25, "1 values are -999"},
+		{"name", IssueSentinel, 0.25, "1 values are placeholders like N/A"},
+	}},
+	{"6", "Mixed formats", []Metric{
+		{Name: "joined", DataType: String},
+	}, []interface{}{
+		[]string{"2019-01-01", "2019-01-02", "2019-01-03", "2019-01-04",
+			"2019-01-05", "2019-01-06", "2019-01-07", "2019-01-08",
+			"01/09/2019", "01/10/2019"},
+	}, []QualityIssue{
+		{"joined", IssueMixedFormat, 0.2, "8 values are dates like " +
+			"2006-01-02, 2 values are dates like 01/02/2006"},
+	}},
+}
+
+func TestDataQuality_Generate(t *testing.T) {
+	for _, v := range qGenerateTCs {
+		t.Run(v.ID, func(t *testing.T) {
+			d := NewDataset()
+			for i, m := range v.Metrics {
+				if err := d.AddMetric(m, v.Datas[i]); err != nil {
+					t.Fatal("Error while adding metric for", v.ID, err)
+				}
+			}
+			q := (&DataQuality{}).Propose(d)[0].I.(*DataQuality)
+			q.FSFA()
+			q.Generate()
+			if q.Relevant() != (len(v.Expected) != 0) {
+				t.Fatal("Expected relevance", len(v.Expected) != 0, "Got",
+					q.Relevant(), q.Issues())
+			}
+			if len(q.Issues()) != len(v.Expected) {
+				t.Fatal("Expected issues", v.Expected, "Got", q.Issues())
+			}
+			for i := range v.Expected {
+				if !reflect.DeepEqual(q.Issues()[i], v.Expected[i]) {
+					t.Fatal("Expected issue", v.Expected[i], "Got",
+						q.Issues()[i])
+				}
+			}
+			if !q.Relevant() {
+				return
+			}
+			tb, ok := q.Visual().(visualizations.Table)
+			if !ok {
+				t.Fatal("Expected a table. Got", reflect.TypeOf(q.Visual()))
+			}
+			if len(tb.Data()) != len(v.Expected) {
+				t.Fatal("Expected", len(v.Expected), "rows. Got",
+					len(tb.Data()))
+			}
+		})
+	}
+}
+
+func TestDataQuality_Nullable(t *testing.T) {
+	d := NewDataset()
+	d.AddNullableMetric(Metric{Name: "id", DataType: Int},
+		[]int64{1, 2, 3, 4}, []bool{true, false, false, true})
+	d.AddMetric(Metric{Name: "amount", DataType: Float}, []float64{5, 1, 9, 3})
+	q := (&DataQuality{}).Propose(d)[0].I.(*DataQuality)
+	q.FSFA()
+	q.Generate()
+	expected := QualityIssue{"id", IssueMissing, 0.5, "2 values are missing"}
+	if !q.Relevant() || len(q.Issues()) != 1 ||
+		!reflect.DeepEqual(q.Issues()[0], expected) {
+		t.Fatal("Expected issue", expected, "Got", q.Issues())
+	}
+}
+
+func TestDataQuality_Propose(t *testing.T) {
+	d := NewDataset()
+	if pro := (&DataQuality{}).Propose(d); len(pro) != 0 {
+		t.Fatal("Expected no proposals for empty dataset. Got", len(pro))
+	}
+	d.AddMetric(Metric{Name: "age", DataType: Float}, []float64{1, 2})
+	d.AddMetric(Metric{Name: "name", DataType: String}, []string{"a", "b"})
+	pro := (&DataQuality{}).Propose(d)
+	if len(pro) != 1 || len(pro[0].M) != 2 {
+		t.Fatal("Expected 1 proposal with 2 metrics. Got", pro)
+	}
+}
@@ -0,0 +1,332 @@
+package insights
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/cuttle-ai/brain/visualizations"
+)
+
+/*
+	This file contains the utilities and structs required for rank change
+	insights
+*/
+
+const (
+	//minRankCategories is the minimum no. of categories required for ranking
+	minRankCategories = 3
+	//maxRankCategories is the maximum no. of categories that are ranked.
+	//Metrics having more categories are not ranked.
+	maxRankCategories = 50
+	//minRankMove is the minimum no. of places a category should move to be
+	//reported as a mover
+	minRankMove = 3
+	//maxRankMovers is the maximum no. of movers reported
+	maxRankMovers = 5
+	//maxBumpCategories is the maximum no. of categories shown in the bump
+	//chart apart from the movers
+	maxBumpCategories = 10
+)
+
+//CategoryRanking is the ranking of a category in each period
+type CategoryRanking struct {
+	Category string //Category is the name of the category
+	//Ranks has the rank of the category in each period starting from 1.
+	//Rank is 0 for the periods in which the category has no records.
+	Ranks []int
+}
+
+//RankMove is the change in the rank of a category between the last two
+//periods
+type RankMove struct {
+	Category string //Category is the name of the category
+	From     int    //From is the rank in the previous period
+	To       int    //To is the rank in the last period
+}
+
+//Rank is the rank change insight.
+//It ranks the categories by the sum of a metric in each period and reports
+//the categories whose rank changed the most in the last period.
+type Rank struct {
+	//visual has the visualization to be used for showing the ranks.
+	//Bump chart of the categories is used.
+	visual visualizations.Visual
+	//relevant stores the information whether the insight is relevant or not.
+	//This property is updated after running methods like FSFA and Generate
+	relevant bool
+	dt       Dataset //dt is the dataset to be used for the insight
+	//ms is the list of metrics. They are the float metric by which the
+	//categories are ranked, the category and the time in the order.
+	ms       []Metric
+	periods  []string          //periods has the labels of the periods in order
+	rankings []CategoryRanking //rankings has the ranking of the categories
+	movers   []RankMove        //movers has the categories that moved the most
+}
+
+//New returns a new instance of the Rank with
+//initializations done for the given dataset
+func (r *Rank) New(d Dataset, ms []Metric) Insight {
+	return &Rank{dt: d, ms: ms}
+}
+
+//Visual returns the visualization to be used for visualizing the ranks
+func (r *Rank) Visual() visualizations.Visual {
+	return r.visual
+}
+
+//Type returns the type string for the rank change type of insight
+func (r *Rank) Type() string {
+	return RANK
+}
+
+//Relevant returns whether the insight is relevant or not for the given dataset.
+func (r *Rank) Relevant() bool {
+	return r.relevant
+}
+
+//Periods returns the labels of the periods in time order
+func (r *Rank) Periods() []string {
+	return r.periods
+}
+
+//Rankings returns the ranking of the categories sorted by their rank in the
+//last period
+func (r *Rank) Rankings() []CategoryRanking {
+	return r.rankings
+}
+
+//Movers returns the categories whose rank changed the most between the last
+//two periods
+func (r *Rank) Movers() []RankMove {
+	return r.movers
+}
+
+//FSFA does the fast statistical feasibilty analysis over the dataset
+//with the given metrics whether the categories can be ranked over time.
+//It requires a float metric, a string category metric and a time metric.
+func (r *Rank) FSFA() {
+	if len(r.ms) != 3 || r.ms[0].DataType != Float ||
+		r.ms[1].DataType != String || !isTime(r.ms[2]) {
+		r.relevant = false
+		return
+	}
+	r.relevant = r.dt.Length >= minRankCategories*2
+}
+
+//Generate generates the rank change insight for the datatset associated
+//with it for the provided metrics. The insight is relevant only if there are
+//categories that moved atleast minRankMove places.
+//This method can only be run after running the FSFA.
+//Else the insight won't be generated
+func (r *Rank) Generate() {
+	/*
+		If the insight is not relevant we won't event bother
+		to go forward.
+		We will bucket the records into periods and sum the metric for
+		each category in each period.
+		Then we rank the categories in each period.
+		Then we find the categories whose rank changed the most between the
+		last two periods.
+	*/
+	//Checking whether the existing relevance of the insight
+	if !r.relevant {
+		return
+	}
+	m := r.ms[0]
+	if m.Index >= len(r.dt.DataF) {
+		r.relevant = false
+		return
+	}
+
+	//bucketing the records and grouping by the category and period
+	cats, ok1 := keys(r.dt, r.ms[1])
+	buckets, valid, g, ok2 := timeBuckets(r.dt, r.ms[2])
+	if !ok1 || !ok2 {
+		r.relevant = false
+		return
+	}
+	sums := map[string]map[int]float64{}
+	seen := map[int]bool{}
+	for i, v := range r.dt.DataF[m.Index] {
+		if !valid[i] || math.IsNaN(v) || len(cats[i]) == 0 {
+			continue
+		}
+		if sums[cats[i]] == nil {
+			sums[cats[i]] = map[int]float64{}
+		}
+		sums[cats[i]][buckets[i]] += v
+		seen[buckets[i]] = true
+	}
+	if len(sums) < minRankCategories || len(sums) > maxRankCategories ||
+		len(seen) < 2 {
+		r.relevant = false
+		return
+	}
+	ps := make([]int, 0, len(seen))
+	for p := range seen {
+		ps = append(ps, p)
+	}
+	sort.Ints(ps)
+	r.periods = make([]string, len(ps))
+	for i, p := range ps {
+		r.periods[i] = bucketLabel(p, g)
+	}
+
+	//ranking the categories in each period
+	r.rankings = rankCategories(sums, ps)
+
+	//finding the movers
+	r.movers = rankMovers(r.rankings)
+	if len(r.movers) == 0 {
+		r.relevant = false
+		return
+	}
+	r.relevant = true
+	r.visual = r.bump()
+}
+
+//bump returns the bump chart of the top categories and the movers
+func (r *Rank) bump() visualizations.BumpChart {
+	name, cname := displayName(r.ms[0]), displayName(r.ms[1])
+	top := r.movers[0]
+	desc := top.Category + " moved from #" + strconv.Itoa(top.From) +
+		" to #" + strconv.Itoa(top.To) + " in " + r.periods[len(r.periods)-1]
+	for _, mv := range r.movers[1:] {
+		desc += ", " + mv.Category + " from #" + strconv.Itoa(mv.From) +
+			" to #" + strconv.Itoa(mv.To)
+	}
+	visual := visualizations.BumpChart{
+		T: "Ranking of " + cname + " by " + name,
+		D: desc,
+		M: []visualizations.Metric{
+			{Name: "period", DisplayName: displayName(r.ms[2]),
+				DataType: String, Dimension: 0},
+			{Name: "rank", DisplayName: "Rank", DataType: Float,
+				Dimension: 1},
+			{Name: "category", DisplayName: cname, DataType: String,
+				Dimension: 2},
+		},
+	}
+	shown := map[string]bool{}
+	for _, mv := range r.movers {
+		shown[mv.Category] = true
+	}
+	data := []map[string]interface{}{}
+	for i, cr := range r.rankings {
+		if i >= maxBumpCategories && !shown[cr.Category] {
+			continue
+		}
+		for j, rank := range cr.Ranks {
+			if rank == 0 {
+				continue
+			}
+			data = append(data, map[string]interface{}{
+				"period":   r.periods[j],
+				"rank":     float64(rank),
+				"category": cr.Category,
+			})
+		}
+	}
+	visual.Dt = data
+	return visual
+}
+
+//Propose suggests the possible insights from the domain knowledge.
+//Datasets having a time metric are proposed for ranking each string metric
+//by each float metric. Metrics having ids like the user or the transaction
+//aren't ranked.
+func (r *Rank) Propose(d Dataset) []ProposedInsight {
+	result := []ProposedInsight{}
+	tm, ok := timeMetric(d)
+	if !ok {
+		return result
+	}
+	ms := sortedMetrics(d)
+	for _, c := range ms {
+		if c.DataType != String || c.Name == tm.Name ||
+			(c.Semantic != "" && c.Semantic != SemanticSegment &&
+				c.Semantic != SemanticItem) {
+			continue
+		}
+		for _, m := range ms {
+			if m.DataType != Float || m.Name == tm.Name {
+				continue
+			}
+			metrics := []Metric{m, c, tm}
+			result = append(result, ProposedInsight{r.New(d, metrics), metrics})
+		}
+	}
+	return result
+}
+
+//rankCategories ranks the categories by their sum in each of the periods.
+//The rankings are sorted by the rank in the last period. Categories without
+//records in the last period are put at the end.
+func rankCategories(sums map[string]map[int]float64, ps []int) []CategoryRanking {
+	cats := make([]string, 0, len(sums))
+	for c := range sums {
+		cats = append(cats, c)
+	}
+	sort.Strings(cats)
+	ranks := map[string][]int{}
+	for _, c := range cats {
+		ranks[c] = make([]int, len(ps))
+	}
+	for j, p := range ps {
+		present := []string{}
+		for _, c := range cats {
+			if _, ok := sums[c][p]; ok {
+				present = append(present, c)
+			}
+		}
+		sort.SliceStable(present, func(a, b int) bool {
+			return sums[present[a]][p] > sums[present[b]][p]
+		})
+		for k, c := range present {
+			ranks[c][j] = k + 1
+		}
+	}
+	result := make([]CategoryRanking, len(cats))
+	for i, c := range cats {
+		result[i] = CategoryRanking{c, ranks[c]}
+	}
+	last := len(ps) - 1
+	sort.SliceStable(result, func(a, b int) bool {
+		ra, rb := result[a].Ranks[last], result[b].Ranks[last]
+		if ra == 0 || rb == 0 {
+			return rb == 0 && ra != 0
+		}
+		return ra < rb
+	})
+	return result
+}
+
+//rankMovers returns the categories that moved atleast minRankMove places
+//between the last two periods sorted by the no. of places moved. Atmost
+//maxRankMovers categories are returned.
+func rankMovers(rankings []CategoryRanking) []RankMove {
+	movers := []RankMove{}
+	for _, cr := range rankings {
+		n := len(cr.Ranks)
+		from, to := cr.Ranks[n-2], cr.Ranks[n-1]
+		if from == 0 || to == 0 {
+			continue
+		}
+		if from-to >= minRankMove || to-from >= minRankMove {
+			movers = append(movers, RankMove{cr.Category, from, to})
+		}
+	}
+	sort.SliceStable(movers, func(a, b int) bool {
+		da := math.Abs(float64(movers[a].From - movers[a].To))
+		db := math.Abs(float64(movers[b].From - movers[b].To))
+		if da != db {
+			return da > db
+		}
+		return movers[a].To < movers[b].To
+	})
+	if len(movers) > maxRankMovers {
+		movers = movers[:maxRankMovers]
+	}
+	return movers
+}
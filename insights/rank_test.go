@@ -0,0 +1,139 @@
+package insights
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/cuttle-ai/brain/visualizations"
+)
+
+/*
+	This file contains the tests for the rank change insight
+*/
+
+//rankMetrics are the metrics of the product sales datasets
+var rankMetrics = []Metric{
+	{Name: "sales", DataType: Float},
+	{Name: "product", DisplayName: "Product", DataType: String},
+	{Name: "month", DataType: String, Semantic: SemanticTime},
+}
+
+//productSales returns the sales of 10 products over 2 months. p0 sells the
+//most and p9 the least in both the months except p8 which jumps to the
+//second place in the second month.
+func productSales() Dataset {
+	sales, products, months := []float64{}, []string{}, []string{}
+	for _, m := range []string{"2019-01-05", "2019-02-10"} {
+		for i := 0; i < 10; i++ {
+			s := 100 - 10*float64(i)
+			if i == 8 && m == "2019-02-10" {
+				s = 95
+			}
+			//splitting the sales into two records to check the grouping
+			sales = append(sales, s/2, s/2)
+			products = append(products, "p"+strconv.Itoa(i), "p"+strconv.Itoa(i))
+			months = append(months, m, m)
+		}
+	}
+	d := NewDataset()
+	d.AddMetric(rankMetrics[0], sales)
+	d.AddMetric(rankMetrics[1], products)
+	d.AddMetric(rankMetrics[2], months)
+	return d
+}
+
+func TestRank_New(t *testing.T) {
+	d := productSales()
+	ri := (&Rank{}).New(d, rankMetrics)
+	r, ok := ri.(*Rank)
+	if !ok {
+		t.Fatal("Expected a rank. Got", reflect.TypeOf(ri))
+	}
+	if len(r.ms) != 3 {
+		t.Fatal("Expected 3 metrics. Got", len(r.ms))
+	}
+}
+
+func TestRank_Type(t *testing.T) {
+	r := &Rank{}
+	if r.Type() != RANK {
+		t.Fatal("Expected insight type is", RANK, "Got", r.Type())
+	}
+}
+
+func TestRank_FSFA(t *testing.T) {
+	d := productSales()
+	r := &Rank{dt: d, ms: rankMetrics[:2]}
+	r.FSFA()
+	if r.Relevant() {
+		t.Fatal("Expected rank to be irrelevant without time metric.",
+			"Got it as relevant")
+	}
+	r = &Rank{dt: d, ms: rankMetrics}
+	r.FSFA()
+	if !r.Relevant() {
+		t.Fatal("Expected rank to be relevant with normal conditions.",
+			"Got it as irrelevant")
+	}
+}
+
+func TestRank_Generate(t *testing.T) {
+	t.Run("Testing generate with a big mover", func(t *testing.T) {
+		d := productSales()
+		r := (&Rank{}).Propose(d)[0].I.(*Rank)
+		r.FSFA()
+		r.Generate()
+		if !r.Relevant() {
+			t.Fatal("Expected rank to be relevant. Got irrelevant")
+		}
+		if len(r.Periods()) != 2 {
+			t.Fatal("Expected 2 periods. Got", r.Periods())
+		}
+		expected := []RankMove{{"p8", 9, 2}}
+		if !reflect.DeepEqual(r.Movers(), expected) {
+			t.Fatal("Expected movers", expected, "Got", r.Movers())
+		}
+		rs := r.Rankings()
+		if rs[0].Category != "p0" || rs[1].Category != "p8" ||
+			!reflect.DeepEqual(rs[2].Ranks, []int{2, 3}) {
+			t.Fatal("Expected p0, p8 and p1 as the top 3. Got", rs[:3])
+		}
+		b, ok := r.Visual().(visualizations.BumpChart)
+		if !ok {
+			t.Fatal("Expected a bump chart. Got", reflect.TypeOf(r.Visual()))
+		}
+		if len(b.Data()) != 20 {
+			t.Fatal("Expected 20 ranks in the bump chart. Got", len(b.Data()))
+		}
+	})
+
+	t.Run("Testing generate with stable ranks", func(t *testing.T) {
+		d := NewDataset()
+		d.AddMetric(rankMetrics[0], []float64{3, 2, 1, 3, 2, 1})
+		d.AddMetric(rankMetrics[1], []string{"a", "b", "c", "a", "b", "c"})
+		d.AddMetric(Metric{Name: "month", DataType: Float,
+			Semantic: SemanticTime}, []float64{1, 1, 1, 2, 2, 2})
+		r := &Rank{dt: d, ms: []Metric{d.Metrics["sales"],
+			d.Metrics["product"], d.Metrics["month"]}}
+		r.FSFA()
+		r.Generate()
+		if r.Relevant() {
+			t.Fatal("Expected rank to be irrelevant without movers.",
+				"Got relevant")
+		}
+	})
+}
+
+func TestRank_Propose(t *testing.T) {
+	d := productSales()
+	if pro := (&Rank{}).Propose(d); len(pro) != 1 {
+		t.Fatal("Expected 1 proposal. Got", len(pro))
+	}
+	d.AddMetric(Metric{Name: "user", DataType: String, Semantic: SemanticUser},
+		make([]string, d.Length))
+	if pro := (&Rank{}).Propose(d); len(pro) != 1 {
+		t.Fatal("Expected user metric not to be ranked. Got", len(pro),
+			"proposals")
+	}
+}
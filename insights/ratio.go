@@ -0,0 +1,237 @@
+package insights
+
+import (
+	"math"
+	"sort"
+
+	"github.com/cuttle-ai/brain/visualizations"
+)
+
+/*
+	This file contains the utilities and structs required for ratio insights
+*/
+
+const (
+	//minRatioChange is the minimum relative change of the ratio in the latest
+	//period for it to be reported
+	minRatioChange = 0.1
+)
+
+//Ratio is the ratio insight.
+//It tracks a ratio of two metrics like average order value over time and
+//explains its change in the latest period by the changes of its numerator and
+//denominator like average order value rose 15% while orders fell.
+type Ratio struct {
+	//visual has the visualization to be used for showing the ratio.
+	//Line chart of the ratio over the periods is used.
+	visual visualizations.Visual
+	//relevant stores the information whether the insight is relevant or not.
+	//This property is updated after running methods like FSFA and Generate
+	relevant bool
+	dt       Dataset //dt is the dataset to be used for the insight
+	//ms is the list of metrics. First one is the ratio derived by the
+	//WithRatios method of the dataset and the second one is the time metric.
+	ms []Metric
+	//change, numerator and denominator are the relative changes of the
+	//ratio, its numerator and its denominator in the latest period
+	change, numerator, denominator float64
+	//period is the label of the latest period
+	period string
+}
+
+//New returns a new instance of the Ratio with
+//initializations done for the given dataset
+func (r *Ratio) New(d Dataset, ms []Metric) Insight {
+	return &Ratio{dt: d, ms: ms}
+}
+
+//Visual returns the visualization to be used for visualizing the ratio
+func (r *Ratio) Visual() visualizations.Visual {
+	return r.visual
+}
+
+//Type returns the type string for the ratio type of insight
+func (r *Ratio) Type() string {
+	return RATIO
+}
+
+//Relevant returns whether the insight is relevant or not for the given dataset.
+func (r *Ratio) Relevant() bool {
+	return r.relevant
+}
+
+//Change returns the relative change of the ratio in the latest period like
+//0.15 for a rise of 15%
+func (r *Ratio) Change() float64 {
+	return r.change
+}
+
+//Components returns the relative changes of the numerator and the
+//denominator of the ratio in the latest period
+func (r *Ratio) Components() (float64, float64) {
+	return r.numerator, r.denominator
+}
+
+//FSFA does the fast statistical feasibilty analysis over the dataset
+//with the given metrics whether the change of the ratio can be explained.
+//It requires a ratio metric whose components are in the dataset and a time
+//metric.
+func (r *Ratio) FSFA() {
+	if len(r.ms) != 2 || r.ms[0].DataType != Float ||
+		len(r.ms[0].Numerator) == 0 || len(r.ms[0].Denominator) == 0 ||
+		!isTime(r.ms[1]) {
+		r.relevant = false
+		return
+	}
+	_, ok1 := r.dt.Metrics[r.ms[0].Numerator]
+	_, ok2 := r.dt.Metrics[r.ms[0].Denominator]
+	r.relevant = ok1 && ok2 && r.dt.Length > 1
+}
+
+//Generate generates the ratio insight for the datatset associated with it
+//for the provided metrics.
+//This method can only be run after running the FSFA.
+//Else the insight won't be generated
+func (r *Ratio) Generate() {
+	/*
+		If the insight is not relevant we won't event bother
+		to go forward.
+		We will bucket the records into periods and sum the numerator and the
+		denominator in each period. Ratio of a period is the ratio of the
+		sums and not the average of the ratios of the records.
+		Then we compare the last two periods.
+		The insight is relevant if the ratio changed by atleast
+		minRatioChange.
+	*/
+	//Checking whether the existing relevance of the insight
+	if !r.relevant {
+		return
+	}
+	num := r.dt.Metrics[r.ms[0].Numerator]
+	den := r.dt.Metrics[r.ms[0].Denominator]
+	if num.Index >= len(r.dt.DataF) || den.Index >= len(r.dt.DataF) {
+		r.relevant = false
+		return
+	}
+
+	//summing the components in each period
+	buckets, valid, g, ok := timeBuckets(r.dt, r.ms[1])
+	if !ok {
+		r.relevant = false
+		return
+	}
+	ns, ds := r.dt.DataF[num.Index], r.dt.DataF[den.Index]
+	nsum, dsum := map[int]float64{}, map[int]float64{}
+	for i := range ns {
+		if !valid[i] || math.IsNaN(ns[i]) || math.IsNaN(ds[i]) {
+			continue
+		}
+		nsum[buckets[i]] += ns[i]
+		dsum[buckets[i]] += ds[i]
+	}
+	ps := make([]int, 0, len(nsum))
+	for p := range nsum {
+		ps = append(ps, p)
+	}
+	sort.Ints(ps)
+	ratios := make([]float64, len(ps))
+	for i, p := range ps {
+		ratios[i] = math.NaN()
+		if dsum[p] != 0 {
+			ratios[i] = nsum[p] / dsum[p]
+		}
+	}
+	if len(ps) < 2 {
+		r.relevant = false
+		return
+	}
+
+	//comparing the last two periods
+	prev, last := ps[len(ps)-2], ps[len(ps)-1]
+	rp, rl := ratios[len(ps)-2], ratios[len(ps)-1]
+	if math.IsNaN(rp) || math.IsNaN(rl) || rp == 0 {
+		r.relevant = false
+		return
+	}
+	r.change = (rl - rp) / math.Abs(rp)
+	r.numerator = (nsum[last] - nsum[prev]) / math.Abs(nsum[prev])
+	r.denominator = (dsum[last] - dsum[prev]) / math.Abs(dsum[prev])
+	r.period = bucketLabel(last, g)
+	if math.Abs(r.change) < minRatioChange {
+		r.relevant = false
+		return
+	}
+
+	r.relevant = true
+	r.visual = r.line(ps, ratios, g, num, den)
+}
+
+//line returns the line chart of the ratio over the periods
+func (r *Ratio) line(ps []int, ratios []float64, g string, num, den Metric) visualizations.LineChart {
+	/*
+		The change of the ratio is described with the changes of the
+		components. If a component moved against the ratio it is contrasted
+		like average order value rose 15% while orders fell 8%.
+	*/
+	m, tm := r.ms[0], r.ms[1]
+	name := displayName(m)
+	desc := name + " " + movement(r.change) + " in " + r.period
+	switch {
+	case r.change*r.denominator < 0:
+		desc += " while " + displayName(den) + " " + movement(r.denominator)
+	case r.change*r.numerator < 0:
+		desc += " while " + displayName(num) + " " + movement(r.numerator)
+	default:
+		desc += " as " + displayName(num) + " " + movement(r.numerator) +
+			" and " + displayName(den) + " " + movement(r.denominator)
+	}
+	visual := visualizations.LineChart{
+		T: name + " " + movement(r.change),
+		D: desc,
+		M: []visualizations.Metric{
+			{Name: tm.Name, DisplayName: tm.DisplayName, DataType: String,
+				Dimension: 0},
+			{Name: m.Name, DisplayName: m.DisplayName, DataType: Float,
+				Dimension: 1},
+		},
+	}
+	data := []map[string]interface{}{}
+	for i, p := range ps {
+		if math.IsNaN(ratios[i]) {
+			continue
+		}
+		data = append(data, map[string]interface{}{tm.Name: bucketLabel(p, g),
+			m.Name: ratios[i]})
+	}
+	visual.Dt = data
+	return visual
+}
+
+//Propose suggests the possible insights from the domain knowledge.
+//Ratios derived by the WithRatios method of the dataset are proposed if the
+//dataset has a time metric. Ratios are derived if the dataset doesn't have
+//them yet.
+func (r *Ratio) Propose(d Dataset) []ProposedInsight {
+	result := []ProposedInsight{}
+	tm, ok := timeMetric(d)
+	if !ok {
+		return result
+	}
+	d = d.WithRatios()
+	for _, m := range sortedMetrics(d) {
+		if m.DataType != Float || len(m.Numerator) == 0 {
+			continue
+		}
+		metrics := []Metric{m, tm}
+		result = append(result, ProposedInsight{r.New(d, metrics), metrics})
+	}
+	return result
+}
+
+//movement describes the relative change like rose 15% or fell 8%
+func movement(change float64) string {
+	if change >= 0 {
+		return "rose " + formatFloat(change*100) + "%"
+	}
+	return "fell " + formatFloat(-change*100) + "%"
+}
@@ -0,0 +1,150 @@
+package insights
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/cuttle-ai/brain/visualizations"
+)
+
+/*
+	This file contains the tests for the ratio insight
+*/
+
+//ordersDataset returns a dataset of the orders in 2 months. There are 20
+//orders of 50 in the first month. In the second month there are 15 orders
+//of the given value.
+func ordersDataset(value float64) Dataset {
+	months := []float64{}
+	revenue := []float64{}
+	for i := 0; i < 35; i++ {
+		if i < 20 {
+			months = append(months, 0)
+			revenue = append(revenue, 50)
+			continue
+		}
+		months = append(months, 1)
+		revenue = append(revenue, value)
+	}
+	orders := make([]float64, len(months))
+	for i := range orders {
+		orders[i] = 1
+	}
+	d := NewDataset()
+	d.AddMetric(Metric{Name: "month", DataType: Float, Semantic: SemanticTime},
+		months)
+	d.AddMetric(Metric{Name: "revenue", DisplayName: "Revenue", DataType: Float,
+		Semantic: SemanticMonetary}, revenue)
+	d.AddMetric(Metric{Name: "orders", DisplayName: "Orders", DataType: Float,
+		Semantic: SemanticCount}, orders)
+	return d
+}
+
+func TestRatio_New(t *testing.T) {
+	d := ordersDataset(60).WithRatios()
+	ri := (&Ratio{}).New(d, []Metric{d.Metrics["revenue_per_orders"],
+		d.Metrics["month"]})
+	r, ok := ri.(*Ratio)
+	if !ok {
+		t.Fatal("Expected a ratio. Got", reflect.TypeOf(ri))
+	}
+	if len(r.ms) != 2 {
+		t.Fatal("Expected 2 metrics. Got", len(r.ms))
+	}
+}
+
+func TestRatio_Type(t *testing.T) {
+	r := &Ratio{}
+	if r.Type() != RATIO {
+		t.Fatal("Expected insight type is", RATIO, "Got", r.Type())
+	}
+}
+
+func TestRatio_FSFA(t *testing.T) {
+	d := ordersDataset(60).WithRatios()
+	r := &Ratio{dt: d, ms: []Metric{d.Metrics["revenue"], d.Metrics["month"]}}
+	r.FSFA()
+	if r.Relevant() {
+		t.Fatal("Expected ratio to be irrelevant for a metric that isn't a",
+			"ratio. Got it as relevant")
+	}
+	r = &Ratio{dt: d, ms: []Metric{d.Metrics["revenue_per_orders"],
+		d.Metrics["month"]}}
+	r.FSFA()
+	if !r.Relevant() {
+		t.Fatal("Expected ratio to be relevant with normal conditions.",
+			"Got it as irrelevant")
+	}
+}
+
+func TestRatio_Generate(t *testing.T) {
+	t.Run("Testing generate with changed ratio", func(t *testing.T) {
+		d := ordersDataset(60)
+		r := (&Ratio{}).Propose(d)[0].I.(*Ratio)
+		r.FSFA()
+		r.Generate()
+		if !r.Relevant() {
+			t.Fatal("Expected ratio to be relevant. Got irrelevant")
+		}
+		if math.Abs(r.Change()-0.2) > 1e-9 {
+			t.Fatal("Expected a rise of 20%. Got", r.Change())
+		}
+		if num, den := r.Components(); math.Abs(num+0.1) > 1e-9 ||
+			math.Abs(den+0.25) > 1e-9 {
+			t.Fatal("Expected revenue to fall 10% and orders 25%. Got", num, den)
+		}
+		l, ok := r.Visual().(visualizations.LineChart)
+		if !ok {
+			t.Fatal("Expected a line chart. Got", reflect.TypeOf(r.Visual()))
+		}
+		desc := "Revenue per Orders rose 20% in 1 while Orders fell 25%"
+		if l.Description() != desc {
+			t.Fatal("Expected description", desc, "Got", l.Description())
+		}
+		if len(l.Data()) != 2 {
+			t.Fatal("Expected 2 periods. Got", len(l.Data()))
+		}
+	})
+
+	t.Run("Testing generate with stable ratio", func(t *testing.T) {
+		d := ordersDataset(52)
+		r := (&Ratio{}).Propose(d)[0].I.(*Ratio)
+		r.FSFA()
+		r.Generate()
+		if r.Relevant() {
+			t.Fatal("Expected a change of 4% to be irrelevant. Got", r.Change())
+		}
+	})
+}
+
+func TestRatio_Propose(t *testing.T) {
+	d := ordersDataset(60)
+	pro := (&Ratio{}).Propose(d)
+	if len(pro) != 1 || pro[0].M[0].Name != "revenue_per_orders" {
+		t.Fatal("Expected the average order value to be proposed. Got", pro)
+	}
+	if pro := (&Ratio{}).Propose(ratioDataset()); len(pro) != 0 {
+		t.Fatal("Expected no proposals without a time metric. Got", len(pro))
+	}
+}
+
+func TestPropose_Ratios(t *testing.T) {
+	//ratios are proposed only to the insights analysing them
+	allowed := map[string]bool{RATIO: true, FORECAST: true, EXTREME: true}
+	forecast := false
+	for _, p := range Propose(ordersDataset(60)) {
+		for _, m := range p.M {
+			if len(m.Numerator) > 0 && !allowed[p.I.Type()] {
+				t.Fatal("Expected the ratio", m.Name, "not to be proposed for",
+					p.I.Type())
+			}
+		}
+		if p.I.Type() == FORECAST && p.M[0].Name == "revenue_per_orders" {
+			forecast = true
+		}
+	}
+	if !forecast {
+		t.Fatal("Expected the forecast to be proposed for the ratios")
+	}
+}
@@ -0,0 +1,134 @@
+package insights
+
+import (
+	"math"
+)
+
+/*
+	This file contains the utilities required for the insights that fit
+	linear regressions
+*/
+
+//olsFit is the fit of an ordinary least squares regression
+type olsFit struct {
+	beta []float64 //beta has the coefficients of the regressors
+	se   []float64 //se has the standard errors of the coefficients
+	rss  float64   //rss is the residual sum of squares
+	//residuals has the residuals of the observations
+	residuals []float64
+}
+
+//ols fits the ordinary least squares regression of y on the regressors.
+//Each row of x has the regressors of an observation. Constant has to be
+//added as a regressor if required. It returns false if there are not more
+//observations than the regressors or the regressors are collinear.
+func ols(x [][]float64, y []float64) (olsFit, bool) {
+	/*
+		We will solve the normal equations (X'X) beta = X'y by inverting X'X.
+		The inverse is also used for the standard errors which are
+		sqrt(sigma^2 * (X'X)^-1 [j][j]) where sigma^2 = rss / (n - k).
+	*/
+	n := len(y)
+	if n == 0 || len(x) != n {
+		return olsFit{}, false
+	}
+	k := len(x[0])
+	if n <= k {
+		return olsFit{}, false
+	}
+
+	//finding X'X and X'y
+	xtx := make([][]float64, k)
+	xty := make([]float64, k)
+	for i := range xtx {
+		xtx[i] = make([]float64, k)
+	}
+	for r, row := range x {
+		for i := 0; i < k; i++ {
+			xty[i] += row[i] * y[r]
+			for j := 0; j < k; j++ {
+				xtx[i][j] += row[i] * row[j]
+			}
+		}
+	}
+	inv, ok := invert(xtx)
+	if !ok {
+		return olsFit{}, false
+	}
+
+	//finding the coefficients and the residuals
+	fit := olsFit{beta: make([]float64, k), se: make([]float64, k),
+		residuals: make([]float64, n)}
+	for i := 0; i < k; i++ {
+		for j := 0; j < k; j++ {
+			fit.beta[i] += inv[i][j] * xty[j]
+		}
+	}
+	for r, row := range x {
+		pred := 0.0
+		for i, v := range row {
+			pred += fit.beta[i] * v
+		}
+		fit.residuals[r] = y[r] - pred
+		fit.rss += fit.residuals[r] * fit.residuals[r]
+	}
+	sigma2 := fit.rss / float64(n-k)
+	for i := range fit.se {
+		fit.se[i] = math.Sqrt(sigma2 * inv[i][i])
+	}
+	return fit, true
+}
+
+//invert returns the inverse of the square matrix using the gauss jordan
+//elimination with partial pivoting. It returns false if the matrix is
+//singular. The given matrix is not modified.
+func invert(a [][]float64) ([][]float64, bool) {
+	n := len(a)
+	//augmenting the matrix with the identity matrix
+	m := make([][]float64, n)
+	scale := 0.0
+	for i := range a {
+		m[i] = make([]float64, 2*n)
+		copy(m[i], a[i])
+		m[i][n+i] = 1
+		for _, v := range a[i] {
+			scale = math.Max(scale, math.Abs(v))
+		}
+	}
+	if scale == 0 {
+		return nil, false
+	}
+	for c := 0; c < n; c++ {
+		//choosing the pivot
+		p := c
+		for r := c + 1; r < n; r++ {
+			if math.Abs(m[r][c]) > math.Abs(m[p][c]) {
+				p = r
+			}
+		}
+		if math.Abs(m[p][c]) <= 1e-12*scale {
+			return nil, false
+		}
+		m[c], m[p] = m[p], m[c]
+
+		//eliminating the column from the other rows
+		pv := m[c][c]
+		for j := range m[c] {
+			m[c][j] /= pv
+		}
+		for r := 0; r < n; r++ {
+			if r == c || m[r][c] == 0 {
+				continue
+			}
+			f := m[r][c]
+			for j := range m[r] {
+				m[r][j] -= f * m[c][j]
+			}
+		}
+	}
+	inv := make([][]float64, n)
+	for i := range m {
+		inv[i] = m[i][n:]
+	}
+	return inv, true
+}
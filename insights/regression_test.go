@@ -0,0 +1,43 @@
+package insights
+
+import (
+	"math"
+	"testing"
+)
+
+/*
+	This file contains the tests for the regression utilities
+*/
+
+func TestOLS(t *testing.T) {
+	x := [][]float64{{1, 1}, {1, 2}, {1, 3}, {1, 4}}
+	fit, ok := ols(x, []float64{3, 5, 7, 9.5})
+	if !ok {
+		t.Fatal("Expected the regression to fit")
+	}
+	if math.Abs(fit.beta[0]-0.75) > 1e-9 || math.Abs(fit.beta[1]-2.15) > 1e-9 {
+		t.Fatal("Expected the coefficients [0.75 2.15]. Got", fit.beta)
+	}
+	if math.Abs(fit.rss-0.075) > 1e-9 || fit.se[1] <= 0 {
+		t.Fatal("Expected rss 0.075 with positive standard errors. Got", fit.rss,
+			fit.se)
+	}
+	if _, ok := ols([][]float64{{1, 2}, {1, 2}, {1, 2}}, []float64{1, 2, 3}); ok {
+		t.Fatal("Expected the collinear regressors not to fit")
+	}
+}
+
+func TestInvert(t *testing.T) {
+	inv, ok := invert([][]float64{{4, 7}, {2, 6}})
+	if !ok {
+		t.Fatal("Expected the matrix to be invertible")
+	}
+	expected := [][]float64{{0.6, -0.7}, {-0.2, 0.4}}
+	for i := range expected {
+		for j := range expected[i] {
+			if math.Abs(inv[i][j]-expected[i][j]) > 1e-9 {
+				t.Fatal("Expected the inverse", expected, "Got", inv)
+			}
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package insights
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+/*
+	This file contains the utilities for saving and loading the datasets as
+	snapshots
+*/
+
+//SnapshotVersion is the version of the snapshot format written. Snapshots
+//of the later versions can't be read.
+const SnapshotVersion = 1
+
+//snapshotMagic are the bytes with which the binary snapshots start
+const snapshotMagic = "BRAINDS"
+
+//snapshot is the content of a snapshot of the dataset
+type snapshot struct {
+	Version  int
+	Length   int64
+	Metrics  map[string]Metric
+	DataF    [][]float64
+	DataS    [][]string
+	DataI    [][]int64
+	DataB    [][]bool
+	DataT    [][]time.Time
+	Validity map[string]Bitmap
+}
+
+//jsonSnapshot is the snapshot written as JSON. It is same as the snapshot
+//except the floats that can be NaN or infinite.
+type jsonSnapshot struct {
+	Version  int
+	Length   int64
+	Metrics  map[string]Metric
+	DataF    [][]jsonFloat
+	DataS    [][]string
+	DataI    [][]int64
+	DataB    [][]bool
+	DataT    [][]time.Time
+	Validity map[string]Bitmap
+}
+
+//jsonFloat is a float written as a JSON number. NaN and infinities are
+//written as the strings NaN, +Inf and -Inf since JSON doesn't have them.
+type jsonFloat float64
+
+//MarshalJSON returns the JSON of the float
+func (f jsonFloat) MarshalJSON() ([]byte, error) {
+	v := float64(f)
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return []byte(`"` + strconv.FormatFloat(v, 'g', -1, 64) + `"`), nil
+	}
+	return []byte(strconv.FormatFloat(v, 'g', -1, 64)), nil
+}
+
+//UnmarshalJSON parses the float from its JSON
+func (f *jsonFloat) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if len(s) > 1 && s[0] == '"' {
+		s = s[1 : len(s)-1]
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	*f = jsonFloat(v)
+	return nil
+}
+
+//WriteSnapshot writes the complete dataset as a binary snapshot. The
+//snapshot has the metrics, their data and validities. It can be read back
+//by ReadSnapshot.
+func (d Dataset) WriteSnapshot(w io.Writer) error {
+	/*
+		We will write the magic bytes and the version.
+		Then we encode the content of the snapshot as gob.
+	*/
+	bw := bufio.NewWriter(w)
+	bw.WriteString(snapshotMagic)
+	var v [binary.MaxVarintLen64]byte
+	bw.Write(v[:binary.PutUvarint(v[:], SnapshotVersion)])
+	err := gob.NewEncoder(bw).Encode(snapshot{SnapshotVersion, d.Length,
+		d.Metrics, d.DataF, d.DataS, d.DataI, d.DataB, d.DataT, d.Validity})
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err != nil {
+		return &Error{ErrMWriteSnapshot + err.Error(), ErrCWriteFailed}
+	}
+	return nil
+}
+
+//ReadSnapshot reads the dataset from a binary snapshot written by
+//WriteSnapshot. It returns an error if the input is not a snapshot, is of
+//a later version or has the metrics inconsistent with their data.
+func ReadSnapshot(r io.Reader) (Dataset, error) {
+	/*
+		We will check the magic bytes and the version.
+		Then we decode the content of the snapshot.
+		Then we check the dataset for consistency.
+	*/
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(snapshotMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != snapshotMagic {
+		return NewDataset(), &Error{ErrMReadSnapshot + "not a snapshot",
+			ErrCReadFailed}
+	}
+	version, err := binary.ReadUvarint(br)
+	if err != nil {
+		return NewDataset(), &Error{ErrMReadSnapshot + err.Error(), ErrCReadFailed}
+	}
+	if version > SnapshotVersion {
+		return NewDataset(), &Error{ErrMSnapshotVersion +
+			strconv.FormatUint(version, 10), ErrCReadFailed}
+	}
+	s := snapshot{}
+	if err := gob.NewDecoder(br).Decode(&s); err != nil {
+		return NewDataset(), &Error{ErrMReadSnapshot + err.Error(), ErrCReadFailed}
+	}
+	return snapshotDataset(s)
+}
+
+//MarshalJSON returns the snapshot of the dataset as JSON. Floats that are
+//NaN or infinite are written as strings.
+func (d Dataset) MarshalJSON() ([]byte, error) {
+	fs := make([][]jsonFloat, len(d.DataF))
+	for i, vals := range d.DataF {
+		fs[i] = make([]jsonFloat, len(vals))
+		for j, v := range vals {
+			fs[i][j] = jsonFloat(v)
+		}
+	}
+	return json.Marshal(jsonSnapshot{SnapshotVersion, d.Length, d.Metrics, fs,
+		d.DataS, d.DataI, d.DataB, d.DataT, d.Validity})
+}
+
+//UnmarshalJSON reads the dataset from the JSON snapshot. It returns the
+//errors same as ReadSnapshot.
+func (d *Dataset) UnmarshalJSON(b []byte) error {
+	s := jsonSnapshot{}
+	if err := json.Unmarshal(b, &s); err != nil {
+		return &Error{ErrMReadSnapshot + err.Error(), ErrCReadFailed}
+	}
+	if s.Version > SnapshotVersion {
+		return &Error{ErrMSnapshotVersion + strconv.Itoa(s.Version),
+			ErrCReadFailed}
+	}
+	fs := make([][]float64, len(s.DataF))
+	for i, vals := range s.DataF {
+		fs[i] = make([]float64, len(vals))
+		for j, v := range vals {
+			fs[i][j] = float64(v)
+		}
+	}
+	ds, err := snapshotDataset(snapshot{s.Version, s.Length, s.Metrics, fs,
+		s.DataS, s.DataI, s.DataB, s.DataT, s.Validity})
+	if err != nil {
+		return err
+	}
+	*d = ds
+	return nil
+}
+
+//snapshotDataset returns the dataset of the snapshot. It returns an error
+//if a metric doesn't have the data of the length of the dataset or isn't
+//stored with its name. Validities have to be of the metrics in the
+//snapshot and cover all the records.
+func snapshotDataset(s snapshot) (Dataset, error) {
+	d := NewDataset()
+	d.Length = s.Length
+	if s.Metrics != nil {
+		d.Metrics = s.Metrics
+	}
+	if s.DataF != nil {
+		d.DataF = s.DataF
+	}
+	if s.DataS != nil {
+		d.DataS = s.DataS
+	}
+	if s.DataI != nil {
+		d.DataI = s.DataI
+	}
+	if s.DataB != nil {
+		d.DataB = s.DataB
+	}
+	if s.DataT != nil {
+		d.DataT = s.DataT
+	}
+	if s.Validity != nil {
+		d.Validity = s.Validity
+	}
+	for name, m := range d.Metrics {
+		if m.Name != name {
+			return NewDataset(), &Error{ErrMSnapshotName + name,
+				ErrCReadFailed}
+		}
+		n := -1
+		switch m.DataType {
+		case Float:
+			if m.Index >= 0 && m.Index < len(d.DataF) {
+				n = len(d.DataF[m.Index])
+			}
+		case String:
+			if m.Index >= 0 && m.Index < len(d.DataS) {
+				n = len(d.DataS[m.Index])
+			}
+		case Int:
+			if m.Index >= 0 && m.Index < len(d.DataI) {
+				n = len(d.DataI[m.Index])
+			}
+		case Bool:
+			if m.Index >= 0 && m.Index < len(d.DataB) {
+				n = len(d.DataB[m.Index])
+			}
+		case Time:
+			if m.Index >= 0 && m.Index < len(d.DataT) {
+				n = len(d.DataT[m.Index])
+			}
+		}
+		if int64(n) != d.Length {
+			return NewDataset(), &Error{ErrMSnapshotMetric + name,
+				ErrCMetricSizeMismatch}
+		}
+	}
+	for name, bits := range d.Validity {
+		if _, ok := d.Metrics[name]; !ok || int64(len(bits))*64 < d.Length {
+			return NewDataset(), &Error{ErrMSnapshotValidity + name,
+				ErrCMetricSizeMismatch}
+		}
+	}
+	return d, nil
+}
@@ -0,0 +1,154 @@
+package insights
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+/*
+	This file contains the tests for the dataset snapshots
+*/
+
+//snapshotDatasetFixture returns a dataset with the metrics of all the data
+//types, a missing value, a NaN and an infinity
+func snapshotDatasetFixture() Dataset {
+	d := NewDataset()
+	d.AddMetric(Metric{Name: "day", DataType: Time, Semantic: SemanticTime},
+		[]time.Time{time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+			time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC)})
+	d.AddMetric(Metric{Name: "units", DataType: Int}, []int64{3, 4, 5})
+	d.AddMetric(Metric{Name: "paid", DataType: Bool}, []bool{true, false, true})
+	d.AddNullableMetric(Metric{Name: "amount", DisplayName: "Amount",
+		DataType: Float, Thresholds: []Limit{{AtMost, 5}, {AtLeast, 1}}},
+		[]float64{1.5, 0, math.Inf(1)}, []bool{true, false, true})
+	d.AddMetric(Metric{Name: "city", DataType: String},
+		[]string{"Kochi", "Pune", ""})
+	return d
+}
+
+//equalDatasets returns whether the datasets have the same metrics and the
+//data. NaN values are considered equal.
+func equalDatasets(a, b Dataset) bool {
+	if a.Length != b.Length || !reflect.DeepEqual(a.Metrics, b.Metrics) ||
+		!reflect.DeepEqual(a.Validity, b.Validity) ||
+		!reflect.DeepEqual(a.DataS, b.DataS) || !reflect.DeepEqual(a.DataI, b.DataI) ||
+		!reflect.DeepEqual(a.DataB, b.DataB) || len(a.DataF) != len(b.DataF) ||
+		len(a.DataT) != len(b.DataT) {
+		return false
+	}
+	for i := range a.DataF {
+		for j, v := range a.DataF[i] {
+			w := b.DataF[i][j]
+			if v != w && !(math.IsNaN(v) && math.IsNaN(w)) {
+				return false
+			}
+		}
+	}
+	for i := range a.DataT {
+		for j, v := range a.DataT[i] {
+			if !v.Equal(b.DataT[i][j]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestDataset_WriteSnapshot(t *testing.T) {
+	d := snapshotDatasetFixture()
+	var buf bytes.Buffer
+	if err := d.WriteSnapshot(&buf); err != nil {
+		t.Fatal("Error while writing the snapshot", err)
+	}
+	r, err := ReadSnapshot(&buf)
+	if err != nil {
+		t.Fatal("Error while reading the snapshot", err)
+	}
+	if !equalDatasets(d, r) {
+		t.Fatal("Expected the dataset", d, "Got", r)
+	}
+	if r.NullCount("amount") != 1 {
+		t.Fatal("Expected 1 missing amount. Got", r.NullCount("amount"))
+	}
+
+	buf.Reset()
+	if err := NewDataset().WriteSnapshot(&buf); err != nil {
+		t.Fatal("Error while writing the empty snapshot", err)
+	}
+	if r, err := ReadSnapshot(&buf); err != nil || r.Length != 0 ||
+		r.Metrics == nil {
+		t.Fatal("Expected an empty dataset. Got", r, err)
+	}
+}
+
+func TestReadSnapshot(t *testing.T) {
+	var buf bytes.Buffer
+	snapshotDatasetFixture().WriteSnapshot(&buf)
+	later := []byte(snapshotMagic + "\x02")
+	mismatch := snapshotDatasetFixture()
+	mismatch.DataS[0] = mismatch.DataS[0][:1]
+	var mbuf bytes.Buffer
+	mismatch.WriteSnapshot(&mbuf)
+	unknown := snapshotDatasetFixture()
+	unknown.Validity["region"] = unknown.Validity["amount"]
+	var ubuf bytes.Buffer
+	unknown.WriteSnapshot(&ubuf)
+	short := snapshotDatasetFixture()
+	short.Validity["amount"] = Bitmap{}
+	var sbuf bytes.Buffer
+	short.WriteSnapshot(&sbuf)
+	renamed := snapshotDatasetFixture()
+	renamed.Metrics["revenue"] = renamed.Metrics["amount"]
+	delete(renamed.Metrics, "amount")
+	delete(renamed.Validity, "amount")
+	var rbuf bytes.Buffer
+	renamed.WriteSnapshot(&rbuf)
+	tcs := []struct {
+		Description string
+		Input       []byte
+		Expected    string
+	}{
+		{"Not a snapshot", []byte("city,amount\n"), ErrMReadSnapshot},
+		{"Truncated snapshot", buf.Bytes()[:buf.Len()/2], ErrMReadSnapshot},
+		{"Later version", later, ErrMSnapshotVersion},
+		{"Metric of wrong length", mbuf.Bytes(), ErrMSnapshotMetric},
+		{"Validities of unknown metric", ubuf.Bytes(), ErrMSnapshotValidity},
+		{"Validities not covering the records", sbuf.Bytes(), ErrMSnapshotValidity},
+		{"Metric stored with another name", rbuf.Bytes(), ErrMSnapshotName},
+	}
+	for _, v := range tcs {
+		t.Run(v.Description, func(t *testing.T) {
+			_, err := ReadSnapshot(bytes.NewReader(v.Input))
+			if e, ok := err.(*Error); !ok || !strings.HasPrefix(e.Message, v.Expected) {
+				t.Fatal("Expected the error", v.Expected, "Got", err)
+			}
+		})
+	}
+}
+
+func TestDataset_MarshalJSON(t *testing.T) {
+	d := snapshotDatasetFixture()
+	b, err := json.Marshal(d)
+	if err != nil {
+		t.Fatal("Error while marshalling the dataset", err)
+	}
+	if !bytes.Contains(b, []byte(`"+Inf"`)) || !bytes.Contains(b, []byte(`"NaN"`)) {
+		t.Fatal("Expected the NaN and the infinity as strings. Got", string(b))
+	}
+	r := Dataset{}
+	if err := json.Unmarshal(b, &r); err != nil {
+		t.Fatal("Error while unmarshalling the dataset", err)
+	}
+	if !equalDatasets(d, r) {
+		t.Fatal("Expected the dataset", d, "Got", r)
+	}
+	if err := json.Unmarshal([]byte(`{"Version": 2}`), &r); err == nil {
+		t.Fatal("Expected an error for the later version. Got nil")
+	}
+}
@@ -0,0 +1,325 @@
+package insights
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+	This file contains the utilities for loading the datasets from the
+	databases using database/sql
+*/
+
+const (
+	//DialectSQLite is the SQL dialect of SQLite
+	DialectSQLite = "sqlite"
+	//DialectPostgres is the SQL dialect of PostgreSQL
+	DialectPostgres = "postgres"
+	//DialectMySQL is the SQL dialect of MySQL
+	DialectMySQL = "mysql"
+)
+
+//sampleConditions has the conditions for sampling a fraction of the rows in
+//the dialects. %v is replaced with the fraction.
+var sampleConditions = map[string]string{
+	DialectSQLite:   "ABS(RANDOM()) %% 1000000 < %v * 1000000",
+	DialectPostgres: "RANDOM() < %v",
+	DialectMySQL:    "RAND() < %v",
+}
+
+//sqlTypes has the data types of the SQL column types. Column types not
+//present are read as String.
+var sqlTypes = map[string]string{
+	"INT":         Float,
+	"INTEGER":     Float,
+	"TINYINT":     Float,
+	"SMALLINT":    Float,
+	"MEDIUMINT":   Float,
+	"BIGINT":      Float,
+	"INT2":        Float,
+	"INT4":        Float,
+	"INT8":        Float,
+	"SERIAL":      Float,
+	"BIGSERIAL":   Float,
+	"UNSIGNED":    Float,
+	"REAL":        Float,
+	"FLOAT":       Float,
+	"FLOAT4":      Float,
+	"FLOAT8":      Float,
+	"DOUBLE":      Float,
+	"NUMERIC":     Float,
+	"DECIMAL":     Float,
+	"MONEY":       Float,
+	"BOOL":        Bool,
+	"BOOLEAN":     Bool,
+	"BIT":         Bool,
+	"DATE":        Time,
+	"DATETIME":    Time,
+	"TIMESTAMP":   Time,
+	"TIMESTAMPTZ": Time,
+}
+
+//Querier runs the queries. *sql.DB, *sql.Tx and *sql.Conn are queriers.
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+//QueryOptions are the options for building a dataset from a query
+type QueryOptions struct {
+	//Limit is the max no. of rows read. All the rows are read if it is 0.
+	Limit int
+	//Sample is the fraction of the rows sampled randomly. All the rows are
+	//read if it is 0 or 1. Sampling requires the dialect.
+	Sample float64
+	//Dialect is the SQL dialect of the database like DialectSQLite. It is
+	//used for pushing the sampling into the query.
+	Dialect string
+	//Types are the data types of the columns mapped to their names in the
+	//dataset. Types of the other columns are mapped from their SQL types.
+	//Numbers are mapped to Float so that the insights can use them. Int has
+	//to be given here for storing them as Int.
+	Types map[string]string
+}
+
+//ReadSQL runs the query with the args and builds a dataset from the rows.
+//Limit and sampling in the options are pushed into the query so that only
+//the required rows are read from the database. Data types of the columns
+//are mapped from their SQL types. Columns with unknown SQL types are typed
+//from their values. Names of the metrics are derived from the column names
+//as done by ReadCSV. NULLs are stored as the missing values of the dataset.
+//It returns an error if the query couldn't be run or a value couldn't be
+//converted to the data type of its column.
+func ReadSQL(ctx context.Context, q Querier, query string, opts QueryOptions, args ...interface{}) (Dataset, error) {
+	/*
+		We will rewrite the query for the limit and the sampling.
+		Then we run the query and read the values of all the rows.
+		Then for each column, we find its data type.
+		Then we convert the values and add the column to the dataset.
+	*/
+	d := NewDataset()
+
+	//running the query
+	query, err := sqlQuery(query, opts)
+	if err != nil {
+		return d, err
+	}
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return d, &Error{ErrMQuery + err.Error(), ErrCReadFailed}
+	}
+	defer rows.Close()
+	cols, err := rows.ColumnTypes()
+	if err != nil {
+		return d, &Error{ErrMQuery + err.Error(), ErrCReadFailed}
+	}
+
+	//reading the values
+	vals := make([][]interface{}, len(cols))
+	for rows.Next() {
+		row := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range row {
+			ptrs[i] = &row[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return d, &Error{ErrMQuery + err.Error(), ErrCReadFailed}
+		}
+		for i, v := range row {
+			vals[i] = append(vals[i], v)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return d, &Error{ErrMQuery + err.Error(), ErrCReadFailed}
+	}
+
+	//adding the columns
+	headers := make([]string, len(cols))
+	for i, c := range cols {
+		headers[i] = c.Name()
+	}
+	names := metricNames(headers)
+	for c, col := range cols {
+		dt, ok := opts.Types[names[c]]
+		if !ok {
+			dt = sqlType(col.DatabaseTypeName(), vals[c])
+		}
+		data, valid, err := sqlColumn(vals[c], dt)
+		if err != nil {
+			return d, &Error{ErrMSQLColumn + headers[c] + " to " + dt + ". " +
+				err.Error(), ErrCDataTypeMismatch}
+		}
+		m := Metric{Name: names[c], DisplayName: headers[c], DataType: dt}
+		if err := d.AddNullableMetric(m, data, valid); err != nil {
+			return d, err
+		}
+	}
+	return d, nil
+}
+
+//sqlQuery returns the query with the limit and the sampling of the options.
+//The query is wrapped as a sub query if required. It returns an error if
+//sampling is asked for an unknown dialect.
+func sqlQuery(query string, opts QueryOptions) (string, error) {
+	sample := opts.Sample > 0 && opts.Sample < 1
+	if !sample && opts.Limit <= 0 {
+		return query, nil
+	}
+	query = "SELECT * FROM (" + strings.TrimRight(strings.TrimSpace(query), ";") +
+		") brain_query"
+	if sample {
+		cond, ok := sampleConditions[opts.Dialect]
+		if !ok {
+			return "", &Error{ErrMSampleDialect + opts.Dialect, ErrCReadFailed}
+		}
+		query += " WHERE " + fmt.Sprintf(cond,
+			strconv.FormatFloat(opts.Sample, 'f', -1, 64))
+	}
+	if opts.Limit > 0 {
+		query += " LIMIT " + strconv.Itoa(opts.Limit)
+	}
+	return query, nil
+}
+
+//sqlType returns the data type of the column having the SQL type. Only the
+//first word of the type is considered after removing the size like (10, 2).
+//If the SQL type is unknown, type of the first value that is not NULL is
+//used.
+func sqlType(dbType string, vals []interface{}) string {
+	t := strings.ToUpper(strings.TrimSpace(dbType))
+	if i := strings.IndexAny(t, "( "); i >= 0 {
+		t = t[:i]
+	}
+	if dt, ok := sqlTypes[t]; ok {
+		return dt
+	}
+	if len(t) > 0 {
+		return String
+	}
+	for _, v := range vals {
+		switch v.(type) {
+		case nil:
+			continue
+		case int64, float64:
+			return Float
+		case bool:
+			return Bool
+		case time.Time:
+			return Time
+		}
+		return String
+	}
+	return String
+}
+
+//sqlColumn converts the values of the column to the data array of the data
+//type. It returns the validities of the values where NULLs are invalid. It
+//returns an error if a value couldn't be converted.
+func sqlColumn(vals []interface{}, dt string) (interface{}, []bool, error) {
+	n := len(vals)
+	valid := make([]bool, n)
+	var fs []float64
+	var is []int64
+	var bs []bool
+	var ts []time.Time
+	var ss []string
+	switch dt {
+	case Float:
+		fs = make([]float64, n)
+	case Int:
+		is = make([]int64, n)
+	case Bool:
+		bs = make([]bool, n)
+	case Time:
+		ts = make([]time.Time, n)
+	case String:
+		ss = make([]string, n)
+	default:
+		return nil, nil, &Error{ErrMDAddMetricUnsupportedType + dt,
+			ErrCUnsupportedDataType}
+	}
+	for i, v := range vals {
+		if v == nil {
+			continue
+		}
+		valid[i] = true
+		val, err := sqlValue(v, dt)
+		if err != nil {
+			return nil, nil, err
+		}
+		switch dt {
+		case Float:
+			fs[i] = val.(float64)
+		case Int:
+			is[i] = val.(int64)
+		case Bool:
+			bs[i] = val.(bool)
+		case Time:
+			ts[i] = val.(time.Time)
+		case String:
+			ss[i] = val.(string)
+		}
+	}
+	switch dt {
+	case Float:
+		return fs, valid, nil
+	case Int:
+		return is, valid, nil
+	case Bool:
+		return bs, valid, nil
+	case Time:
+		return ts, valid, nil
+	}
+	return ss, valid, nil
+}
+
+//sqlValue converts the value scanned from a row to the data type. Numbers
+//are converted to bools as done by the databases storing the bools as 0 and
+//1. Text is parsed like the CSV fields.
+func sqlValue(v interface{}, dt string) (interface{}, error) {
+	switch val := v.(type) {
+	case []byte:
+		v = string(val)
+	case int64:
+		switch dt {
+		case Float:
+			return float64(val), nil
+		case Int:
+			return val, nil
+		case Bool:
+			return val != 0, nil
+		}
+	case float64:
+		switch dt {
+		case Float:
+			return val, nil
+		case Bool:
+			return val != 0, nil
+		}
+	case bool:
+		switch dt {
+		case Float:
+			if val {
+				return float64(1), nil
+			}
+			return float64(0), nil
+		case Bool:
+			return val, nil
+		}
+	case time.Time:
+		if dt == Time {
+			return val, nil
+		}
+	}
+	s, ok := v.(string)
+	if !ok {
+		s = fmt.Sprint(v)
+	}
+	if dt == String {
+		return s, nil
+	}
+	return parseValue(strings.TrimSpace(s), dt, CSVOptions{})
+}
@@ -0,0 +1,70 @@
+//go:build sqlite
+// +build sqlite
+
+package insights
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+/*
+	This file contains the tests for the database/sql loader run against
+	SQLite
+*/
+
+//salesDB returns an in memory SQLite database having 1000 sales
+func salesDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal("Error while opening the database", err)
+	}
+	//every connection has its own in memory database
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec("CREATE TABLE sales (day DATE, amount REAL, " +
+		"units INTEGER, city TEXT)"); err != nil {
+		t.Fatal("Error while creating the table", err)
+	}
+	for i := 0; i < 1000; i++ {
+		if _, err := db.Exec("INSERT INTO sales VALUES (?, ?, ?, ?)",
+			"2019-01-02", float64(i)/4, i, "Kochi"); err != nil {
+			t.Fatal("Error while adding the sales", err)
+		}
+	}
+	return db
+}
+
+func TestReadSQL_SQLite(t *testing.T) {
+	db := salesDB(t)
+	defer db.Close()
+	ctx := context.Background()
+
+	d, err := ReadSQL(ctx, db, "SELECT * FROM sales;", QueryOptions{Limit: 10})
+	if err != nil {
+		t.Fatal("Error while reading the query", err)
+	}
+	if d.Length != 10 {
+		t.Fatal("Expected 10 records. Got", d.Length)
+	}
+	types := map[string]string{"day": Time, "amount": Float, "units": Float,
+		"city": String}
+	for name, dt := range types {
+		if m, ok := d.Metrics[name]; !ok || m.DataType != dt {
+			t.Fatal("Expected", name, "of type", dt, "Got", m, ok)
+		}
+	}
+
+	//sampling a quarter of the rows. Chances of getting less than 150 or
+	//more than 350 rows are negligible.
+	d, err = ReadSQL(ctx, db, "SELECT * FROM sales;", QueryOptions{Sample: 0.25,
+		Dialect: DialectSQLite})
+	if err != nil {
+		t.Fatal("Error while sampling the query", err)
+	}
+	if d.Length < 150 || d.Length > 350 {
+		t.Fatal("Expected around 250 records sampled. Got", d.Length)
+	}
+}
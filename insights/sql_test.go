@@ -0,0 +1,219 @@
+package insights
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+/*
+	This file contains the tests for the database/sql loader
+*/
+
+//fakeDriver is a database/sql driver returning the same rows for any query.
+//It records the last query run so that the rewriting can be tested.
+type fakeDriver struct {
+	query   string
+	columns []string
+	types   []string
+	rows    [][]driver.Value
+}
+
+func (f *fakeDriver) Open(name string) (driver.Conn, error) {
+	return fakeConn{f}, nil
+}
+
+type fakeConn struct {
+	f *fakeDriver
+}
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return fakeStmt{c.f, query}, nil
+}
+
+func (c fakeConn) Close() error {
+	return nil
+}
+
+func (c fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+type fakeStmt struct {
+	f     *fakeDriver
+	query string
+}
+
+func (s fakeStmt) Close() error {
+	return nil
+}
+
+func (s fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("exec is not supported")
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.f.query = s.query
+	if s.query == "fail" {
+		return nil, errors.New("syntax error")
+	}
+	return &fakeRows{f: s.f}, nil
+}
+
+type fakeRows struct {
+	f *fakeDriver
+	i int
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.f.columns
+}
+
+func (r *fakeRows) ColumnTypeDatabaseTypeName(i int) string {
+	return r.f.types[i]
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.i >= len(r.f.rows) {
+		return io.EOF
+	}
+	copy(dest, r.f.rows[r.i])
+	r.i++
+	return nil
+}
+
+//salesDriver is the fake driver having the rows of the sales table
+var salesDriver = &fakeDriver{
+	columns: []string{"Order Date", "amount", "units", "paid", "city", "note"},
+	types:   []string{"DATE", "DECIMAL(10,2)", "BIGINT", "BOOLEAN", "VARCHAR(20)", ""},
+	rows: [][]driver.Value{
+		{[]byte("2019-01-02"), 12.5, int64(3), int64(1), "Kochi", "first"},
+		{time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC), nil, int64(4), false,
+			"Pune", nil},
+		{nil, []byte("7.25"), nil, true, nil, "last"},
+	},
+}
+
+func init() {
+	sql.Register("brain-fake", salesDriver)
+}
+
+func TestReadSQL(t *testing.T) {
+	db, err := sql.Open("brain-fake", "")
+	if err != nil {
+		t.Fatal("Error while opening the database", err)
+	}
+	defer db.Close()
+	d, err := ReadSQL(context.Background(), db, "SELECT * FROM sales",
+		QueryOptions{Types: map[string]string{"units": Int}})
+	if err != nil {
+		t.Fatal("Error while reading the query", err)
+	}
+	if d.Length != 3 || len(d.Metrics) != 6 {
+		t.Fatal("Expected 3 records of 6 metrics. Got", d.Length, len(d.Metrics))
+	}
+	types := map[string]string{"order_date": Time, "amount": Float,
+		"units": Int, "paid": Bool, "city": String, "note": String}
+	for name, dt := range types {
+		if m, ok := d.Metrics[name]; !ok || m.DataType != dt {
+			t.Fatal("Expected", name, "of type", dt, "Got", m, ok)
+		}
+	}
+	if d.Metrics["order_date"].DisplayName != "Order Date" {
+		t.Fatal("Expected the column name as the display name. Got",
+			d.Metrics["order_date"].DisplayName)
+	}
+	if a := d.DataF[d.Metrics["amount"].Index]; a[0] != 12.5 ||
+		!math.IsNaN(a[1]) || a[2] != 7.25 {
+		t.Fatal("Expected the amounts [12.5 NaN 7.25]. Got", a)
+	}
+	if p := d.DataB[d.Metrics["paid"].Index]; !p[0] || p[1] || !p[2] {
+		t.Fatal("Expected paid [true false true]. Got", p)
+	}
+	if day := d.DataT[d.Metrics["order_date"].Index][1]; day.Day() != 3 {
+		t.Fatal("Expected the order date 2019-01-03. Got", day)
+	}
+	for name, nulls := range map[string]int{"order_date": 1, "amount": 1,
+		"units": 1, "city": 1, "paid": 0} {
+		if n := d.NullCount(name); n != nulls {
+			t.Fatal("Expected", nulls, "missing values in", name, "Got", n)
+		}
+	}
+}
+
+func TestReadSQL_Errors(t *testing.T) {
+	db, _ := sql.Open("brain-fake", "")
+	defer db.Close()
+	ctx := context.Background()
+	if _, err := ReadSQL(ctx, db, "fail", QueryOptions{}); err == nil ||
+		err.(*Error).Code != ErrCReadFailed {
+		t.Fatal("Expected the query to fail. Got", err)
+	}
+	if _, err := ReadSQL(ctx, db, "SELECT * FROM sales", QueryOptions{
+		Types: map[string]string{"city": Float}}); err == nil ||
+		err.(*Error).Code != ErrCDataTypeMismatch ||
+		!strings.HasPrefix(err.(*Error).Message, ErrMSQLColumn) {
+		t.Fatal("Expected the city not to be parsed as float. Got", err)
+	}
+}
+
+type sqlQueryTC struct {
+	ID          string
+	Description string
+	Options     QueryOptions
+	Query       string
+	Fails       bool
+}
+
+var sqlQueryTCs = []sqlQueryTC{
+	{"1", "No limit or sample", QueryOptions{}, "SELECT * FROM sales;", false},
+	{"2", "Limit", QueryOptions{Limit: 10},
+		"SELECT * FROM (SELECT * FROM sales) brain_query LIMIT 10", false},
+	{"3", "Sample in sqlite", QueryOptions{Sample: 0.25, Dialect: DialectSQLite},
+		"SELECT * FROM (SELECT * FROM sales) brain_query WHERE " +
+			"ABS(RANDOM()) % 1000000 < 0.25 * 1000000", false},
+	{"4", "Sample and limit in postgres", QueryOptions{Limit: 5, Sample: 0.5,
+		Dialect: DialectPostgres}, "SELECT * FROM (SELECT * FROM sales) " +
+		"brain_query WHERE RANDOM() < 0.5 LIMIT 5", false},
+	{"5", "Sample of all the rows", QueryOptions{Sample: 1},
+		"SELECT * FROM sales;", false},
+	{"6", "Sample in unknown dialect", QueryOptions{Sample: 0.5}, "", true},
+}
+
+func TestReadSQL_Query(t *testing.T) {
+	db, _ := sql.Open("brain-fake", "")
+	defer db.Close()
+	for _, v := range sqlQueryTCs {
+		t.Run(v.ID, func(t *testing.T) {
+			salesDriver.query = ""
+			_, err := ReadSQL(context.Background(), db, "SELECT * FROM sales;",
+				v.Options)
+			if v.Fails {
+				if err == nil {
+					t.Fatal("Expected an error. Got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("Error while reading the query", err)
+			}
+			if salesDriver.query != v.Query {
+				t.Fatal("Expected the query", v.Query, "Got", salesDriver.query)
+			}
+		})
+	}
+}
@@ -0,0 +1,386 @@
+package insights
+
+import (
+	"math"
+
+	"github.com/cuttle-ai/brain/visualizations"
+)
+
+/*
+	This file contains the utilities and structs required for stationarity
+	insights
+*/
+
+const (
+	//minStationarityRecords is the minimum no. of records in the history of
+	//a metric required for testing its stationarity
+	minStationarityRecords = 20
+	//kpssCritical is the 5% critical value of the KPSS test for the
+	//stationarity around a level
+	kpssCritical = 0.463
+	//kpssTrendCritical is the 5% critical value of the KPSS test for the
+	//stationarity around a trend
+	kpssTrendCritical = 0.146
+)
+
+const (
+	//Stationary denotes that the series fluctuates around a constant mean
+	Stationary = "stationary"
+	//TrendStationary denotes that the series fluctuates around a
+	//deterministic trend
+	TrendStationary = "trend stationary"
+	//UnitRoot denotes that the series has a unit root. Shocks to it persist
+	//and it drifts without returning to a mean or a trend.
+	UnitRoot = "unit root"
+	//Inconclusive denotes that the tests contradict each other like both
+	//of them rejecting or neither of them rejecting their hypotheses
+	Inconclusive = "inconclusive"
+)
+
+//unitRootTests has the results of the tests of stationarity of a series
+type unitRootTests struct {
+	//adf is the t statistic of the augmented dickey fuller test with a
+	//constant. Values below adfCritical reject the unit root.
+	adf         float64
+	adfCritical float64
+	//adfTrend is the t statistic of the augmented dickey fuller test with a
+	//constant and a trend
+	adfTrend         float64
+	adfTrendCritical float64
+	//kpss is the statistic of the KPSS test for the stationarity around a
+	//level. Values above kpssCritical reject the stationarity.
+	kpss float64
+	//kpssTrend is the statistic of the KPSS test for the stationarity around
+	//a trend. Values above kpssTrendCritical reject the stationarity.
+	kpssTrend float64
+	//class is one of Stationary, TrendStationary, UnitRoot and Inconclusive
+	class string
+}
+
+//Stationarity is the stationarity insight.
+//It runs the augmented dickey fuller and the KPSS tests on a metric recorded
+//over time and finds whether the metric is stationary, trend stationary or
+//has a unit root.
+type Stationarity struct {
+	//visual has the visualization to be used for showing the metric.
+	//Line chart is used with the trend for trend stationary metrics.
+	visual visualizations.Visual
+	//relevant stores the information whether the insight is relevant or not.
+	//This property is updated after running methods like FSFA and Generate
+	relevant bool
+	dt       Dataset //dt is the dataset to be used for the insight
+	//ms is the list of metrics. First one is the metric to be tested and the
+	//second one has the time of the records.
+	ms    []Metric
+	tests unitRootTests //tests has the results of the tests
+}
+
+//New returns a new instance of the Stationarity with
+//initializations done for the given dataset
+func (s *Stationarity) New(d Dataset, ms []Metric) Insight {
+	return &Stationarity{dt: d, ms: ms}
+}
+
+//Visual returns the visualization to be used for visualizing the metric
+func (s *Stationarity) Visual() visualizations.Visual {
+	return s.visual
+}
+
+//Type returns the type string for the stationarity type of insight
+func (s *Stationarity) Type() string {
+	return STATIONARITY
+}
+
+//Relevant returns whether the insight is relevant or not for the given dataset.
+func (s *Stationarity) Relevant() bool {
+	return s.relevant
+}
+
+//Class returns whether the metric is Stationary, TrendStationary, has a
+//UnitRoot or the tests were Inconclusive
+func (s *Stationarity) Class() string {
+	return s.tests.class
+}
+
+//ADF returns the t statistics of the augmented dickey fuller tests with a
+//constant and with a constant and a trend
+func (s *Stationarity) ADF() (float64, float64) {
+	return s.tests.adf, s.tests.adfTrend
+}
+
+//KPSS returns the statistics of the KPSS tests for the stationarity around a
+//level and around a trend
+func (s *Stationarity) KPSS() (float64, float64) {
+	return s.tests.kpss, s.tests.kpssTrend
+}
+
+//FSFA does the fast statistical feasibilty analysis over the dataset
+//with the given metrics whether the stationarity can be tested.
+//It requires a float metric and a time metric with atleast
+//minStationarityRecords no. of records.
+func (s *Stationarity) FSFA() {
+	if len(s.ms) != 2 || s.ms[0].DataType != Float ||
+		!isTime(s.ms[1]) {
+		s.relevant = false
+		return
+	}
+	s.relevant = s.dt.Length >= minStationarityRecords
+}
+
+//Generate generates the stationarity insight for the datatset associated
+//with it for the provided metrics.
+//This method can only be run after running the FSFA.
+//Else the insight won't be generated
+func (s *Stationarity) Generate() {
+	//Checking whether the existing relevance of the insight
+	if !s.relevant {
+		return
+	}
+
+	//ordering the metric in time and testing it
+	ax, ok := newTimeAxis(s.dt, s.ms[1])
+	if !ok {
+		s.relevant = false
+		return
+	}
+	y, labels := ax.series(s.dt, s.ms[0])
+	if len(y) < minStationarityRecords {
+		s.relevant = false
+		return
+	}
+	//inconclusive tests are not reported
+	if s.tests, ok = stationarity(y); !ok || s.tests.class == Inconclusive {
+		s.relevant = false
+		return
+	}
+
+	s.relevant = true
+	s.visual = s.line(y, labels)
+}
+
+//line returns the line chart of the metric. The trend is added for the trend
+//stationary metrics and the mean for the stationary metrics.
+func (s *Stationarity) line(y []float64, labels []interface{}) visualizations.LineChart {
+	m, tm := s.ms[0], s.ms[1]
+	name := displayName(m)
+	desc := ""
+	switch s.tests.class {
+	case Stationary:
+		desc = name + " is stationary. It fluctuates around a constant mean"
+	case TrendStationary:
+		desc = name + " is trend stationary. It fluctuates around a trend"
+	default:
+		desc = name + " has a unit root. Its shocks persist and it drifts " +
+			"without returning to a mean or a trend. Changes of it are more " +
+			"reliable for comparisons than its values"
+	}
+	adf, kpss := s.tests.adf, s.tests.kpss
+	if s.tests.class == TrendStationary {
+		adf, kpss = s.tests.adfTrend, s.tests.kpssTrend
+	}
+	desc += " (ADF statistic " + formatFloat(adf) + ", KPSS statistic " +
+		formatFloat(kpss) + ")"
+	visual := visualizations.LineChart{
+		T: name + " is " + s.tests.class,
+		D: desc,
+		M: []visualizations.Metric{
+			{Name: tm.Name, DisplayName: tm.DisplayName, DataType: tm.DataType,
+				Dimension: 0},
+			{Name: m.Name, DisplayName: m.DisplayName, DataType: Float,
+				Dimension: 1},
+		},
+	}
+	if s.tests.class == UnitRoot {
+		visual.T = name + " has a unit root"
+	}
+
+	//finding the level or the trend around which the metric fluctuates
+	var fitted []float64
+	fname, fdisplay := m.Name+"_mean", "Mean of "+name
+	if s.tests.class == TrendStationary {
+		fname, fdisplay = m.Name+"_trend", "Trend of "+name
+	}
+	if s.tests.class != UnitRoot {
+		x := make([][]float64, len(y))
+		for i := range x {
+			x[i] = []float64{1}
+			if s.tests.class == TrendStationary {
+				x[i] = append(x[i], float64(i))
+			}
+		}
+		if fit, ok := ols(x, y); ok {
+			fitted = make([]float64, len(y))
+			for i := range y {
+				fitted[i] = y[i] - fit.residuals[i]
+			}
+			visual.M = append(visual.M, visualizations.Metric{Name: fname,
+				DisplayName: fdisplay, DataType: Float, Dimension: 1})
+		}
+	}
+	data := make([]map[string]interface{}, len(y))
+	for i, v := range y {
+		data[i] = map[string]interface{}{tm.Name: labels[i], m.Name: v}
+		if fitted != nil {
+			data[i][fname] = fitted[i]
+		}
+	}
+	visual.Dt = data
+	return visual
+}
+
+//Propose suggests the possible insights from the domain knowledge.
+//Float metrics of datasets having a time metric are proposed for testing.
+func (s *Stationarity) Propose(d Dataset) []ProposedInsight {
+	result := []ProposedInsight{}
+	tm, ok := timeMetric(d)
+	if !ok {
+		return result
+	}
+	for _, m := range sortedMetrics(d) {
+		if m.DataType != Float || m.Name == tm.Name {
+			continue
+		}
+		metrics := []Metric{m, tm}
+		result = append(result, ProposedInsight{s.New(d, metrics), metrics})
+	}
+	return result
+}
+
+//stationarity tests the stationarity of the series ordered in time and
+//classifies it. It returns false if the tests couldn't be run like for the
+//constant series.
+func stationarity(y []float64) (unitRootTests, bool) {
+	r := unitRootTests{}
+	var ok bool
+	if r.adf, r.adfCritical, ok = adf(y, false); !ok {
+		return r, false
+	}
+	if r.adfTrend, r.adfTrendCritical, ok = adf(y, true); !ok {
+		return r, false
+	}
+	if r.kpss, ok = kpss(y, false); !ok {
+		return r, false
+	}
+	if r.kpssTrend, ok = kpss(y, true); !ok {
+		return r, false
+	}
+	r.class = r.classify()
+	return r, true
+}
+
+//classify returns the class of the series from the results of the tests.
+//Series is stationary if the ADF test with a constant rejects the unit root
+//and the KPSS test doesn't reject the stationarity around a level. Similarly
+//it is trend stationary with the tests having a trend. Else the series has a
+//unit root only if the ADF test with a constant doesn't reject the unit root
+//and the KPSS test rejects the stationarity around a level. Tests both
+//rejecting or neither rejecting their hypotheses are inconclusive.
+func (r unitRootTests) classify() string {
+	switch {
+	case r.adf < r.adfCritical && r.kpss < kpssCritical:
+		return Stationary
+	case r.adfTrend < r.adfTrendCritical && r.kpssTrend < kpssTrendCritical:
+		return TrendStationary
+	case r.adf >= r.adfCritical && r.kpss >= kpssCritical:
+		return UnitRoot
+	}
+	return Inconclusive
+}
+
+//nonStationary returns whether the series clearly has a unit root. Series
+//that couldn't be tested or with inconclusive tests are not considered non
+//stationary.
+func nonStationary(y []float64) bool {
+	if len(y) < minStationarityRecords {
+		return false
+	}
+	r, ok := stationarity(y)
+	return ok && r.class == UnitRoot
+}
+
+//adf runs the augmented dickey fuller test on the series. The change of the
+//series is regressed on a constant, the trend if asked, the previous value
+//and the lagged changes. It returns the t statistic of the previous value
+//with the 5% critical value by MacKinnon (2010).
+func adf(y []float64, trend bool) (float64, float64, bool) {
+	/*
+		No. of lagged changes is the cube root of the length of the series.
+		The regression is
+		dy[t] = a + b*t + g*y[t-1] + d1*dy[t-1] + ... + dp*dy[t-p]
+		Critical value is b0 + b1/T + b2/T^2 where T is the no. of
+		observations in the regression.
+	*/
+	n := len(y)
+	p := int(math.Cbrt(float64(n - 1)))
+	dy := make([]float64, n)
+	for t := 1; t < n; t++ {
+		dy[t] = y[t] - y[t-1]
+	}
+	x := [][]float64{}
+	z := []float64{}
+	for t := p + 1; t < n; t++ {
+		row := []float64{1, y[t-1]}
+		if trend {
+			row = append(row, float64(t))
+		}
+		for j := 1; j <= p; j++ {
+			row = append(row, dy[t-j])
+		}
+		x = append(x, row)
+		z = append(z, dy[t])
+	}
+	fit, ok := ols(x, z)
+	if !ok || fit.se[1] == 0 || math.IsNaN(fit.se[1]) {
+		return 0, 0, false
+	}
+	obs := float64(len(z))
+	b := []float64{-2.86154, -2.8903, -4.234}
+	if trend {
+		b = []float64{-3.41034, -4.3904, -9.036}
+	}
+	return fit.beta[1] / fit.se[1], b[0] + b[1]/obs + b[2]/(obs*obs), true
+}
+
+//kpss runs the KPSS test for the stationarity of the series around a level
+//or around a trend. It returns the statistic of the test.
+func kpss(y []float64, trend bool) (float64, bool) {
+	/*
+		We find the residuals of the series from the level or the trend.
+		Then we find the long run variance of the residuals using the newey
+		west estimator with lags of 4 * (n/100)^(1/4).
+		Statistic is sum of squares of the partial sums of the residuals
+		divided by n^2 times the long run variance.
+	*/
+	n := len(y)
+	x := make([][]float64, n)
+	for i := range x {
+		x[i] = []float64{1}
+		if trend {
+			x[i] = append(x[i], float64(i))
+		}
+	}
+	fit, ok := ols(x, y)
+	if !ok {
+		return 0, false
+	}
+	e := fit.residuals
+	lags := int(4 * math.Pow(float64(n)/100, 0.25))
+	lrv := fit.rss / float64(n)
+	for s := 1; s <= lags; s++ {
+		w := 1 - float64(s)/float64(lags+1)
+		cov := 0.0
+		for t := s; t < n; t++ {
+			cov += e[t] * e[t-s]
+		}
+		lrv += 2 * w * cov / float64(n)
+	}
+	if lrv <= 0 {
+		return 0, false
+	}
+	sum, partial := 0.0, 0.0
+	for _, v := range e {
+		partial += v
+		sum += partial * partial
+	}
+	return sum / (float64(n) * float64(n) * lrv), true
+}
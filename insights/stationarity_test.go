@@ -0,0 +1,180 @@
+package insights
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cuttle-ai/brain/visualizations"
+)
+
+/*
+	This file contains the tests for the stationarity insight
+*/
+
+//stationarityCases returns the series of 200 days that are stationary,
+//trend stationary and have a unit root
+func stationarityCases() map[string][]float64 {
+	r := rand.New(rand.NewSource(2))
+	noise := make([]float64, 200)
+	trend := make([]float64, 200)
+	walk := make([]float64, 200)
+	for i := range noise {
+		noise[i] = 10 + r.NormFloat64()
+		trend[i] = 0.5*float64(i) + r.NormFloat64()
+		walk[i] = r.NormFloat64()
+		if i > 0 {
+			walk[i] += walk[i-1]
+		}
+	}
+	return map[string][]float64{
+		Stationary:      noise,
+		TrendStationary: trend,
+		UnitRoot:        walk,
+	}
+}
+
+//dailySeries returns a dataset with the given series recorded daily
+func dailySeries(series []float64) Dataset {
+	days := make([]float64, len(series))
+	for i := range days {
+		days[i] = float64(i)
+	}
+	d := NewDataset()
+	d.AddMetric(Metric{Name: "day", DataType: Float, Semantic: SemanticTime},
+		days)
+	d.AddMetric(Metric{Name: "value", DisplayName: "Value", DataType: Float},
+		series)
+	return d
+}
+
+func TestStationarity_New(t *testing.T) {
+	d := dailySeries([]float64{1, 2})
+	si := (&Stationarity{}).New(d, []Metric{d.Metrics["value"], d.Metrics["day"]})
+	s, ok := si.(*Stationarity)
+	if !ok {
+		t.Fatal("Expected a stationarity. Got", reflect.TypeOf(si))
+	}
+	if len(s.ms) != 2 {
+		t.Fatal("Expected 2 metrics. Got", len(s.ms))
+	}
+}
+
+func TestStationarity_Type(t *testing.T) {
+	s := &Stationarity{}
+	if s.Type() != STATIONARITY {
+		t.Fatal("Expected insight type is", STATIONARITY, "Got", s.Type())
+	}
+}
+
+func TestStationarity_FSFA(t *testing.T) {
+	d := dailySeries([]float64{1, 2})
+	s := &Stationarity{dt: d, ms: []Metric{d.Metrics["value"], d.Metrics["day"]}}
+	s.FSFA()
+	if s.Relevant() {
+		t.Fatal("Expected stationarity to be irrelevant with 2 records.",
+			"Got it as relevant")
+	}
+	d = dailySeries(stationarityCases()[Stationary])
+	s = &Stationarity{dt: d, ms: []Metric{d.Metrics["value"], d.Metrics["day"]}}
+	s.FSFA()
+	if !s.Relevant() {
+		t.Fatal("Expected stationarity to be relevant with normal conditions.",
+			"Got it as irrelevant")
+	}
+}
+
+func TestStationarity_Generate(t *testing.T) {
+	for class, series := range stationarityCases() {
+		t.Run("Testing generate with "+class+" series", func(t *testing.T) {
+			d := dailySeries(series)
+			s := (&Stationarity{}).Propose(d)[0].I.(*Stationarity)
+			s.FSFA()
+			s.Generate()
+			if !s.Relevant() {
+				t.Fatal("Expected stationarity to be relevant. Got irrelevant")
+			}
+			if s.Class() != class {
+				adf, adfTrend := s.ADF()
+				kpss, kpssTrend := s.KPSS()
+				t.Fatal("Expected", class, "Got", s.Class(), adf, adfTrend, kpss,
+					kpssTrend)
+			}
+			l, ok := s.Visual().(visualizations.LineChart)
+			if !ok {
+				t.Fatal("Expected a line chart. Got", reflect.TypeOf(s.Visual()))
+			}
+			if lines := len(l.Metrics()); (class == UnitRoot && lines != 2) ||
+				(class != UnitRoot && lines != 3) {
+				t.Fatal("Expected the mean or the trend for", class, "Got",
+					lines, "metrics")
+			}
+		})
+	}
+
+	t.Run("Testing generate with time metric", func(t *testing.T) {
+		series := stationarityCases()[UnitRoot]
+		days := make([]time.Time, len(series))
+		for i := range days {
+			days[i] = time.Date(2019, 1, 1+i, 0, 0, 0, 0, time.UTC)
+		}
+		d := NewDataset()
+		d.AddMetric(Metric{Name: "day", DataType: Time}, days)
+		d.AddMetric(Metric{Name: "value", DataType: Float}, series)
+		s := (&Stationarity{}).Propose(d)[0].I.(*Stationarity)
+		s.FSFA()
+		s.Generate()
+		if !s.Relevant() || s.Class() != UnitRoot {
+			t.Fatal("Expected", UnitRoot, "Got", s.Class(), s.Relevant())
+		}
+		l := s.Visual().(visualizations.LineChart)
+		if l.Data()[0]["day"] != days[0] {
+			t.Fatal("Expected the times as the labels. Got", l.Data()[0])
+		}
+	})
+
+	t.Run("Testing generate with constant series", func(t *testing.T) {
+		d := dailySeries(make([]float64, 50))
+		s := (&Stationarity{}).Propose(d)[0].I.(*Stationarity)
+		s.FSFA()
+		s.Generate()
+		if s.Relevant() {
+			t.Fatal("Expected stationarity to be irrelevant for constant",
+				"series. Got relevant")
+		}
+	})
+}
+
+func TestStationarity_Propose(t *testing.T) {
+	d := dailySeries([]float64{1, 2})
+	if pro := (&Stationarity{}).Propose(d); len(pro) != 1 {
+		t.Fatal("Expected 1 proposal. Got", len(pro))
+	}
+}
+
+func TestUnitRootTests_Classify(t *testing.T) {
+	//statistics rejecting and not rejecting the hypotheses of the tests
+	reject := unitRootTests{adf: -4, adfCritical: -2.9, adfTrend: -4,
+		adfTrendCritical: -3.4, kpss: 1, kpssTrend: 1}
+	accept := unitRootTests{adf: -1, adfCritical: -2.9, adfTrend: -1,
+		adfTrendCritical: -3.4, kpss: 0.1, kpssTrend: 0.1}
+	tcs := []struct {
+		name     string
+		adf      unitRootTests
+		kpss     unitRootTests
+		expected string
+	}{
+		{"ADF rejecting the unit root", reject, accept, Stationary},
+		{"KPSS rejecting the stationarity", accept, reject, UnitRoot},
+		{"both rejecting", reject, reject, Inconclusive},
+		{"neither rejecting", accept, accept, Inconclusive},
+	}
+	for _, v := range tcs {
+		r := v.adf
+		r.kpss, r.kpssTrend = v.kpss.kpss, v.kpss.kpssTrend
+		if c := r.classify(); c != v.expected {
+			t.Fatal("Expected", v.expected, "with", v.name, "Got", c)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package insights
+
+import (
+	"strconv"
+
+	"github.com/cuttle-ai/brain/visualizations"
+)
+
+/*
+	This file contains the utilities and structs required for threshold
+	insights
+*/
+
+const (
+	//minThresholdRecords is the minimum no. of records in the history of a
+	//metric required for tracking its thresholds
+	minThresholdRecords = 2
+)
+
+//ThresholdStatus is the status of a metric with respect to one of its
+//thresholds
+type ThresholdStatus struct {
+	Limit    Limit //Limit is the threshold tracked
+	Breaches int   //Breaches is the no. of records breaching the threshold
+	//Breached is true if the latest record breaches the threshold
+	Breached bool
+	//LastBreach is the time of the latest record breaching the threshold.
+	//It has the same data type as that of time metric. It is nil if the
+	//threshold was never breached.
+	LastBreach interface{}
+	//Since is the no. of records since the status last changed. If the
+	//threshold is breached, it is the no. of records since the breach
+	//started. Else it is the no. of records since the last breach.
+	//It is -1 if the threshold was never breached.
+	Since int
+	//Projected is the no. of periods ahead the metric is projected to breach
+	//the threshold. It is 0 if the threshold is breached or not projected to
+	//be breached within the forecast horizon.
+	Projected int
+	//ProjectedTime is the time of the period at which the threshold is
+	//projected to be breached. It is nil if Projected is 0.
+	ProjectedTime interface{}
+}
+
+//Threshold is the threshold insight.
+//It tracks a metric recorded over time against its thresholds. It reports
+//the breaches, the time since the last breach and the projected breaches.
+type Threshold struct {
+	//visual has the visualization to be used for showing the metric with its
+	//thresholds. Line chart with the thresholds as reference lines is used.
+	visual visualizations.Visual
+	//relevant stores the information whether the insight is relevant or not.
+	//This property is updated after running methods like FSFA and Generate
+	relevant bool
+	dt       Dataset //dt is the dataset to be used for the insight
+	//ms is the list of metrics. First one is the metric having the
+	//thresholds and the second one has the time of the records.
+	ms []Metric
+	//statuses has the status of the metric for each threshold
+	statuses []ThresholdStatus
+}
+
+//New returns a new instance of the Threshold with
+//initializations done for the given dataset
+func (t *Threshold) New(d Dataset, ms []Metric) Insight {
+	return &Threshold{dt: d, ms: ms}
+}
+
+//Visual returns the visualization to be used for visualizing the thresholds
+func (t *Threshold) Visual() visualizations.Visual {
+	return t.visual
+}
+
+//Type returns the type string for the threshold type of insight
+func (t *Threshold) Type() string {
+	return THRESHOLD
+}
+
+//Relevant returns whether the insight is relevant or not for the given dataset.
+func (t *Threshold) Relevant() bool {
+	return t.relevant
+}
+
+//Statuses returns the status of the metric for each of its thresholds
+func (t *Threshold) Statuses() []ThresholdStatus {
+	return t.statuses
+}
+
+//FSFA does the fast statistical feasibilty analysis over the dataset
+//with the given metrics whether the thresholds can be tracked.
+//It requires a float metric having thresholds and a time metric.
+func (t *Threshold) FSFA() {
+	if len(t.ms) != 2 || t.ms[0].DataType != Float ||
+		len(t.ms[0].Thresholds) == 0 || !isTime(t.ms[1]) {
+		t.relevant = false
+		return
+	}
+	t.relevant = t.dt.Length >= minThresholdRecords
+}
+
+//Generate generates the threshold insight for the datatset associated with
+//it for the provided metrics. The insight is relevant if any of the
+//thresholds was breached or is projected to be breached.
+//This method can only be run after running the FSFA.
+//Else the insight won't be generated
+func (t *Threshold) Generate() {
+	/*
+		If the insight is not relevant we won't event bother
+		to go forward.
+		We will order the metric in time and find the breaches of each
+		threshold.
+		Then we project the metric using the Holt's linear trend model and
+		find the thresholds that are projected to be breached.
+	*/
+	//Checking whether the existing relevance of the insight
+	if !t.relevant {
+		return
+	}
+
+	//ordering the metric in time
+	ax, ok := newTimeAxis(t.dt, t.ms[1])
+	if !ok {
+		t.relevant = false
+		return
+	}
+	y, labels := ax.series(t.dt, t.ms[0])
+	if len(y) < minThresholdRecords {
+		t.relevant = false
+		return
+	}
+
+	//projecting the metric
+	var point []float64
+	var times []interface{}
+	if len(y) >= minForecastRecords {
+		if model, ok := fitHolt(y); ok {
+			steps := forecastHorizon
+			if steps > len(y)/2 {
+				steps = len(y) / 2
+			}
+			point, _, _ = model.forecast(steps)
+			times = ax.future(steps)
+		}
+	}
+
+	//finding the status of the thresholds
+	t.statuses = make([]ThresholdStatus, len(t.ms[0].Thresholds))
+	t.relevant = false
+	for i, l := range t.ms[0].Thresholds {
+		s := thresholdStatus(l, y, labels)
+		if !s.Breached {
+			for h, p := range point {
+				if !l.Met(p) {
+					s.Projected, s.ProjectedTime = h+1, times[h]
+					break
+				}
+			}
+		}
+		t.statuses[i] = s
+		if s.Breaches > 0 || s.Projected > 0 {
+			t.relevant = true
+		}
+	}
+	if !t.relevant {
+		return
+	}
+	t.visual = t.line(y, labels, point, times)
+}
+
+//line returns the line chart of the metric with the thresholds as the
+//reference lines
+func (t *Threshold) line(y []float64, labels []interface{}, point []float64, times []interface{}) visualizations.LineChart {
+	/*
+		We will describe each threshold that was breached or is projected to
+		be breached.
+		The projection is added to the chart only if a threshold is projected
+		to be breached.
+	*/
+	m, tm := t.ms[0], t.ms[1]
+	name := displayName(m)
+	desc := ""
+	projected := false
+	for _, s := range t.statuses {
+		d := ""
+		switch {
+		case s.Breached:
+			d = name + " is breaching the threshold " + s.Limit.String() +
+				" for the last " + strconv.Itoa(s.Since+1) + " records"
+		case s.Projected > 0:
+			d = name + " is projected to breach the threshold " +
+				s.Limit.String() + " in " + strconv.Itoa(s.Projected) +
+				" periods at " + axisLabel(s.ProjectedTime)
+			projected = true
+		case s.Breaches > 0:
+			d = name + " last breached the threshold " + s.Limit.String() +
+				" at " + axisLabel(s.LastBreach) + ", " + strconv.Itoa(s.Since) +
+				" records ago"
+		default:
+			continue
+		}
+		if len(desc) > 0 {
+			desc += ". "
+		}
+		desc += d
+	}
+	visual := visualizations.LineChart{
+		T: name + " against its thresholds",
+		D: desc,
+		M: []visualizations.Metric{
+			{Name: tm.Name, DisplayName: tm.DisplayName, DataType: tm.DataType,
+				Dimension: 0},
+			{Name: m.Name, DisplayName: m.DisplayName, DataType: Float,
+				Dimension: 1},
+		},
+	}
+	for _, l := range m.Thresholds {
+		visual.R = append(visual.R, visualizations.ReferenceLine{
+			Label: "Threshold " + l.String(), Value: l.Value})
+	}
+
+	//adding the history and the projection
+	data := make([]map[string]interface{}, 0, len(y)+len(point))
+	for i, v := range y {
+		data = append(data, map[string]interface{}{tm.Name: labels[i], m.Name: v})
+	}
+	if projected {
+		fname := m.Name + "_forecast"
+		visual.M = append(visual.M, visualizations.Metric{Name: fname,
+			DisplayName: "Forecast of " + name, DataType: Float, Dimension: 1})
+		data[len(data)-1][fname] = y[len(y)-1]
+		for h, p := range point {
+			data = append(data, map[string]interface{}{tm.Name: times[h], fname: p})
+		}
+	}
+	visual.Dt = data
+	return visual
+}
+
+//Propose suggests the possible insights from the domain knowledge.
+//Float metrics having thresholds in the datasets having a time metric are
+//proposed for tracking.
+func (t *Threshold) Propose(d Dataset) []ProposedInsight {
+	result := []ProposedInsight{}
+	tm, ok := timeMetric(d)
+	if !ok {
+		return result
+	}
+	for _, m := range sortedMetrics(d) {
+		if m.DataType != Float || len(m.Thresholds) == 0 {
+			continue
+		}
+		metrics := []Metric{m, tm}
+		result = append(result, ProposedInsight{t.New(d, metrics), metrics})
+	}
+	return result
+}
+
+//thresholdStatus returns the status of the series ordered in time with
+//respect to the threshold. Projections are not found.
+func thresholdStatus(l Limit, y []float64, labels []interface{}) ThresholdStatus {
+	s := ThresholdStatus{Limit: l, Since: -1}
+	last := len(y) - 1
+	for i, v := range y {
+		if !l.Met(v) {
+			s.Breaches++
+			s.LastBreach = labels[i]
+		}
+	}
+	if s.Breaches == 0 {
+		return s
+	}
+	s.Breached = !l.Met(y[last])
+	//finding the record at which the status last changed
+	i := last
+	for i > 0 && l.Met(y[i-1]) == l.Met(y[last]) {
+		i--
+	}
+	if s.Breached {
+		s.Since = last - i
+	} else {
+		s.Since = last - i + 1
+	}
+	return s
+}
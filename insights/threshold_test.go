@@ -0,0 +1,159 @@
+package insights
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cuttle-ai/brain/visualizations"
+)
+
+/*
+	This file contains the tests for the threshold insight
+*/
+
+//dailyLatency returns a dataset with the daily latency having the given
+//thresholds
+func dailyLatency(latency []float64, ts ...Limit) Dataset {
+	days := make([]float64, len(latency))
+	for i := range days {
+		days[i] = float64(i + 1)
+	}
+	d := NewDataset()
+	d.AddMetric(Metric{Name: "day", DataType: Float, Semantic: SemanticTime},
+		days)
+	d.AddMetric(Metric{Name: "latency", DisplayName: "Latency",
+		DataType: Float, Thresholds: ts}, latency)
+	return d
+}
+
+func TestLimit_Met(t *testing.T) {
+	for _, c := range []struct {
+		t   Limit
+		v   float64
+		met bool
+	}{
+		{Limit{AtLeast, 3}, 3, true},
+		{Limit{Above, 3}, 3, false},
+		{Limit{AtMost, 200}, 201, false},
+		{Limit{Below, 200}, 199, true},
+		{Limit{"~", 200}, 199, false},
+	} {
+		if c.t.Met(c.v) != c.met {
+			t.Fatal("Expected", c.v, c.t, "to be", c.met)
+		}
+	}
+}
+
+func TestThreshold_New(t *testing.T) {
+	d := dailyLatency([]float64{1, 2})
+	bi := (&Threshold{}).New(d, []Metric{d.Metrics["latency"], d.Metrics["day"]})
+	b, ok := bi.(*Threshold)
+	if !ok {
+		t.Fatal("Expected a threshold. Got", reflect.TypeOf(bi))
+	}
+	if len(b.ms) != 2 {
+		t.Fatal("Expected 2 metrics. Got", len(b.ms))
+	}
+}
+
+func TestThreshold_Type(t *testing.T) {
+	b := &Threshold{}
+	if b.Type() != THRESHOLD {
+		t.Fatal("Expected insight type is", THRESHOLD, "Got", b.Type())
+	}
+}
+
+func TestThreshold_FSFA(t *testing.T) {
+	d := dailyLatency([]float64{1, 2})
+	b := &Threshold{dt: d, ms: []Metric{d.Metrics["latency"], d.Metrics["day"]}}
+	b.FSFA()
+	if b.Relevant() {
+		t.Fatal("Expected threshold to be irrelevant without thresholds.",
+			"Got it as relevant")
+	}
+	d = dailyLatency([]float64{1, 2}, Limit{AtMost, 200})
+	b = &Threshold{dt: d, ms: []Metric{d.Metrics["latency"], d.Metrics["day"]}}
+	b.FSFA()
+	if !b.Relevant() {
+		t.Fatal("Expected threshold to be relevant with normal conditions.",
+			"Got it as irrelevant")
+	}
+}
+
+func TestThreshold_Generate(t *testing.T) {
+	t.Run("Testing generate with breaches", func(t *testing.T) {
+		d := dailyLatency([]float64{150, 250, 260, 150, 160, 150, 170, 150,
+			210, 220}, Limit{AtMost, 200}, Limit{AtLeast, 100})
+		b := (&Threshold{}).Propose(d)[0].I.(*Threshold)
+		b.FSFA()
+		b.Generate()
+		if !b.Relevant() {
+			t.Fatal("Expected threshold to be relevant. Got irrelevant")
+		}
+		s := b.Statuses()[0]
+		if s.Breaches != 4 || !s.Breached || s.Since != 1 ||
+			s.LastBreach != float64(10) || s.Projected != 0 {
+			t.Fatal("Expected 4 breaches with the last 2 records breaching.",
+				"Got", s)
+		}
+		if s := b.Statuses()[1]; s.Breaches != 0 || s.Since != -1 {
+			t.Fatal("Expected no breaches of >= 100. Got", s)
+		}
+		l, ok := b.Visual().(visualizations.LineChart)
+		if !ok {
+			t.Fatal("Expected a line chart. Got", reflect.TypeOf(b.Visual()))
+		}
+		if len(l.ReferenceLines()) != 2 {
+			t.Fatal("Expected 2 reference lines. Got", l.ReferenceLines())
+		}
+	})
+
+	t.Run("Testing generate with projected breach", func(t *testing.T) {
+		d := dailyLatency([]float64{100, 110, 120, 130, 140, 150, 160, 170, 180,
+			190}, Limit{AtMost, 200})
+		b := (&Threshold{}).Propose(d)[0].I.(*Threshold)
+		b.FSFA()
+		b.Generate()
+		if !b.Relevant() {
+			t.Fatal("Expected threshold to be relevant. Got irrelevant")
+		}
+		s := b.Statuses()[0]
+		if s.Breaches != 0 || s.Projected != 2 || s.ProjectedTime != float64(12) {
+			t.Fatal("Expected breach to be projected on day 12. Got", s)
+		}
+		if len(b.Visual().Data()) != 10+5 {
+			t.Fatal("Expected the projection in the chart. Got",
+				len(b.Visual().Data()), "records")
+		}
+	})
+
+	t.Run("Testing generate without breaches", func(t *testing.T) {
+		d := dailyLatency([]float64{100, 100, 100}, Limit{AtMost, 200})
+		b := (&Threshold{}).Propose(d)[0].I.(*Threshold)
+		b.FSFA()
+		b.Generate()
+		if b.Relevant() {
+			t.Fatal("Expected threshold to be irrelevant without breaches.",
+				"Got relevant")
+		}
+	})
+}
+
+func TestThreshold_Propose(t *testing.T) {
+	d := dailyLatency([]float64{1, 2}, Limit{AtMost, 200})
+	if pro := (&Threshold{}).Propose(d); len(pro) != 1 {
+		t.Fatal("Expected 1 proposal. Got", len(pro))
+	}
+	d = dailyLatency([]float64{1, 2})
+	if pro := (&Threshold{}).Propose(d); len(pro) != 0 {
+		t.Fatal("Expected no proposals. Got", len(pro))
+	}
+}
+
+func TestThresholdStatus(t *testing.T) {
+	labels := []interface{}{"a", "b", "c", "d"}
+	s := thresholdStatus(Limit{Below, 10}, []float64{20, 5, 5, 5}, labels)
+	if s.Breached || s.Breaches != 1 || s.LastBreach != "a" || s.Since != 3 {
+		t.Fatal("Expected the last breach 3 records ago at a. Got", s)
+	}
+}
@@ -0,0 +1,474 @@
+package insights
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+/*
+	This file contains the utilities required for the insights that work on
+	the records ordered in time
+*/
+
+const (
+	//granularityDay is the granularity of the periods spanning a day
+	granularityDay = "day"
+	//granularityWeek is the granularity of the periods spanning a week
+	//starting on monday
+	granularityWeek = "week"
+	//granularityMonth is the granularity of the periods spanning a month
+	granularityMonth = "month"
+	//granularityQuarter is the granularity of the periods spanning a quarter
+	granularityQuarter = "quarter"
+	//granularityYear is the granularity of the periods spanning a year
+	granularityYear = "year"
+)
+
+//timeLayouts are the layouts tried while parsing the time stored as strings
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"2006/01/02",
+	"01/02/2006",
+	"2006-01",
+	"Jan 2006",
+	"2006",
+}
+
+//parseTime parses the time stored as a string. It returns the parsed time
+//and the layout with which the time was parsed. If none of the timeLayouts
+//could parse the string, false is returned.
+func parseTime(s string) (time.Time, string, bool) {
+	for _, l := range timeLayouts {
+		t, err := time.Parse(l, s)
+		if err == nil {
+			return t, l, true
+		}
+	}
+	return time.Time{}, "", false
+}
+
+//timeMetric returns the metric having the time of the records in the dataset.
+//If there are more than one such metrics, the one with the least name is
+//returned. Metrics with the time semantic are preferred over the metrics of
+//the Time data type without a semantic. If the dataset doesn't have any,
+//false is returned.
+func timeMetric(d Dataset) (Metric, bool) {
+	if m, ok := metricWithSemantic(d, SemanticTime); ok {
+		return m, true
+	}
+	for _, m := range sortedMetrics(d) {
+		if isTime(m) {
+			return m, true
+		}
+	}
+	return Metric{}, false
+}
+
+//isTime returns whether the metric has the time of the records. It is true
+//for the metrics with the time semantic and the metrics of the Time data type
+//without a semantic.
+func isTime(m Metric) bool {
+	return m.Semantic == SemanticTime || (m.Semantic == "" && m.DataType == Time)
+}
+
+//timeAxis is the time axis of a dataset. It has the order of the records in
+//time and the time of the records.
+type timeAxis struct {
+	m Metric //m is the metric having the time of the records
+	//order has the indices of the records in the dataset sorted by time
+	order []int
+	//labels has the time of the records in the order. They are of the same
+	//data type as of the metric.
+	labels []interface{}
+	//times has the time of the records in the order if the metric is of the
+	//Time data type or stores the time as strings that can be parsed.
+	//Else it will be nil.
+	times  []time.Time
+	layout string //layout is the layout with which times were parsed
+}
+
+//newTimeAxis returns the time axis of the dataset using the given time
+//metric. Records with missing time are left out of the time axis. Empty
+//strings are also considered missing. If the metric doesn't have data in
+//the dataset, false is returned.
+func newTimeAxis(d Dataset, tm Metric) (timeAxis, bool) {
+	/*
+		We will leave out the records with missing time.
+		Based on the data type of the metric we will sort the records.
+		Float and Time metrics are sorted by their values.
+		String metrics are sorted by the parsed time if all the strings
+		could be parsed. Else they are sorted lexicographically.
+	*/
+	ax := timeAxis{m: tm, order: []int{}}
+	bits, nullable := d.Validity[tm.Name]
+	missing := func(i int) bool {
+		return nullable && !bits.Get(i)
+	}
+
+	switch tm.DataType {
+	case Float:
+		if tm.Index >= len(d.DataF) || int64(len(d.DataF[tm.Index])) != d.Length {
+			return ax, false
+		}
+		vals := d.DataF[tm.Index]
+		for i, v := range vals {
+			if !missing(i) && !math.IsNaN(v) {
+				ax.order = append(ax.order, i)
+			}
+		}
+		sort.SliceStable(ax.order, func(i, j int) bool {
+			return vals[ax.order[i]] < vals[ax.order[j]]
+		})
+		for _, i := range ax.order {
+			ax.labels = append(ax.labels, vals[i])
+		}
+	case String:
+		if tm.Index >= len(d.DataS) || int64(len(d.DataS[tm.Index])) != d.Length {
+			return ax, false
+		}
+		vals := d.DataS[tm.Index]
+		times := make([]time.Time, len(vals))
+		parsed := true
+		for i, v := range vals {
+			if missing(i) || len(v) == 0 {
+				continue
+			}
+			ax.order = append(ax.order, i)
+			if !parsed {
+				continue
+			}
+			t, l, ok := parseTime(v)
+			if !ok {
+				parsed = false
+				continue
+			}
+			times[i] = t
+			ax.layout = l
+		}
+		if parsed {
+			sort.SliceStable(ax.order, func(i, j int) bool {
+				return times[ax.order[i]].Before(times[ax.order[j]])
+			})
+		} else {
+			sort.SliceStable(ax.order, func(i, j int) bool {
+				return vals[ax.order[i]] < vals[ax.order[j]]
+			})
+		}
+		for _, i := range ax.order {
+			ax.labels = append(ax.labels, vals[i])
+			if parsed {
+				ax.times = append(ax.times, times[i])
+			}
+		}
+	case Time:
+		if tm.Index >= len(d.DataT) || int64(len(d.DataT[tm.Index])) != d.Length {
+			return ax, false
+		}
+		vals := d.DataT[tm.Index]
+		for i := range vals {
+			if !missing(i) {
+				ax.order = append(ax.order, i)
+			}
+		}
+		sort.SliceStable(ax.order, func(i, j int) bool {
+			return vals[ax.order[i]].Before(vals[ax.order[j]])
+		})
+		for _, i := range ax.order {
+			ax.labels = append(ax.labels, vals[i])
+			ax.times = append(ax.times, vals[i])
+		}
+	default:
+		return ax, false
+	}
+	return ax, true
+}
+
+//label returns the label of the time in the time axis. It is the time itself
+//for the metrics of the Time data type. Else it is formatted with the layout
+//of the time axis.
+func (t timeAxis) label(tm time.Time) interface{} {
+	if t.m.DataType == Time {
+		return tm
+	}
+	return tm.Format(t.layout)
+}
+
+//future returns the labels of the given no. of periods following the last
+//record in the time axis. The step between the periods is the median step
+//between the records. If the time axis can't be extended, labels like +1, +2
+//are returned.
+func (t timeAxis) future(steps int) []interface{} {
+	/*
+		For float time we extend the last value by the median step.
+		For the parsed times, if the records are separated by whole months
+		we extend the last time by the months. Else by the median duration.
+		Else we return the relative labels.
+	*/
+	result := make([]interface{}, steps)
+	n := len(t.labels)
+
+	//float time
+	if t.m.DataType == Float && n > 1 {
+		diffs := []float64{}
+		for i := 1; i < n; i++ {
+			diffs = append(diffs, t.labels[i].(float64)-t.labels[i-1].(float64))
+		}
+		step := median(diffs)
+		if step <= 0 {
+			step = 1
+		}
+		for h := range result {
+			result[h] = t.labels[n-1].(float64) + float64(h+1)*step
+		}
+		return result
+	}
+
+	//parsed time
+	if len(t.times) > 1 {
+		months := []float64{}
+		durations := []float64{}
+		monthly := true
+		for i := 1; i < n; i++ {
+			a, b := t.times[i-1], t.times[i]
+			durations = append(durations, float64(b.Sub(a)))
+			mdiff := (b.Year()-a.Year())*12 + int(b.Month()) - int(a.Month())
+			if !a.AddDate(0, mdiff, 0).Equal(b) {
+				monthly = false
+			}
+			months = append(months, float64(mdiff))
+		}
+		last := t.times[n-1]
+		if step := int(median(months)); monthly && step > 0 {
+			for h := range result {
+				result[h] = t.label(last.AddDate(0, (h+1)*step, 0))
+			}
+			return result
+		}
+		if step := time.Duration(median(durations)); step > 0 {
+			for h := range result {
+				result[h] = t.label(last.Add(time.Duration(h+1) * step))
+			}
+			return result
+		}
+	}
+
+	//relative labels
+	for h := range result {
+		result[h] = "+" + strconv.Itoa(h+1)
+	}
+	return result
+}
+
+//series returns the values of the float metric for the records in the order
+//of the time axis. Records having NaN values are skipped. The labels of the
+//records that were kept are also returned.
+func (t timeAxis) series(d Dataset, m Metric) ([]float64, []interface{}) {
+	vals := []float64{}
+	labels := []interface{}{}
+	if m.DataType != Float || m.Index >= len(d.DataF) ||
+		int64(len(d.DataF[m.Index])) != d.Length {
+		return vals, labels
+	}
+	for i, row := range t.order {
+		v := d.DataF[m.Index][row]
+		if math.IsNaN(v) {
+			continue
+		}
+		vals = append(vals, v)
+		labels = append(labels, t.labels[i])
+	}
+	return vals, labels
+}
+
+//pairs returns the values of the two float metrics for the records in the
+//order of the time axis. Records having NaN value in either of the metrics
+//are skipped. The labels of the records that were kept are also returned.
+func (t timeAxis) pairs(d Dataset, a, b Metric) ([]float64, []float64, []interface{}) {
+	xs, ys := []float64{}, []float64{}
+	labels := []interface{}{}
+	for _, m := range []Metric{a, b} {
+		if m.DataType != Float || m.Index >= len(d.DataF) ||
+			int64(len(d.DataF[m.Index])) != d.Length {
+			return xs, ys, labels
+		}
+	}
+	for i, row := range t.order {
+		x, y := d.DataF[a.Index][row], d.DataF[b.Index][row]
+		if math.IsNaN(x) || math.IsNaN(y) {
+			continue
+		}
+		xs = append(xs, x)
+		ys = append(ys, y)
+		labels = append(labels, t.labels[i])
+	}
+	return xs, ys, labels
+}
+
+//timeBuckets returns the index of the period having each record as per the
+//time metric. Float times are floored to get the periods. Times stored as
+//strings or of the Time data type are bucketed by the granularity suitable
+//for their span. The granularity is returned with the periods and it is
+//empty for float times. Records whose time couldn't be parsed are marked as
+//invalid. If the metric doesn't have data in the dataset, false is returned.
+func timeBuckets(d Dataset, tm Metric) ([]int, []bool, string, bool) {
+	n := int(d.Length)
+	periods := make([]int, n)
+	valid := make([]bool, n)
+
+	//float times
+	if tm.DataType == Float {
+		if tm.Index >= len(d.DataF) || len(d.DataF[tm.Index]) != n {
+			return nil, nil, "", false
+		}
+		for i, v := range d.DataF[tm.Index] {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				continue
+			}
+			periods[i], valid[i] = int(math.Floor(v)), true
+		}
+		return periods, valid, "", true
+	}
+
+	//string times. Times of the Time data type are formatted by the keys.
+	vals, ok := keys(d, tm)
+	if !ok {
+		return nil, nil, "", false
+	}
+	times := make([]time.Time, n)
+	var first, last time.Time
+	for i, v := range vals {
+		t, _, ok := parseTime(v)
+		if !ok {
+			continue
+		}
+		times[i], valid[i] = t, true
+		if first.IsZero() || t.Before(first) {
+			first = t
+		}
+		if last.IsZero() || t.After(last) {
+			last = t
+		}
+	}
+	if first.IsZero() {
+		return nil, nil, "", false
+	}
+	g := granularity(last.Sub(first))
+	for i, t := range times {
+		if valid[i] {
+			periods[i] = periodIndex(t, g)
+		}
+	}
+	return periods, valid, g, true
+}
+
+//bucketLabel returns the label of the period returned by timeBuckets
+func bucketLabel(p int, g string) string {
+	if g == "" {
+		return strconv.Itoa(p)
+	}
+	return periodLabel(p, g)
+}
+
+//median returns the median of the values. The given values are not
+//modified. Median of an empty slice is NaN.
+func median(vals []float64) float64 {
+	if len(vals) == 0 {
+		return math.NaN()
+	}
+	s := append([]float64{}, vals...)
+	sort.Float64s(s)
+	if len(s)%2 == 1 {
+		return s[len(s)/2]
+	}
+	return (s[len(s)/2-1] + s[len(s)/2]) / 2
+}
+
+//granularity returns the granularity of the periods suitable for bucketing
+//times spanning the given duration. It is chosen such that there are enough
+//periods without making each one too sparse.
+func granularity(span time.Duration) string {
+	day := 24 * time.Hour
+	switch {
+	case span <= 31*day:
+		return granularityDay
+	case span <= 180*day:
+		return granularityWeek
+	case span <= 3*365*day:
+		return granularityMonth
+	case span <= 10*365*day:
+		return granularityQuarter
+	default:
+		return granularityYear
+	}
+}
+
+//epochMonday is the monday from which the weeks are counted
+var epochMonday = time.Date(1970, time.January, 5, 0, 0, 0, 0, time.UTC)
+
+//periodIndex returns the index of the period of the given granularity having
+//the time. Indices of consecutive periods differ by one.
+func periodIndex(t time.Time, g string) int {
+	switch g {
+	case granularityDay:
+		return floorDiv(int(t.Sub(epochMonday).Hours()), 24)
+	case granularityWeek:
+		return floorDiv(int(t.Sub(epochMonday).Hours()), 24*7)
+	case granularityQuarter:
+		return t.Year()*4 + (int(t.Month())-1)/3
+	case granularityYear:
+		return t.Year()
+	default:
+		return t.Year()*12 + int(t.Month()) - 1
+	}
+}
+
+//periodLabel returns the label of the period of the given granularity with
+//the index
+func periodLabel(i int, g string) string {
+	switch g {
+	case granularityDay:
+		return epochMonday.AddDate(0, 0, i).Format("2006-01-02")
+	case granularityWeek:
+		return epochMonday.AddDate(0, 0, i*7).Format("2006-01-02")
+	case granularityQuarter:
+		return strconv.Itoa(floorDiv(i, 4)) + "-Q" + strconv.Itoa(i-floorDiv(i, 4)*4+1)
+	case granularityYear:
+		return strconv.Itoa(i)
+	default:
+		return time.Date(floorDiv(i, 12), time.Month(i-floorDiv(i, 12)*12+1), 1,
+			0, 0, 0, 0, time.UTC).Format("2006-01")
+	}
+}
+
+//periodStart returns the start of the period of the given granularity with
+//the index
+func periodStart(i int, g string) time.Time {
+	switch g {
+	case granularityDay:
+		return epochMonday.AddDate(0, 0, i)
+	case granularityWeek:
+		return epochMonday.AddDate(0, 0, i*7)
+	case granularityQuarter:
+		return time.Date(floorDiv(i, 4), time.Month((i-floorDiv(i, 4)*4)*3+1), 1,
+			0, 0, 0, 0, time.UTC)
+	case granularityYear:
+		return time.Date(i, time.January, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(floorDiv(i, 12), time.Month(i-floorDiv(i, 12)*12+1), 1,
+			0, 0, 0, 0, time.UTC)
+	}
+}
+
+//floorDiv returns the floor of a / b
+func floorDiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
@@ -0,0 +1,240 @@
+package insights
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+/*
+	This file contains the tests for the time series utilities
+*/
+
+func TestParseTime(t *testing.T) {
+	for _, s := range []string{"2019-01-02", "2019-01", "2019-01-02 10:00:00",
+		"2019-01-02T10:00:00Z"} {
+		if _, _, ok := parseTime(s); !ok {
+			t.Fatal("Expected", s, "to be parsed as time")
+		}
+	}
+	if _, _, ok := parseTime("Tesla"); ok {
+		t.Fatal("Expected Tesla not to be parsed as time")
+	}
+}
+
+func TestTimeMetric(t *testing.T) {
+	d := NewDataset()
+	d.AddMetric(Metric{Name: "sales", DataType: Float}, []float64{1, 2})
+	if _, ok := timeMetric(d); ok {
+		t.Fatal("Expected no time metric in the dataset. Got one")
+	}
+	d.AddMetric(Metric{Name: "month", DataType: String, Semantic: SemanticTime},
+		[]string{"2019-02", "2019-01"})
+	tm, ok := timeMetric(d)
+	if !ok || tm.Name != "month" {
+		t.Fatal("Expected month as the time metric. Got", tm.Name, ok)
+	}
+
+	d = NewDataset()
+	jan := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	d.AddMetric(Metric{Name: "created", DataType: Time, Semantic: SemanticSignup},
+		[]time.Time{jan, jan})
+	if _, ok := timeMetric(d); ok {
+		t.Fatal("Expected the signup time not to be the time metric. Got one")
+	}
+	d.AddMetric(Metric{Name: "day", DataType: Time}, []time.Time{jan, jan})
+	if tm, ok := timeMetric(d); !ok || tm.Name != "day" {
+		t.Fatal("Expected day as the time metric. Got", tm.Name, ok)
+	}
+}
+
+type timeAxisTC struct {
+	ID          string
+	Description string
+	Metric      Metric
+	Data        interface{}
+	Order       []int
+	Future      []interface{}
+}
+
+var timeAxisTCs = []timeAxisTC{
+	{"1", "Float time", Metric{Name: "year", DataType: Float},
+		[]float64{2012, 2010, 2014}, []int{1, 0, 2},
+		[]interface{}{float64(2016), float64(2018)}},
+	{"2", "Monthly string time", Metric{Name: "month", DataType: String},
+		[]string{"2019-03", "2019-01", "2019-02"}, []int{1, 2, 0},
+		[]interface{}{"2019-04", "2019-05"}},
+	{"3", "Daily string time", Metric{Name: "day", DataType: String},
+		[]string{"2019-01-03", "2019-01-01", "2019-01-02"}, []int{1, 2, 0},
+		[]interface{}{"2019-01-04", "2019-01-05"}},
+	{"4", "Unparsable string time", Metric{Name: "period", DataType: String},
+		[]string{"c", "a", "b"}, []int{1, 2, 0},
+		[]interface{}{"+1", "+2"}},
+	{"5", "Monthly time", Metric{Name: "month", DataType: Time},
+		[]time.Time{
+			time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2019, 2, 1, 0, 0, 0, 0, time.UTC),
+		}, []int{1, 2, 0},
+		[]interface{}{
+			time.Date(2019, 4, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC),
+		}},
+}
+
+func TestTimeAxis(t *testing.T) {
+	for _, v := range timeAxisTCs {
+		t.Run(v.ID, func(t *testing.T) {
+			d := NewDataset()
+			if err := d.AddMetric(v.Metric, v.Data); err != nil {
+				t.Fatal("Error while adding metric for", v.ID, err)
+			}
+			ax, ok := newTimeAxis(d, d.Metrics[v.Metric.Name])
+			if !ok {
+				t.Fatal("Expected a time axis for", v.ID)
+			}
+			for i := range v.Order {
+				if ax.order[i] != v.Order[i] {
+					t.Fatal("Expected order", v.Order, "Got", ax.order)
+				}
+			}
+			f := ax.future(len(v.Future))
+			for i := range v.Future {
+				if f[i] != v.Future[i] {
+					t.Fatal("Expected future", v.Future, "Got", f)
+				}
+			}
+		})
+	}
+}
+
+func TestTimeAxis_Missing(t *testing.T) {
+	//missing times are left out. Null month of the strings shouldn't stop
+	//the others from being parsed.
+	valid := []bool{true, false, true, true}
+	tcs := []struct {
+		m    Metric
+		data interface{}
+	}{
+		{Metric{Name: "year", DataType: Float},
+			[]float64{2014, math.NaN(), 2012, 2013}},
+		{Metric{Name: "month", DataType: String},
+			[]string{"2019-03", "", "2019-01", "2019-02"}},
+		{Metric{Name: "month", DataType: Time}, []time.Time{
+			time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC), {},
+			time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2019, 2, 1, 0, 0, 0, 0, time.UTC),
+		}},
+	}
+	for _, v := range tcs {
+		d := NewDataset()
+		if err := d.AddNullableMetric(v.m, v.data, valid); err != nil {
+			t.Fatal("Error while adding metric", v.m.DataType, err)
+		}
+		ax, ok := newTimeAxis(d, d.Metrics[v.m.Name])
+		if !ok || !reflect.DeepEqual(ax.order, []int{2, 3, 0}) ||
+			len(ax.labels) != 3 {
+			t.Fatal("Expected order [2 3 0] for", v.m.DataType, "Got", ax.order)
+		}
+		if v.m.DataType != Float && len(ax.times) != 3 {
+			t.Fatal("Expected the times to be parsed for", v.m.DataType, "Got",
+				ax.times)
+		}
+	}
+}
+
+func TestTimeAxis_series(t *testing.T) {
+	d := NewDataset()
+	d.AddMetric(Metric{Name: "year", DataType: Float}, []float64{3, 1, 2})
+	d.AddMetric(Metric{Name: "sales", DataType: Float},
+		[]float64{30, math.NaN(), 20})
+	ax, _ := newTimeAxis(d, d.Metrics["year"])
+	vals, labels := ax.series(d, d.Metrics["sales"])
+	if len(vals) != 2 || vals[0] != 20 || vals[1] != 30 {
+		t.Fatal("Expected the series [20 30]. Got", vals)
+	}
+	if labels[0] != float64(2) || labels[1] != float64(3) {
+		t.Fatal("Expected the labels [2 3]. Got", labels)
+	}
+}
+
+func TestMedian(t *testing.T) {
+	if m := median([]float64{3, 1, 2}); m != 2 {
+		t.Fatal("Expected median 2. Got", m)
+	}
+	if m := median([]float64{4, 1, 2, 3}); m != 2.5 {
+		t.Fatal("Expected median 2.5. Got", m)
+	}
+	if m := median([]float64{}); !math.IsNaN(m) {
+		t.Fatal("Expected median NaN. Got", m)
+	}
+}
+
+func TestGranularity(t *testing.T) {
+	day := 24 * time.Hour
+	for span, g := range map[time.Duration]string{
+		7 * day:         granularityDay,
+		90 * day:        granularityWeek,
+		365 * day:       granularityMonth,
+		5 * 365 * day:   granularityQuarter,
+		100 * 365 * day: granularityYear,
+	} {
+		if granularity(span) != g {
+			t.Fatal("Expected granularity", g, "for", span, "Got",
+				granularity(span))
+		}
+	}
+}
+
+func TestPeriodIndex(t *testing.T) {
+	tm := time.Date(2019, time.February, 14, 10, 0, 0, 0, time.UTC)
+	for g, l := range map[string]string{
+		granularityDay:     "2019-02-14",
+		granularityWeek:    "2019-02-11",
+		granularityMonth:   "2019-02",
+		granularityQuarter: "2019-Q1",
+		granularityYear:    "2019",
+	} {
+		i := periodIndex(tm, g)
+		if periodLabel(i, g) != l {
+			t.Fatal("Expected label", l, "for", g, "Got", periodLabel(i, g))
+		}
+		if periodIndex(tm.AddDate(1, 0, 0), g) <= i {
+			t.Fatal("Expected the period index to increase with time for", g)
+		}
+	}
+	old := time.Date(1969, time.December, 31, 0, 0, 0, 0, time.UTC)
+	if l := periodLabel(periodIndex(old, granularityWeek), granularityWeek); l != "1969-12-29" {
+		t.Fatal("Expected the week 1969-12-29. Got", l)
+	}
+}
+
+func TestTimeBuckets(t *testing.T) {
+	d := NewDataset()
+	d.AddMetric(Metric{Name: "day", DataType: String},
+		[]string{"2019-01-01", "bad", "2019-03-15", "2019-12-30"})
+	ps, valid, g, ok := timeBuckets(d, d.Metrics["day"])
+	if !ok || g != granularityMonth {
+		t.Fatal("Expected monthly buckets. Got", g, ok)
+	}
+	if valid[1] || !valid[0] || ps[2]-ps[0] != 2 ||
+		bucketLabel(ps[3], g) != "2019-12" {
+		t.Fatal("Expected the months 2019-01, 2019-03 and 2019-12. Got", ps,
+			valid)
+	}
+	d = NewDataset()
+	d.AddMetric(Metric{Name: "day", DataType: Time}, []time.Time{
+		time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), {},
+		time.Date(2019, 12, 30, 0, 0, 0, 0, time.UTC)})
+	ps, valid, g, ok = timeBuckets(d, d.Metrics["day"])
+	if !ok || g != granularityMonth || valid[1] || ps[2]-ps[0] != 11 {
+		t.Fatal("Expected monthly buckets of the times. Got", ps, valid, g)
+	}
+	d = NewDataset()
+	d.AddMetric(Metric{Name: "year", DataType: Float}, []float64{1.5, 2})
+	ps, _, g, ok = timeBuckets(d, d.Metrics["year"])
+	if !ok || g != "" || ps[0] != 1 || bucketLabel(ps[1], g) != "2" {
+		t.Fatal("Expected the periods 1 and 2. Got", ps, g)
+	}
+}
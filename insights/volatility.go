@@ -0,0 +1,323 @@
+package insights
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/cuttle-ai/brain/visualizations"
+	"github.com/gonum/stat"
+	"github.com/gonum/stat/distuv"
+)
+
+/*
+	This file contains the utilities and structs required for volatility
+	insights
+*/
+
+const (
+	//minVolatilityRecords is the minimum no. of records in the history of a
+	//metric required for finding the shifts in its volatility
+	minVolatilityRecords = 30
+	//volatilityWindow is the no. of changes over which the rolling
+	//volatility is found
+	volatilityWindow = 10
+	//minRegimeRecords is the minimum no. of changes in a regime
+	minRegimeRecords = 10
+	//maxRegimeSplits is the no. of times the regimes are split further.
+	//There will be atmost 2^maxRegimeSplits regimes.
+	maxRegimeSplits = 2
+	//maxRegimeCandidates is the maximum no. of points tried for splitting
+	//a regime
+	maxRegimeCandidates = 100
+	//volatilityAlpha is the significance level of the tests between the
+	//regimes. It is low as the best of the many split points is tested.
+	volatilityAlpha = 0.001
+)
+
+//VolatilityRegime is a period in which the volatility of the metric is stable
+type VolatilityRegime struct {
+	//Start and End are the time of the first and the last record in the
+	//regime. They have the same data type as that of time metric.
+	Start interface{}
+	End   interface{}
+	//StdDev is the standard deviation of the changes of the metric in the
+	//regime
+	StdDev float64
+}
+
+//Volatility is the volatility insight.
+//It finds the rolling volatility of a metric recorded over time and the
+//regimes in which the variance of its changes shifts significantly.
+//Volatility is measured on the changes between consecutive records so that
+//the trend of the metric doesn't affect it.
+type Volatility struct {
+	//visual has the visualization to be used for showing the volatility.
+	//Line chart with the regimes shaded is used.
+	visual visualizations.Visual
+	//relevant stores the information whether the insight is relevant or not.
+	//This property is updated after running methods like FSFA and Generate
+	relevant bool
+	dt       Dataset //dt is the dataset to be used for the insight
+	//ms is the list of metrics. First one is the metric whose volatility is
+	//to be found and the second one has the time of the records.
+	ms      []Metric
+	regimes []VolatilityRegime //regimes has the regimes in time order
+}
+
+//New returns a new instance of the Volatility with
+//initializations done for the given dataset
+func (v *Volatility) New(d Dataset, ms []Metric) Insight {
+	return &Volatility{dt: d, ms: ms}
+}
+
+//Visual returns the visualization to be used for visualizing the volatility
+func (v *Volatility) Visual() visualizations.Visual {
+	return v.visual
+}
+
+//Type returns the type string for the volatility type of insight
+func (v *Volatility) Type() string {
+	return VOLATILITY
+}
+
+//Relevant returns whether the insight is relevant or not for the given dataset.
+func (v *Volatility) Relevant() bool {
+	return v.relevant
+}
+
+//Regimes returns the volatility regimes of the metric in time order
+func (v *Volatility) Regimes() []VolatilityRegime {
+	return v.regimes
+}
+
+//Change returns the ratio of the volatility of the last regime to that of the
+//regime before it. Ratio above 1 means that the metric has become noisier.
+//It should be called only if the insight is relevant.
+func (v *Volatility) Change() float64 {
+	n := len(v.regimes)
+	return v.regimes[n-1].StdDev / v.regimes[n-2].StdDev
+}
+
+//FSFA does the fast statistical feasibilty analysis over the dataset
+//with the given metrics whether the volatility can be analysed.
+//It requires a float metric and a time metric with atleast
+//minVolatilityRecords no. of records.
+func (v *Volatility) FSFA() {
+	if len(v.ms) != 2 || v.ms[0].DataType != Float ||
+		!isTime(v.ms[1]) {
+		v.relevant = false
+		return
+	}
+	v.relevant = v.dt.Length >= minVolatilityRecords
+}
+
+//Generate generates the volatility insight for the datatset associated with
+//it for the provided metrics. The insight is relevant only if the volatility
+//of the metric shifts.
+//This method can only be run after running the FSFA.
+//Else the insight won't be generated
+func (v *Volatility) Generate() {
+	/*
+		If the insight is not relevant we won't event bother
+		to go forward.
+		We will order the metric in time and find the changes between
+		consecutive records.
+		Then we split the changes into regimes where the variance shifts
+		significantly as per the Brown-Forsythe test.
+		Then we create the visual with the rolling volatility.
+	*/
+	//Checking whether the existing relevance of the insight
+	if !v.relevant {
+		return
+	}
+
+	//ordering the metric in time
+	ax, ok := newTimeAxis(v.dt, v.ms[1])
+	if !ok {
+		v.relevant = false
+		return
+	}
+	y, labels := ax.series(v.dt, v.ms[0])
+	if len(y) < minVolatilityRecords {
+		v.relevant = false
+		return
+	}
+	changes := differences(y)
+
+	//finding the regimes
+	bounds := append([]int{0}, varianceShifts(changes, 0, len(changes), 0)...)
+	bounds = append(bounds, len(changes))
+	if len(bounds) < 3 {
+		v.relevant = false
+		return
+	}
+	v.regimes = make([]VolatilityRegime, len(bounds)-1)
+	for i := range v.regimes {
+		lo, hi := bounds[i], bounds[i+1]
+		//change i is between the records i and i+1
+		v.regimes[i] = VolatilityRegime{labels[lo], labels[hi],
+			stat.StdDev(changes[lo:hi], nil)}
+	}
+
+	v.relevant = true
+	v.visual = v.line(y, changes, labels)
+}
+
+//line returns the line chart of the metric and its rolling volatility with
+//the regimes shaded
+func (v *Volatility) line(y, changes []float64, labels []interface{}) visualizations.LineChart {
+	m, tm := v.ms[0], v.ms[1]
+	name := displayName(m)
+	last := v.regimes[len(v.regimes)-1]
+	change := v.Change()
+	desc := name + " has become "
+	if change >= 1 {
+		desc += formatFloat(change) + " times more volatile"
+	} else {
+		desc += formatFloat(1/change) + " times less volatile"
+	}
+	desc += " since " + axisLabel(last.Start) + ". Standard deviation of its " +
+		"changes between the records is " + formatFloat(last.StdDev)
+	vname := m.Name + "_volatility"
+	visual := visualizations.LineChart{
+		T: "Volatility of " + name,
+		D: desc,
+		M: []visualizations.Metric{
+			{Name: tm.Name, DisplayName: tm.DisplayName, DataType: tm.DataType,
+				Dimension: 0},
+			{Name: m.Name, DisplayName: m.DisplayName, DataType: Float,
+				Dimension: 1},
+			{Name: vname, DisplayName: "Rolling volatility of " + name,
+				DataType: Float, Dimension: 1},
+		},
+	}
+	for i, r := range v.regimes {
+		visual.G = append(visual.G, visualizations.Region{
+			Label: "Regime " + strconv.Itoa(i+1) + " with volatility " +
+				formatFloat(r.StdDev),
+			Start: r.Start,
+			End:   r.End,
+		})
+	}
+
+	//adding the metric and the rolling volatility
+	data := make([]map[string]interface{}, len(y))
+	for i := range y {
+		data[i] = map[string]interface{}{tm.Name: labels[i], m.Name: y[i]}
+		if i >= volatilityWindow {
+			data[i][vname] = stat.StdDev(changes[i-volatilityWindow:i], nil)
+		}
+	}
+	visual.Dt = data
+	return visual
+}
+
+//Propose suggests the possible insights from the domain knowledge.
+//Float metrics of datasets having a time metric are proposed for volatility.
+func (v *Volatility) Propose(d Dataset) []ProposedInsight {
+	result := []ProposedInsight{}
+	tm, ok := timeMetric(d)
+	if !ok {
+		return result
+	}
+	for _, m := range sortedMetrics(d) {
+		if m.DataType != Float || m.Name == tm.Name {
+			continue
+		}
+		metrics := []Metric{m, tm}
+		result = append(result, ProposedInsight{v.New(d, metrics), metrics})
+	}
+	return result
+}
+
+//varianceShifts returns the indices in the values between lo and hi at which
+//the variance shifts significantly. The values are split at the point having
+//the most significant Brown-Forsythe test between the values before and after
+//it. Then the splits are searched recursively on either side.
+func varianceShifts(x []float64, lo, hi, depth int) []int {
+	if depth >= maxRegimeSplits || hi-lo < 2*minRegimeRecords {
+		return []int{}
+	}
+	step := (hi - lo - 2*minRegimeRecords) / maxRegimeCandidates
+	if step < 1 {
+		step = 1
+	}
+	best, bestP := -1, 1.0
+	for k := lo + minRegimeRecords; k <= hi-minRegimeRecords; k += step {
+		if _, p := brownForsythe(x[lo:k], x[k:hi]); p < bestP {
+			best, bestP = k, p
+		}
+	}
+	if best == -1 || bestP >= volatilityAlpha {
+		return []int{}
+	}
+	result := varianceShifts(x, lo, best, depth+1)
+	result = append(result, best)
+	return append(result, varianceShifts(x, best, hi, depth+1)...)
+}
+
+//brownForsythe does the Brown-Forsythe test for the equality of the variances
+//of the groups. It is the one way anova of the absolute deviations of the
+//values from the median of their group. It returns the F statistic and the
+//p-value.
+func brownForsythe(groups ...[]float64) (float64, float64) {
+	/*
+		We find the absolute deviations from the group medians.
+		Then we find the sum of squares between and within the groups of the
+		deviations.
+		F = (SSB / (k - 1)) / (SSW / (N - k))
+	*/
+	k := len(groups)
+	devs := make([][]float64, k)
+	n := 0
+	total := 0.0
+	for i, g := range groups {
+		med := median(g)
+		devs[i] = make([]float64, len(g))
+		for j, x := range g {
+			devs[i][j] = math.Abs(x - med)
+			total += devs[i][j]
+		}
+		n += len(g)
+	}
+	if k < 2 || n <= k {
+		return 0, 1
+	}
+	grand := total / float64(n)
+	ssb, ssw := 0.0, 0.0
+	for _, d := range devs {
+		mean := stat.Mean(d, nil)
+		ssb += float64(len(d)) * (mean - grand) * (mean - grand)
+		for _, x := range d {
+			ssw += (x - mean) * (x - mean)
+		}
+	}
+	d1, d2 := float64(k-1), float64(n-k)
+	if ssw == 0 {
+		if ssb == 0 {
+			return 0, 1
+		}
+		return math.Inf(1), 0
+	}
+	f := (ssb / d1) / (ssw / d2)
+	return f, distuv.F{D1: d1, D2: d2}.Survival(f)
+}
+
+//axisLabel returns the string form of a label of the time axis
+func axisLabel(l interface{}) string {
+	switch t := l.(type) {
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case time.Time:
+		if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 &&
+			t.Nanosecond() == 0 {
+			return t.Format("2006-01-02")
+		}
+		return t.Format(time.RFC3339)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,132 @@
+package insights
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/cuttle-ai/brain/visualizations"
+)
+
+/*
+	This file contains the tests for the volatility insight
+*/
+
+//dailyPrices returns a dataset with the price of 100 days. The price changes
+//by the given standard deviations in the first and the last 50 days.
+func dailyPrices(before, after float64) Dataset {
+	r := rand.New(rand.NewSource(1))
+	days := make([]float64, 100)
+	prices := make([]float64, 100)
+	price := 100.0
+	for i := range days {
+		sd := before
+		if i >= 50 {
+			sd = after
+		}
+		price += r.NormFloat64() * sd
+		days[i] = float64(i)
+		prices[i] = price
+	}
+	d := NewDataset()
+	d.AddMetric(Metric{Name: "day", DataType: Float, Semantic: SemanticTime},
+		days)
+	d.AddMetric(Metric{Name: "price", DisplayName: "Price", DataType: Float},
+		prices)
+	return d
+}
+
+func TestVolatility_New(t *testing.T) {
+	d := dailyPrices(1, 1)
+	vi := (&Volatility{}).New(d, []Metric{d.Metrics["price"], d.Metrics["day"]})
+	v, ok := vi.(*Volatility)
+	if !ok {
+		t.Fatal("Expected a volatility. Got", reflect.TypeOf(vi))
+	}
+	if len(v.ms) != 2 {
+		t.Fatal("Expected 2 metrics. Got", len(v.ms))
+	}
+}
+
+func TestVolatility_Type(t *testing.T) {
+	v := &Volatility{}
+	if v.Type() != VOLATILITY {
+		t.Fatal("Expected insight type is", VOLATILITY, "Got", v.Type())
+	}
+}
+
+func TestVolatility_FSFA(t *testing.T) {
+	d := dailyPrices(1, 1)
+	v := &Volatility{dt: d, ms: []Metric{d.Metrics["price"],
+		d.Metrics["price"]}}
+	v.FSFA()
+	if v.Relevant() {
+		t.Fatal("Expected volatility to be irrelevant without time metric.",
+			"Got it as relevant")
+	}
+	v = &Volatility{dt: d, ms: []Metric{d.Metrics["price"], d.Metrics["day"]}}
+	v.FSFA()
+	if !v.Relevant() {
+		t.Fatal("Expected volatility to be relevant with normal conditions.",
+			"Got it as irrelevant")
+	}
+}
+
+func TestVolatility_Generate(t *testing.T) {
+	t.Run("Testing generate with a shift in volatility", func(t *testing.T) {
+		d := dailyPrices(1, 5)
+		v := (&Volatility{}).Propose(d)[0].I.(*Volatility)
+		v.FSFA()
+		v.Generate()
+		if !v.Relevant() {
+			t.Fatal("Expected volatility to be relevant. Got irrelevant")
+		}
+		rs := v.Regimes()
+		start := rs[len(rs)-1].Start.(float64)
+		if start < 40 || start > 60 {
+			t.Fatal("Expected the last regime to start around day 50. Got",
+				start)
+		}
+		if v.Change() < 2 {
+			t.Fatal("Expected the metric to become noisier. Got a change of",
+				v.Change())
+		}
+		l, ok := v.Visual().(visualizations.LineChart)
+		if !ok {
+			t.Fatal("Expected a line chart. Got", reflect.TypeOf(v.Visual()))
+		}
+		if len(l.Regions()) != len(rs) || len(l.Data()) != 100 {
+			t.Fatal("Expected a region for each regime and 100 records. Got",
+				len(l.Regions()), len(l.Data()))
+		}
+	})
+
+	t.Run("Testing generate with stable volatility", func(t *testing.T) {
+		d := dailyPrices(1, 1)
+		v := (&Volatility{}).Propose(d)[0].I.(*Volatility)
+		v.FSFA()
+		v.Generate()
+		if v.Relevant() {
+			t.Fatal("Expected volatility to be irrelevant with stable",
+				"volatility. Got relevant with", v.Regimes())
+		}
+	})
+}
+
+func TestVolatility_Propose(t *testing.T) {
+	d := dailyPrices(1, 1)
+	if pro := (&Volatility{}).Propose(d); len(pro) != 1 {
+		t.Fatal("Expected 1 proposal. Got", len(pro))
+	}
+}
+
+func TestBrownForsythe(t *testing.T) {
+	a := []float64{1, -1, 1, -1, 1, -1, 1, -1}
+	b := []float64{5, -5, 5, -5, 5, -5, 5, -5, 4, -4}
+	if _, p := brownForsythe(a, b); p > 0.001 {
+		t.Fatal("Expected significantly different variances. Got p-value", p)
+	}
+	if f, p := brownForsythe(a, a); f != 0 || p != 1 {
+		t.Fatal("Expected equal variances. Got", f, p)
+	}
+}
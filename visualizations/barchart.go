@@ -0,0 +1,54 @@
+package visualizations
+
+/*
+	This file has the struct and utlities required for the bar chart
+	visualization
+*/
+
+//BarChart is the bar chart visualization.
+//It is used to compare the values of metrics across categories.
+//The metric with dimension 0 has the categories plotted on the x axis.
+//Metrics with dimension 1 are plotted as bars grouped by the categories.
+type BarChart struct {
+	//M stores the metrics involved in rendering a bar chart
+	M []Metric `json:"Metrics"`
+	//T is the title of the bar chart
+	T string `json:"Title"`
+	//D is the description of the bar chart
+	D string `json:"Description"`
+	//Dt stores the data to be plotted in the bar chart
+	Dt []map[string]interface{} `json:"Data"`
+	//A has the annotations to be attached to the bars in the bar chart
+	A []Annotation `json:"Annotations"`
+}
+
+//Type returns the bar chart's type string
+func (b BarChart) Type() string {
+	return BARCHART
+}
+
+//Metrics returns the metrics involved for creating the bar chart
+func (b BarChart) Metrics() []Metric {
+	return b.M
+}
+
+//Title returns the title of the bar chart
+func (b BarChart) Title() string {
+	return b.T
+}
+
+//Description returns the description for the bar chart
+func (b BarChart) Description() string {
+	return b.D
+}
+
+//Data returns the data to be plotted in the bar chart visualization
+func (b BarChart) Data() []map[string]interface{} {
+	return b.Dt
+}
+
+//Annotations returns the annotations to be attached to the bars in the
+//bar chart
+func (b BarChart) Annotations() []Annotation {
+	return b.A
+}
@@ -0,0 +1,47 @@
+package visualizations
+
+/*
+	This file has the struct and utlities required for the bump chart
+	visualization
+*/
+
+//BumpChart is the bump chart visualization.
+//It is used to show the change in the ranking of categories over periods.
+//The metric with dimension 0 is the period on the x axis. The metric with
+//dimension 1 is the rank on the y axis with the first rank at the top. The
+//metric with dimension 2 is the category, each of which is drawn as a line.
+type BumpChart struct {
+	//M stores the metrics involved in rendering a bump chart
+	M []Metric `json:"Metrics"`
+	//T is the title of the bump chart
+	T string `json:"Title"`
+	//D is the description of the bump chart
+	D string `json:"Description"`
+	//Dt stores the rank of the categories in each period
+	Dt []map[string]interface{} `json:"Data"`
+}
+
+//Type returns the bump chart's type string
+func (b BumpChart) Type() string {
+	return BUMPCHART
+}
+
+//Metrics returns the metrics involved for creating the bump chart
+func (b BumpChart) Metrics() []Metric {
+	return b.M
+}
+
+//Title returns the title of the bump chart
+func (b BumpChart) Title() string {
+	return b.T
+}
+
+//Description returns the description for the bump chart
+func (b BumpChart) Description() string {
+	return b.D
+}
+
+//Data returns the rank of the categories in each period
+func (b BumpChart) Data() []map[string]interface{} {
+	return b.Dt
+}
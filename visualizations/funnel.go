@@ -0,0 +1,48 @@
+package visualizations
+
+/*
+	This file has the struct and utlities required for the funnel
+	visualization
+*/
+
+//Funnel is the funnel visualization.
+//It is used to show the no. of records reaching each stage of a process.
+//The metric with dimension 0 has the stages in their order and the metric
+//with dimension 1 has the no. of records reaching the stage. A metric with
+//dimension 2 if present has the segments of the records. A funnel is drawn
+//for each segment.
+type Funnel struct {
+	//M stores the metrics involved in rendering a funnel
+	M []Metric `json:"Metrics"`
+	//T is the title of the funnel
+	T string `json:"Title"`
+	//D is the description of the funnel
+	D string `json:"Description"`
+	//Dt stores the stages of the funnel
+	Dt []map[string]interface{} `json:"Data"`
+}
+
+//Type returns the funnel's type string
+func (f Funnel) Type() string {
+	return FUNNEL
+}
+
+//Metrics returns the metrics involved for creating the funnel
+func (f Funnel) Metrics() []Metric {
+	return f.M
+}
+
+//Title returns the title of the funnel
+func (f Funnel) Title() string {
+	return f.T
+}
+
+//Description returns the description for the funnel
+func (f Funnel) Description() string {
+	return f.D
+}
+
+//Data returns the stages of the funnel
+func (f Funnel) Data() []map[string]interface{} {
+	return f.Dt
+}
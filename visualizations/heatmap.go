@@ -0,0 +1,47 @@
+package visualizations
+
+/*
+	This file has the struct and utlities required for the heat map
+	visualization
+*/
+
+//HeatMap is the heat map visualization.
+//It is used to show a metric across two categorical axes as a colored grid.
+//The metrics with dimension 0 and 1 are the categories on the x and y axis
+//respectively. The metric with dimension 2 has the value used for the color
+//of the cells.
+type HeatMap struct {
+	//M stores the metrics involved in rendering a heat map
+	M []Metric `json:"Metrics"`
+	//T is the title of the heat map
+	T string `json:"Title"`
+	//D is the description of the heat map
+	D string `json:"Description"`
+	//Dt stores the cells of the heat map
+	Dt []map[string]interface{} `json:"Data"`
+}
+
+//Type returns the heat map's type string
+func (h HeatMap) Type() string {
+	return HEATMAP
+}
+
+//Metrics returns the metrics involved for creating the heat map
+func (h HeatMap) Metrics() []Metric {
+	return h.M
+}
+
+//Title returns the title of the heat map
+func (h HeatMap) Title() string {
+	return h.T
+}
+
+//Description returns the description for the heat map
+func (h HeatMap) Description() string {
+	return h.D
+}
+
+//Data returns the cells of the heat map
+func (h HeatMap) Data() []map[string]interface{} {
+	return h.Dt
+}
@@ -0,0 +1,86 @@
+package visualizations
+
+/*
+	This file has the struct and utlities required for the line chart
+	visualization
+*/
+
+//LineChart is the line chart visualization.
+//It is used to plot the metrics over a continuous axis like time.
+//The metric with dimension 0 is plotted on the x axis. Metrics with dimension
+//1 are plotted as lines. Metrics with dimension 2 and 3 are the lower and upper
+//bounds of a band like a prediction interval. Regions are the ranges of the
+//x axis to be shaded like the regimes of a metric.
+type LineChart struct {
+	//M stores the metrics involved in rendering a line chart
+	M []Metric `json:"Metrics"`
+	//T is the title of the line chart
+	T string `json:"Title"`
+	//D is the description of the line chart
+	D string `json:"Description"`
+	//Dt stores the data to be plotted in the line chart
+	Dt []map[string]interface{} `json:"Data"`
+	//R has the reference lines to be drawn in the line chart
+	R []ReferenceLine `json:"ReferenceLines"`
+	//G has the regions to be shaded in the line chart
+	G []Region `json:"Regions"`
+	//A has the annotations to be attached to the points in the line chart
+	A []Annotation `json:"Annotations"`
+}
+
+//ReferenceLine is a horizontal line drawn at a constant value
+//like a target or threshold in a chart
+type ReferenceLine struct {
+	Label string  //Label is the label of the reference line
+	Value float64 //Value is the value at which the line is to be drawn
+}
+
+//Region is a range of the x axis shaded in a chart
+type Region struct {
+	Label string //Label is the label of the region
+	//Start and End are the values of the x axis at which the region starts
+	//and ends. Both are inclusive.
+	Start interface{}
+	End   interface{}
+}
+
+//Type returns the line chart's type string
+func (l LineChart) Type() string {
+	return LINECHART
+}
+
+//Metrics returns the metrics involved for creating the line chart
+func (l LineChart) Metrics() []Metric {
+	return l.M
+}
+
+//Title returns the title of the line chart
+func (l LineChart) Title() string {
+	return l.T
+}
+
+//Description returns the description for the line chart
+func (l LineChart) Description() string {
+	return l.D
+}
+
+//Data returns the data to be plotted in the line chart visualization
+func (l LineChart) Data() []map[string]interface{} {
+	return l.Dt
+}
+
+//ReferenceLines returns the reference lines to be drawn in the line chart
+func (l LineChart) ReferenceLines() []ReferenceLine {
+	return l.R
+}
+
+//Regions returns the regions to be shaded in the line chart
+func (l LineChart) Regions() []Region {
+	return l.G
+}
+
+//Annotations returns the annotations to be attached to the points in the
+//line chart
+func (l LineChart) Annotations() []Annotation {
+	return l.A
+}
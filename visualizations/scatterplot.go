@@ -8,6 +8,9 @@ package visualizations
 //ScatterPlot is the scatter plot visualization
 //It is used to plot two continuous/ discrete variables.
 //It is often used to visualize the correlation between two variables.
+//The metrics with dimension 0 and 1 are plotted on the x and y axis
+//respectively. A metric with dimension 2 if present is used to color the
+//points by the group to which they belong.
 type ScatterPlot struct {
 	//M stores the metrics involved in rendering a scatterplot
 	M []Metric `json:"Metrics"`
@@ -0,0 +1,45 @@
+package visualizations
+
+/*
+	This file has the struct and utlities required for the table
+	visualization
+*/
+
+//Table is the table visualization.
+//It is used to show the records as rows. The metrics are the columns of the
+//table ordered by their dimension.
+type Table struct {
+	//M stores the metrics which are the columns of the table
+	M []Metric `json:"Metrics"`
+	//T is the title of the table
+	T string `json:"Title"`
+	//D is the description of the table
+	D string `json:"Description"`
+	//Dt stores the rows of the table
+	Dt []map[string]interface{} `json:"Data"`
+}
+
+//Type returns the table's type string
+func (t Table) Type() string {
+	return TABLE
+}
+
+//Metrics returns the metrics which are the columns of the table
+func (t Table) Metrics() []Metric {
+	return t.M
+}
+
+//Title returns the title of the table
+func (t Table) Title() string {
+	return t.T
+}
+
+//Description returns the description for the table
+func (t Table) Description() string {
+	return t.D
+}
+
+//Data returns the rows of the table
+func (t Table) Data() []map[string]interface{} {
+	return t.Dt
+}
@@ -9,6 +9,24 @@ const (
 	//SCATTERPLOT is the string storing the name type of the
 	//scatter plot visualization.
 	SCATTERPLOT = "SCATTERPLOT"
+	//LINECHART is the string storing the name type of the
+	//line chart visualization.
+	LINECHART = "LINECHART"
+	//BARCHART is the string storing the name type of the
+	//bar chart visualization.
+	BARCHART = "BARCHART"
+	//TABLE is the string storing the name type of the
+	//table visualization.
+	TABLE = "TABLE"
+	//HEATMAP is the string storing the name type of the
+	//heat map visualization.
+	HEATMAP = "HEATMAP"
+	//FUNNEL is the string storing the name type of the
+	//funnel visualization.
+	FUNNEL = "FUNNEL"
+	//BUMPCHART is the string storing the name type of the
+	//bump chart visualization.
+	BUMPCHART = "BUMPCHART"
 )
 
 //Visual is the interface to be implemented by any visualization
@@ -21,6 +39,17 @@ type Visual interface {
 	Data() []map[string]interface{} //Data to be visualized
 }
 
+//Annotation is a note attached to a point in a chart like a record high
+type Annotation struct {
+	Label string //Label is the text of the annotation
+	//X is the value of the metric with dimension 0 at which the annotation
+	//is to be attached like the time in a line chart or the category in a
+	//bar chart
+	X interface{}
+	//Value is the value of the plotted metric at the point
+	Value float64
+}
+
 //Metric has the basic information about a variable in the dataset
 //that can be used for rendering a visual
 type Metric struct {